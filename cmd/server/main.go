@@ -2,14 +2,18 @@
 package main
 
 import (
+	"container/list"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
 	"os/signal"
+	"runtime/pprof"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,18 +31,41 @@ const (
 	defaultWriteTimeout     = 5 * time.Second
 	defaultShutdownDeadline = 10 * time.Second
 	defaultRequestTimeout   = 2 * time.Second
+	defaultIdleTimeout      = 60 * time.Second
+	minAcceptBackoff        = 5 * time.Millisecond
+	maxAcceptBackoff        = time.Second
 )
 
 // serverConfig configures runtime behavior from environment values.
 type serverConfig struct {
-	ListenAddress    string
-	ReadTimeout      time.Duration
-	WriteTimeout     time.Duration
-	ShutdownDeadline time.Duration
-	RequestTimeout   time.Duration
-	TLSCertFile      string
-	TLSKeyFile       string
-	TLSMinVersion    uint16
+	ListenAddress         string
+	ReadTimeout           time.Duration
+	WriteTimeout          time.Duration
+	ShutdownDeadline      time.Duration
+	RequestTimeout        time.Duration
+	IdleTimeout           time.Duration
+	BodyReadTimeout       time.Duration
+	TLSEnabled            bool
+	HTTPRedirectPort      int
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSMinVersion         uint16
+	TLSClientCAFile       string
+	TLSClientAuth         tls.ClientAuthType
+	ShedHighWater         int
+	ShedLowWater          int
+	PIDFile               string
+	ConcurrentPipelining  bool
+	MaxConcurrentStreams  int
+	HandlerGoroutine      bool
+	MaxIdleConns          int
+	AllowedMethods        []string
+	ParserLimits          httpadapter.ParserLimits
+	MaxReadBytesPerSecond int
+	ServerHeader          string
+	ShutdownDiagnostics   bool
+	ShutdownGoroutineDump bool
+	PlaintextHint         bool
 }
 
 // main starts the TCP listener and accepts incoming HTTP connections.
@@ -49,11 +76,15 @@ func main() {
 	}
 
 	structuredLogger := logadapter.NewStdLogger(log.Default())
+	metrics := httpadapter.NewMetricsRegistry()
 	httpadapter.UseMiddleware(
+		httpadapter.MetricsMiddleware(metrics),
 		httpadapter.LoggingMiddleware(structuredLogger),
+		httpadapter.TimeoutBudgetMiddleware(cfg.RequestTimeout),
 		httpadapter.TimeoutMiddleware(cfg.RequestTimeout),
 		httpadapter.RecoveryMiddleware(structuredLogger),
 	)
+	httpadapter.DefaultRouter().SetMetricsRegistry(metrics)
 
 	httpadapter.RegisterRoute("GET", "/health", func(req *httpadapter.Request) *httpadapter.Response {
 		resp := httpadapter.NewResponse()
@@ -63,6 +94,8 @@ func main() {
 		return resp
 	})
 
+	httpadapter.RegisterRoute("GET", "/metrics", metrics.Handler())
+
 	httpadapter.RegisterRoute("GET", "/hello", func(req *httpadapter.Request) *httpadapter.Response {
 		resp := httpadapter.NewResponse()
 		resp.StatusCode = 200
@@ -79,29 +112,132 @@ func main() {
 		return resp
 	})
 
-	tlsCertificate, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
-	if err != nil {
-		log.Fatalf("tls certificate: %v", err)
-	}
-	tlsConfig := &tls.Config{
-		MinVersion:   cfg.TLSMinVersion,
-		Certificates: []tls.Certificate{tlsCertificate},
-	}
+	var listener net.Listener
+	if cfg.TLSEnabled {
+		tlsCertificate, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			log.Fatalf("tls certificate: %v", err)
+		}
+		tlsConfig := &tls.Config{
+			MinVersion:   cfg.TLSMinVersion,
+			Certificates: []tls.Certificate{tlsCertificate},
+			ClientAuth:   cfg.TLSClientAuth,
+		}
+		if cfg.TLSClientCAFile != "" {
+			tlsConfig.ClientCAs, err = loadCertPool(cfg.TLSClientCAFile)
+			if err != nil {
+				log.Fatalf("tls client ca: %v", err)
+			}
+		}
 
-	listener, err := tls.Listen("tcp", cfg.ListenAddress, tlsConfig)
-	if err != nil {
-		log.Fatalf("listen: %v", err)
+		listener, err = tls.Listen("tcp", cfg.ListenAddress, tlsConfig)
+		if err != nil {
+			log.Fatalf("listen: %v", err)
+		}
+		structuredLogger.Info("https adapter server listening", "address", cfg.ListenAddress, "tls_min_version", tlsVersionName(cfg.TLSMinVersion))
+	} else {
+		listener, err = net.Listen("tcp", cfg.ListenAddress)
+		if err != nil {
+			log.Fatalf("listen: %v", err)
+		}
+		structuredLogger.Info("http adapter server listening", "address", cfg.ListenAddress, "tls", "disabled")
 	}
 
-	structuredLogger.Info("https adapter server listening", "address", cfg.ListenAddress, "tls_min_version", tlsVersionName(cfg.TLSMinVersion))
-
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
 	runtime := newServerRuntime(listener, structuredLogger, cfg.ReadTimeout, cfg.WriteTimeout, cfg.ShutdownDeadline)
-	if err := runtime.serve(ctx); err != nil {
-		log.Fatalf("serve: %v", err)
+	runtime.setKeepAliveShedding(cfg.ShedHighWater, cfg.ShedLowWater)
+	runtime.pidFile = cfg.PIDFile
+	runtime.shutdownDiagnostics = cfg.ShutdownDiagnostics || cfg.ShutdownGoroutineDump
+	runtime.shutdownGoroutineDump = cfg.ShutdownGoroutineDump
+	runtime.plaintextHint = cfg.PlaintextHint
+	runtime.maxIdleConns = cfg.MaxIdleConns
+	httpadapter.SetServerOptions(httpadapter.ServerOptions{
+		Logger:                structuredLogger,
+		ShedKeepAlive:         runtime.shouldShedKeepAlive,
+		ConcurrentPipelining:  cfg.ConcurrentPipelining,
+		MaxConcurrentStreams:  cfg.MaxConcurrentStreams,
+		IdleTimeout:           cfg.IdleTimeout,
+		BodyReadTimeout:       cfg.BodyReadTimeout,
+		ReadTimeout:           cfg.ReadTimeout,
+		WriteTimeout:          cfg.WriteTimeout,
+		HandlerGoroutine:      cfg.HandlerGoroutine,
+		ConnIdle:              runtime.setConnIdle,
+		AllowedMethods:        cfg.AllowedMethods,
+		ParserLimits:          cfg.ParserLimits,
+		MaxReadBytesPerSecond: cfg.MaxReadBytesPerSecond,
+	})
+	httpadapter.SetServerHeader(cfg.ServerHeader)
+
+	var redirectDone <-chan error
+	if cfg.HTTPRedirectPort > 0 {
+		redirectAddress := ":" + strconv.Itoa(cfg.HTTPRedirectPort)
+		redirectListener, err := net.Listen("tcp", redirectAddress)
+		if err != nil {
+			log.Fatalf("listen http redirect: %v", err)
+		}
+		structuredLogger.Info("http redirect listening", "address", redirectAddress, "redirect_to", cfg.ListenAddress)
+		redirectRuntime := newHTTPRedirectRuntime(redirectListener, structuredLogger, cfg)
+		redirectDone = serveHTTPRedirect(ctx, redirectRuntime, structuredLogger, stop)
+	}
+
+	serveErr := runtime.serve(ctx)
+	stop()
+	if redirectDone != nil {
+		if err := <-redirectDone; err != nil {
+			log.Fatalf("serve http redirect: %v", err)
+		}
+	}
+	if serveErr != nil {
+		log.Fatalf("serve: %v", serveErr)
+	}
+}
+
+// serveHTTPRedirect runs the redirect runtime in the background and returns
+// the channel its serve result is sent on. A failure is logged at once and
+// shuts the whole server down through stop, rather than leaving the HTTPS
+// listener running without its redirect.
+func serveHTTPRedirect(ctx context.Context, redirect *serverRuntime, logger usecase.Logger, stop context.CancelFunc) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		err := redirect.serve(ctx)
+		if err != nil {
+			logRuntimeError(logger, "http redirect stopped", "error", err, "action", "shutdown")
+			stop()
+		}
+		done <- err
+	}()
+	return done
+}
+
+// newHTTPRedirectRuntime builds the runtime for the plain HTTP listener that
+// answers every request with a 308 redirect to the HTTPS listener. It shares
+// the timeouts and shutdown deadline of the main runtime, and reports keep-
+// alive state to its own runtime so Drain covers its connections.
+func newHTTPRedirectRuntime(listener connListener, logger usecase.Logger, cfg serverConfig) *serverRuntime {
+	httpsPort := defaultPort
+	if _, rawPort, err := net.SplitHostPort(cfg.ListenAddress); err == nil {
+		if port, err := strconv.Atoi(rawPort); err == nil {
+			httpsPort = port
+		}
+	}
+
+	runtime := newServerRuntime(listener, logger, cfg.ReadTimeout, cfg.WriteTimeout, cfg.ShutdownDeadline)
+	router := httpadapter.NewRouter()
+	router.SetNotFoundHandler(httpadapter.HTTPSRedirectHandler(httpsPort))
+	opts := httpadapter.ServerOptions{
+		Logger:        logger,
+		IdleTimeout:   cfg.IdleTimeout,
+		ReadTimeout:   cfg.ReadTimeout,
+		WriteTimeout:  cfg.WriteTimeout,
+		ShedKeepAlive: runtime.shouldShedKeepAlive,
+		ConnIdle:      runtime.setConnIdle,
 	}
+	runtime.serveConn = func(conn net.Conn, ctx context.Context) {
+		httpadapter.HandleConnWithOptions(conn, router, ctx, opts)
+	}
+	return runtime
 }
 
 // loadServerConfigFromEnv loads runtime configuration from LIGHT_SERVE_* vars.
@@ -127,28 +263,149 @@ func loadServerConfigFromEnv() (serverConfig, error) {
 	if err != nil {
 		return serverConfig{}, err
 	}
-	tlsCertFile, err := parseRequiredFileEnv("LIGHT_SERVE_TLS_CERT_FILE")
+	idleTimeout, err := parseDurationEnv("LIGHT_SERVE_IDLE_TIMEOUT", defaultIdleTimeout)
 	if err != nil {
 		return serverConfig{}, err
 	}
-	tlsKeyFile, err := parseRequiredFileEnv("LIGHT_SERVE_TLS_KEY_FILE")
+	bodyReadTimeout, err := parseDurationEnv("LIGHT_SERVE_BODY_READ_TIMEOUT", 0)
 	if err != nil {
 		return serverConfig{}, err
 	}
+	tlsEnabled, err := parseBoolEnv("LIGHT_SERVE_TLS_ENABLED", true)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	httpRedirectPort, err := parsePortEnv("LIGHT_SERVE_HTTP_REDIRECT_PORT", 0)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	if httpRedirectPort != 0 && !tlsEnabled {
+		return serverConfig{}, fmt.Errorf("LIGHT_SERVE_HTTP_REDIRECT_PORT: requires LIGHT_SERVE_TLS_ENABLED")
+	}
+	if httpRedirectPort == port {
+		return serverConfig{}, fmt.Errorf("LIGHT_SERVE_HTTP_REDIRECT_PORT: must differ from LIGHT_SERVE_PORT")
+	}
+	var tlsCertFile, tlsKeyFile string
+	if tlsEnabled {
+		if tlsCertFile, err = parseRequiredFileEnv("LIGHT_SERVE_TLS_CERT_FILE"); err != nil {
+			return serverConfig{}, err
+		}
+		if tlsKeyFile, err = parseRequiredFileEnv("LIGHT_SERVE_TLS_KEY_FILE"); err != nil {
+			return serverConfig{}, err
+		}
+	}
 	tlsMinVersion, err := parseTLSMinVersionEnv("LIGHT_SERVE_TLS_MIN_VERSION", tls.VersionTLS13)
 	if err != nil {
 		return serverConfig{}, err
 	}
+	tlsClientAuth, err := parseTLSClientAuthEnv("LIGHT_SERVE_TLS_CLIENT_AUTH", tls.NoClientCert)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	var tlsClientCAFile string
+	if strings.TrimSpace(os.Getenv("LIGHT_SERVE_TLS_CLIENT_CA_FILE")) != "" {
+		if tlsClientCAFile, err = parseRequiredFileEnv("LIGHT_SERVE_TLS_CLIENT_CA_FILE"); err != nil {
+			return serverConfig{}, err
+		}
+	}
+	if tlsClientAuth >= tls.VerifyClientCertIfGiven && tlsClientCAFile == "" {
+		return serverConfig{}, fmt.Errorf("LIGHT_SERVE_TLS_CLIENT_AUTH: verifying client certificates requires LIGHT_SERVE_TLS_CLIENT_CA_FILE")
+	}
+	shedHighWater, err := parseNonNegativeIntEnv("LIGHT_SERVE_SHED_HIGH_WATER", 0)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	shedLowWater, err := parseNonNegativeIntEnv("LIGHT_SERVE_SHED_LOW_WATER", shedHighWater/2)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	if shedLowWater > shedHighWater {
+		return serverConfig{}, fmt.Errorf("LIGHT_SERVE_SHED_LOW_WATER: must be <= LIGHT_SERVE_SHED_HIGH_WATER")
+	}
+	pidFile := strings.TrimSpace(os.Getenv("LIGHT_SERVE_PID_FILE"))
+	allowedMethods, err := parseMethodListEnv("LIGHT_SERVE_ALLOWED_METHODS")
+	if err != nil {
+		return serverConfig{}, err
+	}
+	serverHeader := strings.TrimSpace(os.Getenv("LIGHT_SERVE_SERVER_HEADER"))
+	concurrentPipelining, err := parseBoolEnv("LIGHT_SERVE_CONCURRENT_PIPELINING", false)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	maxConcurrentStreams, err := parseNonNegativeIntEnv("LIGHT_SERVE_MAX_CONCURRENT_STREAMS", httpadapter.DefaultMaxConcurrentStreams)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	if maxConcurrentStreams < 1 {
+		return serverConfig{}, fmt.Errorf("LIGHT_SERVE_MAX_CONCURRENT_STREAMS: value must be >= 1")
+	}
+	maxIdleConns, err := parseNonNegativeIntEnv("LIGHT_SERVE_MAX_IDLE_CONNS", 0)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	var parserLimits httpadapter.ParserLimits
+	if parserLimits.MaxRequestLineBytes, err = parseNonNegativeIntEnv("LIGHT_SERVE_MAX_REQUEST_LINE_BYTES", 0); err != nil {
+		return serverConfig{}, err
+	}
+	if parserLimits.MaxHeadersBytes, err = parseNonNegativeIntEnv("LIGHT_SERVE_MAX_HEADER_BYTES", 0); err != nil {
+		return serverConfig{}, err
+	}
+	if parserLimits.MaxHeaderCount, err = parseNonNegativeIntEnv("LIGHT_SERVE_MAX_HEADER_COUNT", 0); err != nil {
+		return serverConfig{}, err
+	}
+	if parserLimits.MaxBodyBytes, err = parseNonNegativeIntEnv("LIGHT_SERVE_MAX_BODY_BYTES", 0); err != nil {
+		return serverConfig{}, err
+	}
+	maxReadBytesPerSecond, err := parseNonNegativeIntEnv("LIGHT_SERVE_MAX_READ_BYTES_PER_SECOND", 0)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	handlerGoroutine, err := parseBoolEnv("LIGHT_SERVE_HANDLER_GOROUTINE", false)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	shutdownDiagnostics, err := parseBoolEnv("LIGHT_SERVE_SHUTDOWN_DIAGNOSTICS", false)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	shutdownGoroutineDump, err := parseBoolEnv("LIGHT_SERVE_SHUTDOWN_GOROUTINE_DUMP", false)
+	if err != nil {
+		return serverConfig{}, err
+	}
+	plaintextHint, err := parseBoolEnv("LIGHT_SERVE_PLAINTEXT_HINT", false)
+	if err != nil {
+		return serverConfig{}, err
+	}
 
 	return serverConfig{
-		ListenAddress:    ":" + strconv.Itoa(port),
-		ReadTimeout:      readTimeout,
-		WriteTimeout:     writeTimeout,
-		ShutdownDeadline: shutdownDeadline,
-		RequestTimeout:   requestTimeout,
-		TLSCertFile:      tlsCertFile,
-		TLSKeyFile:       tlsKeyFile,
-		TLSMinVersion:    tlsMinVersion,
+		ListenAddress:         ":" + strconv.Itoa(port),
+		ReadTimeout:           readTimeout,
+		WriteTimeout:          writeTimeout,
+		ShutdownDeadline:      shutdownDeadline,
+		RequestTimeout:        requestTimeout,
+		IdleTimeout:           idleTimeout,
+		BodyReadTimeout:       bodyReadTimeout,
+		TLSEnabled:            tlsEnabled,
+		HTTPRedirectPort:      httpRedirectPort,
+		TLSCertFile:           tlsCertFile,
+		TLSKeyFile:            tlsKeyFile,
+		TLSMinVersion:         tlsMinVersion,
+		TLSClientCAFile:       tlsClientCAFile,
+		TLSClientAuth:         tlsClientAuth,
+		ShedHighWater:         shedHighWater,
+		ShedLowWater:          shedLowWater,
+		PIDFile:               pidFile,
+		ConcurrentPipelining:  concurrentPipelining,
+		MaxConcurrentStreams:  maxConcurrentStreams,
+		HandlerGoroutine:      handlerGoroutine,
+		MaxIdleConns:          maxIdleConns,
+		AllowedMethods:        allowedMethods,
+		ServerHeader:          serverHeader,
+		ParserLimits:          parserLimits,
+		MaxReadBytesPerSecond: maxReadBytesPerSecond,
+		ShutdownDiagnostics:   shutdownDiagnostics,
+		ShutdownGoroutineDump: shutdownGoroutineDump,
+		PlaintextHint:         plaintextHint,
 	}, nil
 }
 
@@ -186,6 +443,65 @@ func parsePortEnv(envKey string, fallback int) (int, error) {
 	return port, nil
 }
 
+// parseNonNegativeIntEnv reads a non-negative integer env var with fallback default.
+func parseNonNegativeIntEnv(envKey string, fallback int) (int, error) {
+	raw := strings.TrimSpace(os.Getenv(envKey))
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid integer %q", envKey, raw)
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("%s: value must be >= 0", envKey)
+	}
+	return value, nil
+}
+
+// parseBoolEnv reads a boolean env var with fallback default.
+func parseBoolEnv(envKey string, fallback bool) (bool, error) {
+	raw := strings.TrimSpace(os.Getenv(envKey))
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s: invalid boolean %q", envKey, raw)
+	}
+	return value, nil
+}
+
+// parseListEnv reads a comma-separated env var, dropping empty entries.
+func parseListEnv(envKey string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(envKey), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseMethodListEnv reads a comma-separated list of request methods,
+// uppercasing each so it matches the case-sensitive method comparison.
+// Entries that are not HTTP tokens are rejected.
+func parseMethodListEnv(envKey string) ([]string, error) {
+	methods := parseListEnv(envKey)
+	for i, method := range methods {
+		if strings.IndexFunc(method, func(c rune) bool { return !isTokenRune(c) }) >= 0 {
+			return nil, fmt.Errorf("%s: invalid method %q", envKey, method)
+		}
+		methods[i] = strings.ToUpper(method)
+	}
+	return methods, nil
+}
+
+// isTokenRune reports whether c may appear in an HTTP token such as a method.
+func isTokenRune(c rune) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", c)
+}
+
 // parseRequiredFileEnv reads a required file path env var and checks existence.
 func parseRequiredFileEnv(envKey string) (string, error) {
 	raw := strings.TrimSpace(os.Getenv(envKey))
@@ -217,6 +533,41 @@ func parseTLSMinVersionEnv(envKey string, fallback uint16) (uint16, error) {
 	}
 }
 
+// parseTLSClientAuthEnv reads the client certificate policy from env with
+// fallback: none, request (ask without verifying), verify (verify when
+// given), or require (require and verify).
+func parseTLSClientAuthEnv(envKey string, fallback tls.ClientAuthType) (tls.ClientAuthType, error) {
+	raw := strings.TrimSpace(strings.ToLower(os.Getenv(envKey)))
+	if raw == "" {
+		return fallback, nil
+	}
+	switch raw {
+	case "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "verify":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("%s: invalid value %q (allowed: none, request, verify, require)", envKey, raw)
+	}
+}
+
+// loadCertPool reads PEM-encoded CA certificates from path into a pool.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemBytes) {
+		return nil, fmt.Errorf("%s: no PEM certificates found", path)
+	}
+	return pool, nil
+}
+
 // tlsVersionName renders TLS version constants for structured logs.
 func tlsVersionName(version uint16) string {
 	switch version {
@@ -229,28 +580,70 @@ func tlsVersionName(version uint16) string {
 	}
 }
 
+// connListener is the minimal listener behavior the runtime depends on.
+// net.Listener satisfies it; tests inject scripted fakes.
+type connListener interface {
+	Accept() (net.Conn, error)
+	Close() error
+	Addr() net.Addr
+}
+
 // serverRuntime owns accept loop and graceful shutdown lifecycle.
 type serverRuntime struct {
-	listener         net.Listener
+	listener         connListener
 	logger           usecase.Logger
 	readTimeout      time.Duration
 	writeTimeout     time.Duration
 	shutdownDeadline time.Duration
+	clock            httpadapter.Clock
 
 	wg    sync.WaitGroup
 	mu    sync.Mutex
-	conns map[net.Conn]struct{}
+	conns map[net.Conn]time.Time
+
+	// idleConns orders idle keep-alive connections from least to most
+	// recently idled; idleElems indexes it by connection.
+	maxIdleConns int
+	idleConns    *list.List
+	idleElems    map[net.Conn]*list.Element
+
+	shedHighWater int
+	shedLowWater  int
+	shedding      bool
+
+	// draining is set by Drain; drainComplete is closed once it is set and
+	// no connection remains.
+	draining      bool
+	drainComplete chan struct{}
+
+	pidFile string
+
+	shutdownDiagnostics   bool
+	shutdownGoroutineDump bool
+
+	plaintextHint bool
+
+	// serveConn serves an accepted connection once its TLS handshake, if
+	// any, is done; nil serves it with the default router and options.
+	serveConn func(conn net.Conn, ctx context.Context)
+
+	shuttingDownHooks     []func(context.Context)
+	shutdownCompleteHooks []func(context.Context)
 }
 
 // newServerRuntime constructs a runtime with lifecycle and timeout settings.
-func newServerRuntime(listener net.Listener, logger usecase.Logger, readTimeout, writeTimeout, shutdownDeadline time.Duration) *serverRuntime {
+func newServerRuntime(listener connListener, logger usecase.Logger, readTimeout, writeTimeout, shutdownDeadline time.Duration) *serverRuntime {
 	return &serverRuntime{
 		listener:         listener,
 		logger:           logger,
 		readTimeout:      readTimeout,
 		writeTimeout:     writeTimeout,
 		shutdownDeadline: shutdownDeadline,
-		conns:            make(map[net.Conn]struct{}),
+		clock:            httpadapter.SystemClock,
+		conns:            make(map[net.Conn]time.Time),
+		idleConns:        list.New(),
+		idleElems:        make(map[net.Conn]*list.Element),
+		drainComplete:    make(chan struct{}),
 	}
 }
 
@@ -258,27 +651,67 @@ func newServerRuntime(listener net.Listener, logger usecase.Logger, readTimeout,
 func (s *serverRuntime) serve(ctx context.Context) error {
 	defer s.listener.Close()
 
+	if s.pidFile != "" {
+		if err := writePIDFile(s.pidFile, s.logger); err != nil {
+			return err
+		}
+		defer removePIDFile(s.pidFile, s.logger)
+	}
+	logRuntimeInfo(s.logger, "server bound", "pid", os.Getpid(), "network", s.listener.Addr().Network(), "address", s.listener.Addr().String())
+
+	// The watcher stops accepts on cancellation and is shut down before
+	// serve returns, so it never logs after serve has finished.
+	stopWatch := make(chan struct{})
+	watchDone := make(chan struct{})
 	go func() {
-		<-ctx.Done()
-		logRuntimeInfo(s.logger, "shutdown signal received", "action", "stop_accepts")
-		_ = s.listener.Close()
+		defer close(watchDone)
+		select {
+		case <-ctx.Done():
+			logRuntimeInfo(s.logger, "shutdown signal received", "action", "stop_accepts")
+			_ = s.listener.Close()
+		case <-stopWatch:
+		}
+	}()
+	defer func() {
+		close(stopWatch)
+		<-watchDone
 	}()
 
+	var acceptErr error
+	backoff := time.Duration(0)
 	for {
 		conn, err := s.listener.Accept()
 		if err != nil {
 			if errors.Is(err, net.ErrClosed) {
 				break
 			}
-			logRuntimeError(s.logger, "accept failed", "error", err)
+			if !isTransientAcceptErr(err) {
+				logRuntimeError(s.logger, "accept failed permanently", "error", err, "action", "stop_accepts")
+				acceptErr = fmt.Errorf("accept: %w", err)
+				_ = s.listener.Close()
+				break
+			}
+
+			backoff = nextAcceptBackoff(backoff)
+			logRuntimeError(s.logger, "accept failed", "error", err, "retry_in", backoff.String())
+			select {
+			case <-ctx.Done():
+			case <-s.clock.After(backoff):
+			}
 			continue
 		}
+		backoff = 0
 
 		s.trackConn(conn)
 		s.wg.Add(1)
 		go s.handleConn(ctx, conn)
 	}
 
+	s.Drain()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), s.shutdownDeadline)
+	defer cancelShutdown()
+	s.runShutdownHooks(shutdownCtx, "shutting_down")
+
 	logRuntimeInfo(s.logger, "waiting for in-flight connections")
 	done := make(chan struct{})
 	go func() {
@@ -289,14 +722,118 @@ func (s *serverRuntime) serve(ctx context.Context) error {
 	select {
 	case <-done:
 		logRuntimeInfo(s.logger, "shutdown complete")
-	case <-time.After(s.shutdownDeadline):
+	case <-s.clock.After(s.shutdownDeadline):
 		logRuntimeError(s.logger, "shutdown deadline reached", "deadline", s.shutdownDeadline.String(), "action", "force_close_active_connections")
+		if s.shutdownDiagnostics {
+			s.logStuckConns()
+		}
 		s.closeTrackedConns()
 		<-done
 		logRuntimeInfo(s.logger, "shutdown complete after forced close")
 	}
+	s.runShutdownHooks(shutdownCtx, "shutdown_complete")
 
-	return nil
+	return acceptErr
+}
+
+// OnShuttingDown registers a hook run once accepts have stopped and before
+// in-flight connections are drained, e.g. to mark the service unready.
+// Hooks run in registration order with a context bounded by the shutdown
+// deadline.
+func (s *serverRuntime) OnShuttingDown(hook func(ctx context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shuttingDownHooks = append(s.shuttingDownHooks, hook)
+}
+
+// OnShutdownComplete registers a hook run after in-flight connections have
+// drained or been force-closed, e.g. to close database pools. It receives
+// the same shutdown context as OnShuttingDown hooks.
+func (s *serverRuntime) OnShutdownComplete(hook func(ctx context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shutdownCompleteHooks = append(s.shutdownCompleteHooks, hook)
+}
+
+// Drain stops keeping connections alive ahead of a shutdown: every later
+// response carries Connection: close, so busy keep-alive connections close
+// after their current request, and idle ones are closed at once. New
+// connections are still accepted and served one request each. Calling
+// Drain again has no effect.
+func (s *serverRuntime) Drain() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return
+	}
+	s.draining = true
+	logRuntimeInfo(s.logger, "draining connections", "active_connections", len(s.conns))
+	for s.idleConns.Len() > 0 {
+		conn := s.idleConns.Front().Value.(net.Conn)
+		s.removeIdleLocked(conn)
+		_ = conn.Close()
+	}
+	s.checkDrainedLocked()
+}
+
+// DrainComplete returns a channel closed once Drain has been called and the
+// last connection has closed. It fires before the shutdown-complete hooks
+// when draining is part of a shutdown.
+func (s *serverRuntime) DrainComplete() <-chan struct{} {
+	return s.drainComplete
+}
+
+// checkDrainedLocked closes drainComplete when draining has finished.
+func (s *serverRuntime) checkDrainedLocked() {
+	if !s.draining || len(s.conns) > 0 {
+		return
+	}
+	select {
+	case <-s.drainComplete:
+	default:
+		close(s.drainComplete)
+		logRuntimeInfo(s.logger, "drain complete")
+	}
+}
+
+// runShutdownHooks runs the hooks registered for phase in order.
+func (s *serverRuntime) runShutdownHooks(ctx context.Context, phase string) {
+	s.mu.Lock()
+	registered := s.shuttingDownHooks
+	if phase == "shutdown_complete" {
+		registered = s.shutdownCompleteHooks
+	}
+	hooks := make([]func(context.Context), len(registered))
+	copy(hooks, registered)
+	s.mu.Unlock()
+
+	if len(hooks) > 0 {
+		logRuntimeInfo(s.logger, "running shutdown hooks", "phase", phase, "count", len(hooks))
+	}
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+}
+
+// isTransientAcceptErr reports whether an accept error is worth retrying.
+func isTransientAcceptErr(err error) bool {
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// nextAcceptBackoff doubles the accept retry delay within configured bounds.
+func nextAcceptBackoff(current time.Duration) time.Duration {
+	if current <= 0 {
+		return minAcceptBackoff
+	}
+	if next := current * 2; next < maxAcceptBackoff {
+		return next
+	}
+	return maxAcceptBackoff
 }
 
 // handleConn sets per-connection deadlines and delegates request handling.
@@ -314,6 +851,7 @@ func (s *serverRuntime) handleConn(ctx context.Context, conn net.Conn) {
 		}
 	}()
 
+	// Socket deadlines are enforced by the OS against wall time, not s.clock.
 	if s.readTimeout > 0 {
 		_ = conn.SetReadDeadline(time.Now().Add(s.readTimeout))
 	}
@@ -321,14 +859,62 @@ func (s *serverRuntime) handleConn(ctx context.Context, conn net.Conn) {
 		_ = conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
 	}
 
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			s.rejectHandshake(conn, err)
+			_ = conn.Close()
+			return
+		}
+	}
+
+	if s.serveConn != nil {
+		s.serveConn(conn, ctx)
+		return
+	}
 	httpadapter.HandleConnWithContext(conn, ctx)
 }
 
+// plaintextOnTLSResponse tells a plaintext HTTP client to retry over HTTPS.
+const plaintextOnTLSResponse = "HTTP/1.0 400 Bad Request\r\nContent-Type: text/plain\r\nConnection: close\r\n\r\n" +
+	"Client sent an HTTP request to an HTTPS server.\n"
+
+// rejectHandshake logs a failed TLS handshake. A plaintext HTTP client is
+// told to use HTTPS when plaintextHint is enabled; otherwise the connection
+// is simply closed.
+func (s *serverRuntime) rejectHandshake(conn net.Conn, err error) {
+	remote := ""
+	if addr := conn.RemoteAddr(); addr != nil {
+		remote = addr.String()
+	}
+
+	var recordErr tls.RecordHeaderError
+	if !errors.As(err, &recordErr) || recordErr.Conn == nil || !looksLikeHTTP(recordErr.RecordHeader) {
+		logRuntimeError(s.logger, "tls handshake failed", "remote_addr", remote, "error", err)
+		return
+	}
+
+	logRuntimeError(s.logger, "tls handshake failed", "remote_addr", remote, "reason", "plaintext_http", "action", "reject")
+	if s.plaintextHint {
+		_, _ = io.WriteString(recordErr.Conn, plaintextOnTLSResponse)
+	}
+}
+
+// looksLikeHTTP reports whether a TLS record header holds the start of an
+// HTTP/1.x request line rather than a ClientHello.
+func looksLikeHTTP(header [5]byte) bool {
+	switch string(header[:]) {
+	case "GET /", "HEAD ", "POST ", "PUT /", "OPTIO", "DELET", "PATCH", "CONNE", "TRACE":
+		return true
+	}
+	return false
+}
+
 // trackConn adds a connection to the active set.
 func (s *serverRuntime) trackConn(conn net.Conn) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.conns[conn] = struct{}{}
+	s.conns[conn] = s.clock.Now()
+	s.updateSheddingLocked()
 }
 
 // untrackConn removes a connection from the active set.
@@ -336,6 +922,90 @@ func (s *serverRuntime) untrackConn(conn net.Conn) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.conns, conn)
+	s.removeIdleLocked(conn)
+	s.updateSheddingLocked()
+	s.checkDrainedLocked()
+}
+
+// setConnIdle records a connection's keep-alive idle state. When more than
+// maxIdleConns are idle the least recently idled one is closed; connections
+// serving a request are never evicted.
+func (s *serverRuntime) setConnIdle(conn net.Conn, idle bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !idle {
+		s.removeIdleLocked(conn)
+		return
+	}
+	if _, tracked := s.conns[conn]; !tracked {
+		return
+	}
+	if s.draining {
+		_ = conn.Close()
+		return
+	}
+	if elem, ok := s.idleElems[conn]; ok {
+		s.idleConns.MoveToBack(elem)
+	} else {
+		s.idleElems[conn] = s.idleConns.PushBack(conn)
+	}
+
+	if s.maxIdleConns <= 0 || s.idleConns.Len() <= s.maxIdleConns {
+		return
+	}
+	oldest := s.idleConns.Front().Value.(net.Conn)
+	s.removeIdleLocked(oldest)
+	_ = oldest.Close()
+	remote := ""
+	if addr := oldest.RemoteAddr(); addr != nil {
+		remote = addr.String()
+	}
+	logRuntimeInfo(s.logger, "evicted idle keep-alive connection", "remote_addr", remote, "max_idle_conns", s.maxIdleConns)
+}
+
+// removeIdleLocked drops a connection from the idle list if present.
+func (s *serverRuntime) removeIdleLocked(conn net.Conn) {
+	if elem, ok := s.idleElems[conn]; ok {
+		s.idleConns.Remove(elem)
+		delete(s.idleElems, conn)
+	}
+}
+
+// setKeepAliveShedding configures keep-alive shedding watermarks; high <= 0 disables it.
+func (s *serverRuntime) setKeepAliveShedding(high, low int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shedHighWater = high
+	s.shedLowWater = low
+	s.updateSheddingLocked()
+}
+
+// shouldShedKeepAlive reports whether responses should close their
+// connection, because of load shedding or draining.
+func (s *serverRuntime) shouldShedKeepAlive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shedding || s.draining
+}
+
+// updateSheddingLocked applies watermark hysteresis to the active connection count.
+// Shedding starts above the high-water mark and stops at or below the low-water mark.
+func (s *serverRuntime) updateSheddingLocked() {
+	if s.shedHighWater <= 0 {
+		s.shedding = false
+		return
+	}
+
+	active := len(s.conns)
+	switch {
+	case !s.shedding && active > s.shedHighWater:
+		s.shedding = true
+		logRuntimeInfo(s.logger, "keep-alive shedding started", "active_connections", active, "high_water", s.shedHighWater)
+	case s.shedding && active <= s.shedLowWater:
+		s.shedding = false
+		logRuntimeInfo(s.logger, "keep-alive shedding stopped", "active_connections", active, "low_water", s.shedLowWater)
+	}
 }
 
 // closeTrackedConns force closes all currently tracked active connections.
@@ -347,6 +1017,72 @@ func (s *serverRuntime) closeTrackedConns() {
 	}
 }
 
+// logStuckConns logs each still-active connection's remote address and age,
+// plus a full goroutine dump when shutdownGoroutineDump is enabled.
+func (s *serverRuntime) logStuckConns() {
+	s.mu.Lock()
+	now := s.clock.Now()
+	for conn, acceptedAt := range s.conns {
+		remote := "unknown"
+		if addr := conn.RemoteAddr(); addr != nil {
+			remote = addr.String()
+		}
+		logRuntimeError(s.logger, "connection still active at shutdown deadline",
+			"remote_addr", remote,
+			"active_for", now.Sub(acceptedAt).String(),
+		)
+	}
+	s.mu.Unlock()
+
+	if !s.shutdownGoroutineDump {
+		return
+	}
+	var dump strings.Builder
+	if err := pprof.Lookup("goroutine").WriteTo(&dump, 2); err != nil {
+		logRuntimeError(s.logger, "goroutine dump failed", "error", err)
+		return
+	}
+	logRuntimeError(s.logger, "goroutine dump at shutdown deadline", "goroutines", dump.String())
+}
+
+// writePIDFile records the current process ID, replacing a stale file whose
+// process is no longer running.
+func writePIDFile(path string, logger usecase.Logger) error {
+	if raw, err := os.ReadFile(path); err == nil {
+		pid, convErr := strconv.Atoi(strings.TrimSpace(string(raw)))
+		if convErr == nil && pid != os.Getpid() && processAlive(pid) {
+			return fmt.Errorf("pid file %s: process %d is still running", path, pid)
+		}
+		logRuntimeInfo(logger, "replacing stale pid file", "path", path, "stale_pid", strings.TrimSpace(string(raw)))
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("pid file %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return fmt.Errorf("pid file %s: %w", path, err)
+	}
+	return nil
+}
+
+// removePIDFile deletes the PID file written at startup.
+func removePIDFile(path string, logger usecase.Logger) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logRuntimeError(logger, "pid file removal failed", "path", path, "error", err)
+	}
+}
+
+// processAlive reports whether a process with the given PID appears to be running.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
 // logRuntimeInfo logs runtime lifecycle events when a logger is configured.
 func logRuntimeInfo(logger usecase.Logger, msg string, keysAndValues ...any) {
 	if logger == nil {
@@ -1,18 +1,31 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"io"
 	"log"
+	"math/big"
 	"net"
+	nethttp "net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	httpadapter "github.com/jamalishaq/light_serve/internal/adapter/http"
 	logadapter "github.com/jamalishaq/light_serve/internal/adapter/logging"
 )
 
@@ -86,6 +99,348 @@ func TestServerRuntime_ServeForcesCloseOnShutdownDeadline(t *testing.T) {
 	}
 }
 
+// TestServerRuntime_ShutdownDiagnosticsLogStuckConns verifies stuck connections are described at the deadline.
+func TestServerRuntime_ShutdownDiagnosticsLogStuckConns(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	handlerStarted := make(chan struct{}, 1)
+	router := httpadapter.NewRouter()
+	router.Register("GET", "/stuck-at-shutdown", func(req *httpadapter.Request) *httpadapter.Response {
+		handlerStarted <- struct{}{}
+		time.Sleep(200 * time.Millisecond)
+		return httpadapter.NewResponse()
+	})
+	previousRouter := httpadapter.DefaultRouter()
+	httpadapter.SetDefaultRouter(router)
+	t.Cleanup(func() { httpadapter.SetDefaultRouter(previousRouter) })
+
+	var logs bytes.Buffer
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(&logs, "", 0)), 0, 0, 50*time.Millisecond)
+	runtime.shutdownDiagnostics = true
+	runtime.shutdownGoroutineDump = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer clientConn.Close()
+	if _, err := clientConn.Write([]byte("GET /stuck-at-shutdown HTTP/1.1\r\nHost: example.com\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	<-handlerStarted
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected nil serve error, got %v", err)
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, "connection still active at shutdown deadline") {
+		t.Fatalf("expected stuck connection diagnostic, got %q", output)
+	}
+	if !strings.Contains(output, "remote_addr="+clientConn.LocalAddr().String()) {
+		t.Fatalf("expected client remote address in diagnostic, got %q", output)
+	}
+	if !strings.Contains(output, "goroutine dump at shutdown deadline") {
+		t.Fatalf("expected goroutine dump, got %q", output)
+	}
+}
+
+// TestServerRuntime_ShutdownHooksRunAroundDrain verifies hooks fire before and after in-flight work drains.
+func TestServerRuntime_ShutdownHooksRunAroundDrain(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	handlerStarted := make(chan struct{}, 1)
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	router := httpadapter.NewRouter()
+	router.Register("GET", "/in-flight-at-shutdown", func(req *httpadapter.Request) *httpadapter.Response {
+		handlerStarted <- struct{}{}
+		time.Sleep(100 * time.Millisecond)
+		record("handler_done")
+		return httpadapter.NewResponse()
+	})
+	previousRouter := httpadapter.DefaultRouter()
+	httpadapter.SetDefaultRouter(router)
+	t.Cleanup(func() { httpadapter.SetDefaultRouter(previousRouter) })
+
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), 0, 0, time.Second)
+	runtime.OnShuttingDown(func(ctx context.Context) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("expected shutdown context to carry the deadline")
+		}
+		record("shutting_down")
+	})
+	runtime.OnShutdownComplete(func(ctx context.Context) { record("shutdown_complete") })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer clientConn.Close()
+	if _, err := clientConn.Write([]byte("GET /in-flight-at-shutdown HTTP/1.1\r\nHost: example.com\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	<-handlerStarted
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected nil serve error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(events, ","); got != "shutting_down,handler_done,shutdown_complete" {
+		t.Fatalf("expected hooks around the drain, got %s", got)
+	}
+}
+
+// TestHTTPRedirectRuntime_RedirectsToHTTPS verifies the redirect listener answers 308 and shuts down with the context.
+func TestHTTPRedirectRuntime_RedirectsToHTTPS(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	cfg := serverConfig{ListenAddress: ":8443", ShutdownDeadline: time.Second}
+	runtime := newHTTPRedirectRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer clientConn.Close()
+	if _, err := clientConn.Write([]byte("GET /login?next=%2Fhome HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	resp := string(respBytes)
+	if !strings.HasPrefix(resp, "HTTP/1.1 308 Permanent Redirect\r\n") {
+		t.Fatalf("expected 308 redirect, got %q", resp)
+	}
+	if !strings.Contains(resp, "Location: https://example.com:8443/login?next=%2Fhome\r\n") {
+		t.Fatalf("expected HTTPS Location, got %q", resp)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected nil serve error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("redirect runtime did not stop after context cancellation")
+	}
+}
+
+// TestHTTPRedirectRuntime_DrainClosesIdleConns verifies Drain closes idle redirect connections and answers with Connection: close.
+func TestHTTPRedirectRuntime_DrainClosesIdleConns(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	cfg := serverConfig{ListenAddress: ":8443", ShutdownDeadline: time.Second}
+	runtime := newHTTPRedirectRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer clientConn.Close()
+	if _, err := clientConn.Write([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	_ = clientConn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := clientConn.Read(make([]byte, 1024)); err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	waitForIdleCount(t, runtime, 1, time.Second)
+
+	runtime.Drain()
+	if _, err := clientConn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the idle redirect connection to be closed, got %v", err)
+	}
+	if got := sendKeepAliveRequest(t, listener.Addr().String()); !strings.Contains(got, "Connection: close\r\n") {
+		t.Fatalf("expected Connection: close while draining, got %q", got)
+	}
+}
+
+// TestServerRuntime_KeepAliveOutlivesWriteTimeout verifies a keep-alive request arriving after the write timeout still gets its response.
+func TestServerRuntime_KeepAliveOutlivesWriteTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	router := httpadapter.NewRouter()
+	router.Register("GET", "/keep-alive-deadlines", func(req *httpadapter.Request) *httpadapter.Response {
+		return httpadapter.NewResponse().WriteString("ok")
+	})
+	previousRouter := httpadapter.DefaultRouter()
+	httpadapter.SetDefaultRouter(router)
+	t.Cleanup(func() { httpadapter.SetDefaultRouter(previousRouter) })
+
+	const readTimeout, writeTimeout = 200 * time.Millisecond, 200 * time.Millisecond
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), readTimeout, writeTimeout, time.Second)
+	httpadapter.SetServerOptions(httpadapter.ServerOptions{
+		IdleTimeout:  5 * time.Second,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		ConnIdle:     runtime.setConnIdle,
+	})
+	defer httpadapter.SetServerOptions(httpadapter.ServerOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer clientConn.Close()
+	reader := bufio.NewReader(clientConn)
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			// Outlast both timeouts while idle, but stay within IdleTimeout.
+			time.Sleep(2 * writeTimeout)
+		}
+		if _, err := clientConn.Write([]byte("GET /keep-alive-deadlines HTTP/1.1\r\nHost: example.com\r\n\r\n")); err != nil {
+			t.Fatalf("write request %d failed: %v", i+1, err)
+		}
+		_ = clientConn.SetReadDeadline(time.Now().Add(time.Second))
+		resp, err := nethttp.ReadResponse(reader, nil)
+		if err != nil {
+			t.Fatalf("read response %d failed: %v", i+1, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != 200 || string(body) != "ok" {
+			t.Fatalf("expected response %d to be 200 ok, got %d %q", i+1, resp.StatusCode, string(body))
+		}
+	}
+}
+
+// TestServeHTTPRedirect_FailureStopsServer verifies a failed redirect runtime is logged and stops the server at once.
+func TestServeHTTPRedirect_FailureStopsServer(t *testing.T) {
+	permanent := errors.New("listener broken")
+	var logs bytes.Buffer
+	logger := logadapter.NewStdLogger(log.New(&logs, "", 0))
+	redirect := newServerRuntime(newFakeListener(fakeAccept{err: permanent}), logger, 0, 0, 100*time.Millisecond)
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	done := serveHTTPRedirect(ctx, redirect, logger, stop)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected the redirect failure to stop the server")
+	}
+	if err := <-done; !errors.Is(err, permanent) {
+		t.Fatalf("expected the accept error, got %v", err)
+	}
+	if !strings.Contains(logs.String(), `msg="http redirect stopped"`) {
+		t.Fatalf("expected the failure to be logged, got %q", logs.String())
+	}
+}
+
+// TestServerRuntime_PlaintextOnTLSPortIsRejected verifies plaintext HTTP on the TLS port gets a logged, clean rejection.
+func TestServerRuntime_PlaintextOnTLSPortIsRejected(t *testing.T) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", newTestTLSConfig(t))
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	var logs bytes.Buffer
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(&logs, "", 0)), time.Second, time.Second, 100*time.Millisecond)
+	runtime.plaintextHint = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET /health HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	resp, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	if !strings.HasPrefix(string(resp), "HTTP/1.0 400 Bad Request\r\n") || !strings.Contains(string(resp), "HTTPS") {
+		t.Fatalf("expected plaintext HTTPS hint, got %q", string(resp))
+	}
+
+	waitForConnCount(t, runtime, 0, time.Second)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected nil serve error, got %v", err)
+	}
+	if !strings.Contains(logs.String(), "reason=plaintext_http") {
+		t.Fatalf("expected plaintext rejection to be logged, got %q", logs.String())
+	}
+}
+
 // TestServerRuntime_HandleConnSetsDeadlines verifies configured deadlines are applied.
 func TestServerRuntime_HandleConnSetsDeadlines(t *testing.T) {
 	conn := &spyConn{}
@@ -103,6 +458,286 @@ func TestServerRuntime_HandleConnSetsDeadlines(t *testing.T) {
 	}
 }
 
+// TestServerRuntime_KeepAliveSheddingHysteresis verifies responses switch to close above the high-water mark.
+func TestServerRuntime_KeepAliveSheddingHysteresis(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), time.Second, time.Second, 100*time.Millisecond)
+	runtime.setKeepAliveShedding(2, 1)
+	httpadapter.SetServerOptions(httpadapter.ServerOptions{ShedKeepAlive: runtime.shouldShedKeepAlive})
+	defer httpadapter.SetServerOptions(httpadapter.ServerOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	idle := make([]net.Conn, 0, 2)
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		idle = append(idle, conn)
+	}
+	waitForConnCount(t, runtime, 2, time.Second)
+
+	if got := sendKeepAliveRequest(t, listener.Addr().String()); !strings.Contains(got, "Connection: close\r\n") {
+		t.Fatalf("expected Connection: close above high-water mark, got %q", got)
+	}
+
+	for _, conn := range idle {
+		conn.Close()
+	}
+	waitForConnCount(t, runtime, 0, time.Second)
+
+	if got := sendKeepAliveRequest(t, listener.Addr().String()); !strings.Contains(got, "Connection: keep-alive\r\n") {
+		t.Fatalf("expected keep-alive after dropping below low-water mark, got %q", got)
+	}
+}
+
+// TestServerRuntime_MaxIdleConnsEvictsOldestIdle verifies the longest-idle keep-alive connection is closed past the cap.
+func TestServerRuntime_MaxIdleConnsEvictsOldestIdle(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), 5*time.Second, 5*time.Second, 100*time.Millisecond)
+	runtime.maxIdleConns = 2
+	httpadapter.SetServerOptions(httpadapter.ServerOptions{ConnIdle: runtime.setConnIdle})
+	defer httpadapter.SetServerOptions(httpadapter.ServerOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	conns := make([]net.Conn, 0, 3)
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+
+		if _, err := conn.Write([]byte("GET /idle-probe HTTP/1.1\r\nHost: example.com\r\n\r\n")); err != nil {
+			t.Fatalf("write request failed: %v", err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := conn.Read(make([]byte, 1024)); err != nil {
+			t.Fatalf("read response failed: %v", err)
+		}
+		waitForIdleCount(t, runtime, min(i+1, 2), time.Second)
+	}
+
+	_ = conns[0].SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conns[0].Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected oldest idle connection to be closed, got %v", err)
+	}
+	for _, conn := range conns[1:] {
+		_ = conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+		var netErr net.Error
+		if _, err := conn.Read(make([]byte, 1)); !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("expected newer idle connection to stay open, got %v", err)
+		}
+	}
+}
+
+// TestServerRuntime_DrainClosesConnectionsAndSignalsComplete verifies draining closes idle and finished connections, then fires DrainComplete.
+func TestServerRuntime_DrainClosesConnectionsAndSignalsComplete(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), 5*time.Second, 5*time.Second, 100*time.Millisecond)
+	httpadapter.SetServerOptions(httpadapter.ServerOptions{ShedKeepAlive: runtime.shouldShedKeepAlive, ConnIdle: runtime.setConnIdle})
+	defer httpadapter.SetServerOptions(httpadapter.ServerOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	idle, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer idle.Close()
+	if _, err := idle.Write([]byte("GET /drain-probe HTTP/1.1\r\nHost: example.com\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	_ = idle.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := idle.Read(make([]byte, 1024)); err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	waitForIdleCount(t, runtime, 1, time.Second)
+
+	busy, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer busy.Close()
+	waitForConnCount(t, runtime, 2, time.Second)
+
+	runtime.Drain()
+	_ = idle.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := idle.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the idle connection to be closed on drain, got %v", err)
+	}
+	select {
+	case <-runtime.DrainComplete():
+		t.Fatalf("expected drain to wait for the open connection")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if _, err := busy.Write([]byte("GET /drain-probe HTTP/1.1\r\nHost: example.com\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	_ = busy.SetReadDeadline(time.Now().Add(time.Second))
+	response, err := io.ReadAll(busy)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	if !strings.Contains(string(response), "Connection: close\r\n") {
+		t.Fatalf("expected Connection: close while draining, got %q", response)
+	}
+
+	select {
+	case <-runtime.DrainComplete():
+	case <-time.After(time.Second):
+		t.Fatalf("expected DrainComplete after the last connection closed")
+	}
+	select {
+	case <-done:
+		t.Fatalf("expected serve to keep running after the drain")
+	default:
+	}
+}
+
+// TestServerRuntime_PIDFileLifecycle verifies the PID file is written on start and removed on stop.
+func TestServerRuntime_PIDFileLifecycle(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	pidFile := filepath.Join(t.TempDir(), "light_serve.pid")
+	if err := os.WriteFile(pidFile, []byte("999999999\n"), 0o644); err != nil {
+		t.Fatalf("write stale pid file: %v", err)
+	}
+
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), 0, 0, 100*time.Millisecond)
+	runtime.pidFile = pidFile
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+
+	wantPID := strconv.Itoa(os.Getpid())
+	deadline := time.Now().Add(time.Second)
+	for {
+		raw, err := os.ReadFile(pidFile)
+		if err == nil && strings.TrimSpace(string(raw)) == wantPID {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected pid file to contain %s, got %q (err %v)", wantPID, string(raw), err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected nil serve error, got %v", err)
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Fatalf("expected pid file to be removed, got %v", err)
+	}
+}
+
+// TestServerRuntime_TransientAcceptErrorsBackOff verifies transient accept errors are retried with backoff.
+func TestServerRuntime_TransientAcceptErrorsBackOff(t *testing.T) {
+	listener := newFakeListener(
+		fakeAccept{err: temporaryAcceptErr{}},
+		fakeAccept{err: temporaryAcceptErr{}},
+		fakeAccept{conn: &spyConn{}},
+	)
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), 0, 0, 100*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startedAt := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(ctx)
+	}()
+
+	select {
+	case <-listener.drained:
+	case <-time.After(time.Second):
+		t.Fatalf("expected all scripted accepts to be consumed")
+	}
+	if elapsed := time.Since(startedAt); elapsed < minAcceptBackoff*3 {
+		t.Fatalf("expected accept retries to back off at least %s, took %s", minAcceptBackoff*3, elapsed)
+	}
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected serve to keep running after transient errors, got %v", err)
+	default:
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected nil serve error, got %v", err)
+	}
+}
+
+// TestServerRuntime_PermanentAcceptErrorStopsServe verifies permanent accept errors end the loop.
+func TestServerRuntime_PermanentAcceptErrorStopsServe(t *testing.T) {
+	permanent := errors.New("listener broken")
+	listener := newFakeListener(fakeAccept{err: permanent})
+	runtime := newServerRuntime(listener, logadapter.NewStdLogger(log.New(io.Discard, "", 0)), 0, 0, 100*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.serve(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, permanent) {
+			t.Fatalf("expected permanent accept error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected serve to stop after a permanent accept error")
+	}
+}
+
 // TestLoadServerConfigFromEnv_Defaults verifies defaults when env vars are unset.
 func TestLoadServerConfigFromEnv_Defaults(t *testing.T) {
 	certFile, keyFile := createTempTLSFiles(t)
@@ -111,6 +746,7 @@ func TestLoadServerConfigFromEnv_Defaults(t *testing.T) {
 	t.Setenv("LIGHT_SERVE_WRITE_TIMEOUT", "")
 	t.Setenv("LIGHT_SERVE_SHUTDOWN_DEADLINE", "")
 	t.Setenv("LIGHT_SERVE_REQUEST_TIMEOUT", "")
+	t.Setenv("LIGHT_SERVE_IDLE_TIMEOUT", "")
 	t.Setenv("LIGHT_SERVE_TLS_CERT_FILE", certFile)
 	t.Setenv("LIGHT_SERVE_TLS_KEY_FILE", keyFile)
 	t.Setenv("LIGHT_SERVE_TLS_MIN_VERSION", "")
@@ -135,12 +771,106 @@ func TestLoadServerConfigFromEnv_Defaults(t *testing.T) {
 	if cfg.RequestTimeout != defaultRequestTimeout {
 		t.Fatalf("expected default request timeout %s, got %s", defaultRequestTimeout, cfg.RequestTimeout)
 	}
+	if cfg.IdleTimeout != defaultIdleTimeout {
+		t.Fatalf("expected default idle timeout %s, got %s", defaultIdleTimeout, cfg.IdleTimeout)
+	}
+	if !cfg.TLSEnabled {
+		t.Fatalf("expected tls to be enabled by default")
+	}
 	if cfg.TLSCertFile != certFile {
 		t.Fatalf("expected tls cert file %q, got %q", certFile, cfg.TLSCertFile)
 	}
 	if cfg.TLSKeyFile != keyFile {
 		t.Fatalf("expected tls key file %q, got %q", keyFile, cfg.TLSKeyFile)
 	}
+	if cfg.ConcurrentPipelining {
+		t.Fatalf("expected concurrent pipelining to be disabled by default")
+	}
+	if cfg.MaxConcurrentStreams != httpadapter.DefaultMaxConcurrentStreams {
+		t.Fatalf("expected default max concurrent streams %d, got %d", httpadapter.DefaultMaxConcurrentStreams, cfg.MaxConcurrentStreams)
+	}
+}
+
+// TestLoadServerConfigFromEnv_TLSClientAuth verifies each client auth mode and the client CA file are parsed.
+func TestLoadServerConfigFromEnv_TLSClientAuth(t *testing.T) {
+	certFile, keyFile := createTempTLSFiles(t)
+	t.Setenv("LIGHT_SERVE_TLS_CERT_FILE", certFile)
+	t.Setenv("LIGHT_SERVE_TLS_KEY_FILE", keyFile)
+	t.Setenv("LIGHT_SERVE_TLS_CLIENT_CA_FILE", certFile)
+
+	tests := []struct {
+		value string
+		want  tls.ClientAuthType
+	}{
+		{value: "", want: tls.NoClientCert},
+		{value: "none", want: tls.NoClientCert},
+		{value: "request", want: tls.RequestClientCert},
+		{value: "Verify", want: tls.VerifyClientCertIfGiven},
+		{value: "require", want: tls.RequireAndVerifyClientCert},
+	}
+	for _, tt := range tests {
+		t.Setenv("LIGHT_SERVE_TLS_CLIENT_AUTH", tt.value)
+		cfg, err := loadServerConfigFromEnv()
+		if err != nil {
+			t.Fatalf("client auth %q: unexpected config error: %v", tt.value, err)
+		}
+		if cfg.TLSClientAuth != tt.want {
+			t.Fatalf("client auth %q: expected %v, got %v", tt.value, tt.want, cfg.TLSClientAuth)
+		}
+		if cfg.TLSClientCAFile != certFile {
+			t.Fatalf("expected client ca file %q, got %q", certFile, cfg.TLSClientCAFile)
+		}
+	}
+}
+
+// TestLoadCertPool verifies PEM CA files load and files without certificates are rejected.
+func TestLoadCertPool(t *testing.T) {
+	der := newTestTLSConfig(t).Certificates[0].Certificate[0]
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write ca file: %v", err)
+	}
+	if _, err := loadCertPool(caFile); err != nil {
+		t.Fatalf("unexpected error loading ca file: %v", err)
+	}
+
+	bogusFile := filepath.Join(dir, "bogus.pem")
+	if err := os.WriteFile(bogusFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("write bogus file: %v", err)
+	}
+	if _, err := loadCertPool(bogusFile); err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Fatalf("expected no PEM certificates error, got %v", err)
+	}
+}
+
+// TestLoadServerConfigFromEnv_PlainHTTPMode verifies cert and key are only required with TLS enabled.
+func TestLoadServerConfigFromEnv_PlainHTTPMode(t *testing.T) {
+	t.Setenv("LIGHT_SERVE_TLS_ENABLED", "false")
+	t.Setenv("LIGHT_SERVE_TLS_CERT_FILE", "")
+	t.Setenv("LIGHT_SERVE_TLS_KEY_FILE", "")
+
+	cfg, err := loadServerConfigFromEnv()
+	if err != nil {
+		t.Fatalf("expected plain http config without cert and key, got %v", err)
+	}
+	if cfg.TLSEnabled {
+		t.Fatalf("expected tls to be disabled")
+	}
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		t.Fatalf("expected no tls files in plain http mode, got %q and %q", cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+
+	t.Setenv("LIGHT_SERVE_HTTP_REDIRECT_PORT", "8081")
+	if _, err := loadServerConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "requires LIGHT_SERVE_TLS_ENABLED") {
+		t.Fatalf("expected redirect port to require tls, got %v", err)
+	}
+	t.Setenv("LIGHT_SERVE_HTTP_REDIRECT_PORT", "")
+
+	t.Setenv("LIGHT_SERVE_TLS_ENABLED", "true")
+	if _, err := loadServerConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "LIGHT_SERVE_TLS_CERT_FILE: value is required") {
+		t.Fatalf("expected missing cert error with tls enabled, got %v", err)
+	}
 }
 
 // TestLoadServerConfigFromEnv_Overrides verifies valid env overrides are parsed.
@@ -151,9 +881,22 @@ func TestLoadServerConfigFromEnv_Overrides(t *testing.T) {
 	t.Setenv("LIGHT_SERVE_WRITE_TIMEOUT", "8s")
 	t.Setenv("LIGHT_SERVE_SHUTDOWN_DEADLINE", "12s")
 	t.Setenv("LIGHT_SERVE_REQUEST_TIMEOUT", "3s")
+	t.Setenv("LIGHT_SERVE_IDLE_TIMEOUT", "45s")
+	t.Setenv("LIGHT_SERVE_BODY_READ_TIMEOUT", "7s")
+	t.Setenv("LIGHT_SERVE_ALLOWED_METHODS", "get, Head,")
+	t.Setenv("LIGHT_SERVE_SERVER_HEADER", "light_serve")
+	t.Setenv("LIGHT_SERVE_HTTP_REDIRECT_PORT", "8080")
+	t.Setenv("LIGHT_SERVE_MAX_REQUEST_LINE_BYTES", "8192")
+	t.Setenv("LIGHT_SERVE_MAX_HEADER_BYTES", "32768")
+	t.Setenv("LIGHT_SERVE_MAX_HEADER_COUNT", "100")
+	t.Setenv("LIGHT_SERVE_MAX_BODY_BYTES", "1048576")
+	t.Setenv("LIGHT_SERVE_MAX_READ_BYTES_PER_SECOND", "65536")
 	t.Setenv("LIGHT_SERVE_TLS_CERT_FILE", certFile)
 	t.Setenv("LIGHT_SERVE_TLS_KEY_FILE", keyFile)
 	t.Setenv("LIGHT_SERVE_TLS_MIN_VERSION", "1.2")
+	t.Setenv("LIGHT_SERVE_CONCURRENT_PIPELINING", "true")
+	t.Setenv("LIGHT_SERVE_MAX_CONCURRENT_STREAMS", "16")
+	t.Setenv("LIGHT_SERVE_SHUTDOWN_DIAGNOSTICS", "true")
 
 	cfg, err := loadServerConfigFromEnv()
 	if err != nil {
@@ -175,9 +918,40 @@ func TestLoadServerConfigFromEnv_Overrides(t *testing.T) {
 	if cfg.RequestTimeout != 3*time.Second {
 		t.Fatalf("expected request timeout 3s, got %s", cfg.RequestTimeout)
 	}
+	if cfg.IdleTimeout != 45*time.Second {
+		t.Fatalf("expected idle timeout 45s, got %s", cfg.IdleTimeout)
+	}
+	if cfg.BodyReadTimeout != 7*time.Second {
+		t.Fatalf("expected body read timeout 7s, got %s", cfg.BodyReadTimeout)
+	}
+	if strings.Join(cfg.AllowedMethods, ",") != "GET,HEAD" {
+		t.Fatalf("expected allowed methods [GET HEAD], got %v", cfg.AllowedMethods)
+	}
+	if cfg.HTTPRedirectPort != 8080 {
+		t.Fatalf("expected http redirect port 8080, got %d", cfg.HTTPRedirectPort)
+	}
+	if cfg.ServerHeader != "light_serve" {
+		t.Fatalf("expected server header light_serve, got %q", cfg.ServerHeader)
+	}
+	wantLimits := httpadapter.ParserLimits{MaxRequestLineBytes: 8192, MaxHeadersBytes: 32768, MaxHeaderCount: 100, MaxBodyBytes: 1048576}
+	if cfg.ParserLimits != wantLimits {
+		t.Fatalf("expected parser limits %+v, got %+v", wantLimits, cfg.ParserLimits)
+	}
+	if cfg.MaxReadBytesPerSecond != 65536 {
+		t.Fatalf("expected max read rate 65536, got %d", cfg.MaxReadBytesPerSecond)
+	}
 	if cfg.TLSMinVersion != tls.VersionTLS12 {
 		t.Fatalf("expected tls min version 1.2, got %#x", cfg.TLSMinVersion)
 	}
+	if !cfg.ConcurrentPipelining {
+		t.Fatalf("expected concurrent pipelining to be enabled")
+	}
+	if cfg.MaxConcurrentStreams != 16 {
+		t.Fatalf("expected max concurrent streams 16, got %d", cfg.MaxConcurrentStreams)
+	}
+	if !cfg.ShutdownDiagnostics {
+		t.Fatalf("expected shutdown diagnostics to be enabled")
+	}
 }
 
 // TestLoadServerConfigFromEnv_InvalidValues verifies invalid env values fail fast.
@@ -195,6 +969,16 @@ func TestLoadServerConfigFromEnv_InvalidValues(t *testing.T) {
 		{name: "missing cert file", key: "LIGHT_SERVE_TLS_CERT_FILE", value: "", expect: "value is required"},
 		{name: "missing key file", key: "LIGHT_SERVE_TLS_KEY_FILE", value: "", expect: "value is required"},
 		{name: "invalid tls min version", key: "LIGHT_SERVE_TLS_MIN_VERSION", value: "1.1", expect: "invalid value"},
+		{name: "invalid concurrent pipelining", key: "LIGHT_SERVE_CONCURRENT_PIPELINING", value: "maybe", expect: "invalid boolean"},
+		{name: "zero max concurrent streams", key: "LIGHT_SERVE_MAX_CONCURRENT_STREAMS", value: "0", expect: "must be >= 1"},
+		{name: "invalid http redirect port", key: "LIGHT_SERVE_HTTP_REDIRECT_PORT", value: "0", expect: "between 1 and 65535"},
+		{name: "http redirect port same as port", key: "LIGHT_SERVE_HTTP_REDIRECT_PORT", value: "8080", expect: "must differ"},
+		{name: "invalid tls client auth", key: "LIGHT_SERVE_TLS_CLIENT_AUTH", value: "trust-me", expect: "invalid value"},
+		{name: "verified client auth without ca", key: "LIGHT_SERVE_TLS_CLIENT_AUTH", value: "require", expect: "requires LIGHT_SERVE_TLS_CLIENT_CA_FILE"},
+		{name: "client ca file not found", key: "LIGHT_SERVE_TLS_CLIENT_CA_FILE", value: "C:/missing-ca.pem", expect: "file does not exist"},
+		{name: "invalid allowed method", key: "LIGHT_SERVE_ALLOWED_METHODS", value: "GET,PO ST", expect: "invalid method"},
+		{name: "negative max read rate", key: "LIGHT_SERVE_MAX_READ_BYTES_PER_SECOND", value: "-1", expect: "must be >= 0"},
+		{name: "invalid tls enabled", key: "LIGHT_SERVE_TLS_ENABLED", value: "sometimes", expect: "invalid boolean"},
 		{name: "cert file not found", key: "LIGHT_SERVE_TLS_CERT_FILE", value: "C:/missing-cert.pem", expect: "file does not exist"},
 	}
 
@@ -206,6 +990,7 @@ func TestLoadServerConfigFromEnv_InvalidValues(t *testing.T) {
 			t.Setenv("LIGHT_SERVE_WRITE_TIMEOUT", "")
 			t.Setenv("LIGHT_SERVE_SHUTDOWN_DEADLINE", "")
 			t.Setenv("LIGHT_SERVE_REQUEST_TIMEOUT", "")
+			t.Setenv("LIGHT_SERVE_IDLE_TIMEOUT", "")
 			t.Setenv("LIGHT_SERVE_TLS_CERT_FILE", certFile)
 			t.Setenv("LIGHT_SERVE_TLS_KEY_FILE", keyFile)
 			t.Setenv("LIGHT_SERVE_TLS_MIN_VERSION", "")
@@ -237,6 +1022,31 @@ func createTempTLSFiles(t *testing.T) (string, string) {
 	return certFile, keyFile
 }
 
+// newTestTLSConfig builds a server TLS config with a fresh self-signed certificate.
+func newTestTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+}
+
 // waitForActiveConn blocks until one connection is tracked or timeout is reached.
 func waitForActiveConn(t *testing.T, runtime *serverRuntime, timeout time.Duration) {
 	deadline := time.Now().Add(timeout)
@@ -252,6 +1062,124 @@ func waitForActiveConn(t *testing.T, runtime *serverRuntime, timeout time.Durati
 	t.Fatalf("timed out waiting for active tracked connection")
 }
 
+// waitForConnCount blocks until the runtime tracks exactly want connections.
+func waitForConnCount(t *testing.T, runtime *serverRuntime, want int, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		runtime.mu.Lock()
+		active := len(runtime.conns)
+		runtime.mu.Unlock()
+		if active == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d tracked connections", want)
+}
+
+// waitForIdleCount polls until the runtime tracks want idle connections.
+func waitForIdleCount(t *testing.T, runtime *serverRuntime, want int, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		runtime.mu.Lock()
+		idle := runtime.idleConns.Len()
+		runtime.mu.Unlock()
+		if idle == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d idle connections", want)
+}
+
+// sendKeepAliveRequest sends one keep-alive request and returns the response head.
+func sendKeepAliveRequest(t *testing.T, address string) string {
+	t.Helper()
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("GET /shed-probe HTTP/1.1\r\nHost: example.com\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	return string(buf[:n])
+}
+
+// fakeAccept is one scripted Accept result.
+type fakeAccept struct {
+	conn net.Conn
+	err  error
+}
+
+// fakeListener replays scripted Accept results, then blocks until closed.
+type fakeListener struct {
+	mu      sync.Mutex
+	script  []fakeAccept
+	drained chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// newFakeListener creates a listener that replays the given accept script.
+func newFakeListener(script ...fakeAccept) *fakeListener {
+	return &fakeListener{
+		script:  script,
+		drained: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+// Accept returns the next scripted result or net.ErrClosed once closed.
+func (l *fakeListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if len(l.script) > 0 {
+		next := l.script[0]
+		l.script = l.script[1:]
+		if len(l.script) == 0 {
+			close(l.drained)
+		}
+		l.mu.Unlock()
+		return next.conn, next.err
+	}
+	l.mu.Unlock()
+
+	<-l.closed
+	return nil, net.ErrClosed
+}
+
+// Close unblocks pending Accept calls.
+func (l *fakeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+// Addr returns a dummy listener address.
+func (l *fakeListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0}
+}
+
+// temporaryAcceptErr is a transient accept error for backoff tests.
+type temporaryAcceptErr struct{}
+
+// Error describes the transient failure.
+func (temporaryAcceptErr) Error() string { return "temporary accept failure" }
+
+// Temporary marks the error as retryable.
+func (temporaryAcceptErr) Temporary() bool { return true }
+
+// Timeout reports the error is not a timeout.
+func (temporaryAcceptErr) Timeout() bool { return false }
+
 // spyConn records deadlines and supports minimal net.Conn behavior for tests.
 type spyConn struct {
 	mu            sync.Mutex
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AcceptsCharset reports whether charset is acceptable under the request's
+// Accept-Charset header. An absent header accepts every charset; otherwise
+// the charset, or a "*" entry, must be listed with a non-zero q-value.
+// Charset names compare case-insensitively.
+func (r *Request) AcceptsCharset(charset string) bool {
+	header := strings.TrimSpace(r.Headers["accept-charset"])
+	if header == "" {
+		return true
+	}
+
+	wildcard := -1.0
+	for _, entry := range strings.Split(header, ",") {
+		name, q, ok := parseQualityEntry(entry)
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(name, charset) {
+			return q > 0
+		}
+		if name == "*" {
+			wildcard = q
+		}
+	}
+	return wildcard > 0
+}
+
+// parseQualityEntry splits an "name;q=0.5" list entry into its name and
+// q-value, defaulting q to 1. Malformed entries report false.
+func parseQualityEntry(entry string) (string, float64, bool) {
+	name, params, _ := strings.Cut(entry, ";")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", 0, false
+	}
+
+	q := 1.0
+	for _, param := range strings.Split(params, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return "", 0, false
+		}
+		q = parsed
+	}
+	return name, q, true
+}
+
+// AcceptCharsetMiddleware answers 406 when the charset declared in the
+// handler response's Content-Type is not acceptable to the client.
+// Responses without a charset parameter pass through unchanged.
+func AcceptCharsetMiddleware() Middleware {
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			resp := safeInvoke(next, req)
+			if req == nil || resp.Raw != nil {
+				return resp
+			}
+			charset := contentTypeCharset(headerValueIgnoreCase(resp.Headers, "Content-Type"))
+			if charset == "" || req.AcceptsCharset(charset) {
+				return resp
+			}
+			return withoutBodyForHead(req, statusResponse(406))
+		}
+	}
+}
+
+// contentTypeCharset returns the charset parameter of a Content-Type value.
+func contentTypeCharset(contentType string) string {
+	_, params, _ := strings.Cut(contentType, ";")
+	for _, param := range strings.Split(params, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if found && strings.EqualFold(strings.TrimSpace(key), "charset") {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
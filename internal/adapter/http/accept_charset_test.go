@@ -0,0 +1,61 @@
+package http
+
+import "testing"
+
+// TestRequest_AcceptsCharset verifies Accept-Charset matching with q-values and wildcards.
+func TestRequest_AcceptsCharset(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		charset string
+		want    bool
+	}{
+		{name: "absent header accepts all", charset: "utf-8", want: true},
+		{name: "listed charset", header: "iso-8859-1, UTF-8;q=0.8", charset: "utf-8", want: true},
+		{name: "unlisted charset", header: "iso-8859-1", charset: "utf-8", want: false},
+		{name: "zero q rejects", header: "utf-8;q=0, *", charset: "utf-8", want: false},
+		{name: "wildcard accepts", header: "iso-8859-1, *;q=0.1", charset: "utf-8", want: true},
+		{name: "wildcard with zero q rejects", header: "*;q=0", charset: "utf-8", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Headers: map[string]string{}}
+			if tt.header != "" {
+				req.Headers["accept-charset"] = tt.header
+			}
+			if got := req.AcceptsCharset(tt.charset); got != tt.want {
+				t.Fatalf("AcceptsCharset(%q) with %q = %v, want %v", tt.charset, tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAcceptCharsetMiddleware verifies 406 is returned only when the response charset is not acceptable.
+func TestAcceptCharsetMiddleware(t *testing.T) {
+	handler := AcceptCharsetMiddleware()(func(req *Request) *Response {
+		return NewResponse().Header("Content-Type", "text/plain; charset=utf-8").WriteString("hello")
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{name: "accepting header", header: "utf-8", status: 200},
+		{name: "rejecting header", header: "iso-8859-1", status: 406},
+		{name: "absent header", status: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Method: "GET", Path: "/text", Headers: map[string]string{}}
+			if tt.header != "" {
+				req.Headers["accept-charset"] = tt.header
+			}
+			if resp := handler(req); resp.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessLogFormat selects how LoggingMiddleware renders request/response fields.
+type AccessLogFormat int
+
+const (
+	// AccessLogKeyValue logs fields as structured key/value pairs (default).
+	AccessLogKeyValue AccessLogFormat = iota
+	// AccessLogCommon renders the Apache Common Log Format.
+	AccessLogCommon
+	// AccessLogCombined renders the Apache Combined Log Format.
+	AccessLogCombined
+	// AccessLogJSON renders fields as a single JSON object.
+	AccessLogJSON
+)
+
+// AccessLogFields holds the values available to access-log formatters.
+type AccessLogFields struct {
+	Time          time.Time
+	Method        string
+	Path          string
+	Version       string
+	Status        int
+	Bytes         int
+	Duration      time.Duration
+	RequestID     string
+	CorrelationID string
+	UserAgent     string
+	Referer       string
+	RemoteAddr    string
+}
+
+// AccessLogFormatter renders access-log fields into a single log message.
+type AccessLogFormatter func(fields AccessLogFields) string
+
+// LoggingOptions configures LoggingMiddlewareWithOptions.
+type LoggingOptions struct {
+	// Format selects a built-in rendering; ignored when Formatter is set.
+	Format AccessLogFormat
+	// Formatter overrides Format with a user-supplied rendering.
+	Formatter AccessLogFormatter
+	// Clock measures request duration; nil uses SystemClock.
+	Clock Clock
+	// DurationMillis logs the duration as a float duration_ms field in
+	// place of the duration string, for key/value output.
+	DurationMillis bool
+}
+
+// formatter returns the message formatter for the options, or nil for key/value logging.
+func (o LoggingOptions) formatter() AccessLogFormatter {
+	if o.Formatter != nil {
+		return o.Formatter
+	}
+	switch o.Format {
+	case AccessLogCommon:
+		return FormatCommonLog
+	case AccessLogCombined:
+		return FormatCombinedLog
+	case AccessLogJSON:
+		return FormatJSONLog
+	default:
+		return nil
+	}
+}
+
+// FormatCommonLog renders fields in Apache Common Log Format. The client
+// host is taken from RemoteAddr, without its port, or "-" when unknown.
+func FormatCommonLog(fields AccessLogFields) string {
+	var b strings.Builder
+	b.WriteString(commonLogHost(fields.RemoteAddr))
+	b.WriteString(" - - [")
+	b.WriteString(fields.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	b.WriteString("] \"")
+	b.WriteString(fields.Method)
+	b.WriteString(" ")
+	b.WriteString(fields.Path)
+	b.WriteString(" ")
+	b.WriteString(fields.Version)
+	b.WriteString("\" ")
+	b.WriteString(strconv.Itoa(fields.Status))
+	b.WriteString(" ")
+	if fields.Bytes > 0 {
+		b.WriteString(strconv.Itoa(fields.Bytes))
+	} else {
+		b.WriteString("-")
+	}
+	return b.String()
+}
+
+// FormatCombinedLog renders fields in Apache Combined Log Format.
+func FormatCombinedLog(fields AccessLogFields) string {
+	return FormatCommonLog(fields) + " " + quoteLogValue(fields.Referer) + " " + quoteLogValue(fields.UserAgent)
+}
+
+// FormatJSONLog renders fields as a single-line JSON object.
+func FormatJSONLog(fields AccessLogFields) string {
+	encoded, err := json.Marshal(map[string]any{
+		"time":           fields.Time.Format(time.RFC3339Nano),
+		"method":         fields.Method,
+		"path":           fields.Path,
+		"version":        fields.Version,
+		"status":         fields.Status,
+		"bytes":          fields.Bytes,
+		"duration":       fields.Duration.String(),
+		"request_id":     fields.RequestID,
+		"correlation_id": fields.CorrelationID,
+		"user_agent":     fields.UserAgent,
+		"referer":        fields.Referer,
+		"remote_addr":    fields.RemoteAddr,
+	})
+	if err != nil {
+		return "{}"
+	}
+	return string(encoded)
+}
+
+// commonLogHost returns the host part of a peer address for Apache-style
+// logs, or "-" when the address is empty.
+func commonLogHost(remoteAddr string) string {
+	if remoteAddr == "" {
+		return "-"
+	}
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// quoteLogValue quotes a value for Apache-style logs, using "-" when empty.
+func quoteLogValue(value string) string {
+	if value == "" {
+		return "\"-\""
+	}
+	return strconv.Quote(value)
+}
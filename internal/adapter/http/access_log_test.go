@@ -0,0 +1,93 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoggingMiddlewareWithOptions_CombinedFormat verifies Apache combined rendering.
+func TestLoggingMiddlewareWithOptions_CombinedFormat(t *testing.T) {
+	logger := &stubLogger{}
+	mw := LoggingMiddlewareWithOptions(logger, LoggingOptions{Format: AccessLogCombined})
+
+	handler := mw(func(req *Request) *Response {
+		resp := NewResponse()
+		resp.StatusCode = 201
+		resp.WriteString("created")
+		return resp
+	})
+
+	handler(&Request{
+		Method:  "POST",
+		Path:    "/items",
+		Version: "HTTP/1.1",
+		Headers: map[string]string{
+			"user-agent": "curl/8.0",
+			"referer":    "https://example.com/",
+		},
+	})
+	if len(logger.entries) != 1 {
+		t.Fatalf("expected one log entry, got %d", len(logger.entries))
+	}
+	entry := logger.entries[0]
+	if !strings.Contains(entry, "\"POST /items HTTP/1.1\" 201 7 \"https://example.com/\" \"curl/8.0\"") {
+		t.Fatalf("expected combined log line, got %q", entry)
+	}
+	if !strings.HasPrefix(entry, "- - - [") {
+		t.Fatalf("expected combined log prefix, got %q", entry)
+	}
+}
+
+// TestLoggingMiddlewareWithOptions_CustomFormatter verifies user-supplied formatters take priority.
+func TestLoggingMiddlewareWithOptions_CustomFormatter(t *testing.T) {
+	logger := &stubLogger{}
+	mw := LoggingMiddlewareWithOptions(logger, LoggingOptions{
+		Format: AccessLogJSON,
+		Formatter: func(fields AccessLogFields) string {
+			return fields.Method + "|" + fields.Path + "|" + fields.RequestID
+		},
+	})
+
+	handler := mw(func(req *Request) *Response {
+		return NewResponse()
+	})
+
+	handler(&Request{
+		Method:  "GET",
+		Path:    "/custom",
+		Headers: map[string]string{"x-request-id": "req-1"},
+	})
+	if len(logger.entries) != 1 {
+		t.Fatalf("expected one log entry, got %d", len(logger.entries))
+	}
+	if !strings.HasPrefix(logger.entries[0], "GET|/custom|req-1 ") {
+		t.Fatalf("expected custom formatted entry, got %q", logger.entries[0])
+	}
+}
+
+// TestFormatCommonLog_RemoteHost verifies the client host leads the line, without its port.
+func TestFormatCommonLog_RemoteHost(t *testing.T) {
+	fields := AccessLogFields{
+		Time:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Method:  "GET",
+		Path:    "/",
+		Version: "HTTP/1.1",
+		Status:  200,
+		Bytes:   2,
+	}
+	tests := []struct {
+		remoteAddr string
+		want       string
+	}{
+		{remoteAddr: "203.0.113.7:51234", want: "203.0.113.7 - - [01/May/2024:12:00:00 +0000] \"GET / HTTP/1.1\" 200 2"},
+		{remoteAddr: "[2001:db8::1]:443", want: "2001:db8::1 - - ["},
+		{remoteAddr: "", want: "- - - ["},
+	}
+	for _, tt := range tests {
+		fields.RemoteAddr = tt.remoteAddr
+		if got := FormatCommonLog(fields); !strings.HasPrefix(got, tt.want) {
+			t.Fatalf("expected %q to start with %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"sync"
+	"time"
+)
+
+// AdmissionOptions configures AdmissionMiddleware.
+type AdmissionOptions struct {
+	// MaxInFlight caps concurrently executing requests. Zero disables admission control.
+	MaxInFlight int
+	// MaxQueue caps requests waiting for a slot. Requests beyond it get 503 immediately.
+	MaxQueue int
+	// MaxWait bounds how long a queued request waits for a slot before getting 503.
+	MaxWait time.Duration
+}
+
+// AdmissionMiddleware limits in-flight requests, queueing excess requests in
+// FIFO order for up to MaxWait before answering 503 Service Unavailable.
+// A queued request stops waiting as soon as its context is canceled.
+func AdmissionMiddleware(opts AdmissionOptions) Middleware {
+	return func(next HandlerAdapter) HandlerAdapter {
+		if opts.MaxInFlight <= 0 {
+			return next
+		}
+
+		gate := &admissionGate{limit: opts.MaxInFlight, maxQueue: opts.MaxQueue}
+		return func(req *Request) *Response {
+			if !gate.acquire(req, opts.MaxWait) {
+				return statusResponse(503)
+			}
+			defer gate.release()
+			return safeInvoke(next, req)
+		}
+	}
+}
+
+// admissionGate is a counting semaphore whose waiters are served in FIFO order.
+type admissionGate struct {
+	mu       sync.Mutex
+	limit    int
+	maxQueue int
+	inFlight int
+	waiters  []chan struct{}
+}
+
+// acquire takes a slot, waiting up to maxWait in the queue when none is free.
+func (g *admissionGate) acquire(req *Request, maxWait time.Duration) bool {
+	g.mu.Lock()
+	if g.inFlight < g.limit && len(g.waiters) == 0 {
+		g.inFlight++
+		g.mu.Unlock()
+		return true
+	}
+	if len(g.waiters) >= g.maxQueue || maxWait <= 0 {
+		g.mu.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	g.waiters = append(g.waiters, ready)
+	g.mu.Unlock()
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		return true
+	case <-timer.C:
+	case <-requestContext(req).Done():
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, waiter := range g.waiters {
+		if waiter == ready {
+			g.waiters = append(g.waiters[:i], g.waiters[i+1:]...)
+			return false
+		}
+	}
+	// The slot was handed over while giving up; pass it on.
+	g.releaseLocked()
+	return false
+}
+
+// release frees a slot, handing it directly to the oldest waiter if any.
+func (g *admissionGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.releaseLocked()
+}
+
+// releaseLocked implements release with g.mu held.
+func (g *admissionGate) releaseLocked() {
+	if len(g.waiters) > 0 {
+		ready := g.waiters[0]
+		g.waiters = g.waiters[1:]
+		close(ready)
+		return
+	}
+	g.inFlight--
+}
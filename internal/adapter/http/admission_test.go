@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestAdmissionMiddleware_AdmitsAfterShortWait verifies a queued request runs once a slot frees.
+func TestAdmissionMiddleware_AdmitsAfterShortWait(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	handler := AdmissionMiddleware(AdmissionOptions{MaxInFlight: 1, MaxQueue: 1, MaxWait: time.Second})(func(req *Request) *Response {
+		if req.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		return NewResponse()
+	})
+
+	go handler(&Request{Path: "/slow"})
+	<-started
+
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	resp := handler(&Request{Path: "/queued"})
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected queued request to be admitted, got %d", resp.StatusCode)
+	}
+}
+
+// TestAdmissionMiddleware_QueueWaitTimesOut verifies a request that never gets a slot receives 503.
+func TestAdmissionMiddleware_QueueWaitTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 1)
+	handler := AdmissionMiddleware(AdmissionOptions{MaxInFlight: 1, MaxQueue: 1, MaxWait: 20 * time.Millisecond})(func(req *Request) *Response {
+		started <- struct{}{}
+		<-release
+		return NewResponse()
+	})
+
+	go handler(&Request{Path: "/slow"})
+	<-started
+
+	resp := handler(&Request{Path: "/queued"})
+	if resp.StatusCode != 503 {
+		t.Fatalf("expected 503 after queue wait, got %d", resp.StatusCode)
+	}
+	if string(resp.Body) != "Service Unavailable" {
+		t.Fatalf("expected reason phrase body, got %q", string(resp.Body))
+	}
+}
+
+// TestAdmissionMiddleware_CanceledWhileQueued verifies a queued request stops waiting on cancellation.
+func TestAdmissionMiddleware_CanceledWhileQueued(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 1)
+	handler := AdmissionMiddleware(AdmissionOptions{MaxInFlight: 1, MaxQueue: 1, MaxWait: time.Minute})(func(req *Request) *Response {
+		started <- struct{}{}
+		<-release
+		return NewResponse()
+	})
+
+	go handler(&Request{Path: "/slow"})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	begin := time.Now()
+	resp := handler(&Request{Path: "/queued", Ctx: ctx})
+	if resp.StatusCode != 503 {
+		t.Fatalf("expected 503 for canceled queued request, got %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(begin); elapsed > 5*time.Second {
+		t.Fatalf("expected cancellation to end the wait promptly, waited %s", elapsed)
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"strings"
+)
+
+// BearerToken returns the token of an "Authorization: Bearer <token>"
+// header. The scheme is matched case-insensitively and surrounding
+// whitespace is trimmed. It reports false when the header is missing, uses
+// another scheme, or the token is empty or contains whitespace.
+func (r *Request) BearerToken() (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	header := strings.TrimSpace(headerValueIgnoreCase(r.Headers, "Authorization"))
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(header[len(prefix):])
+	if token == "" || strings.ContainsAny(token, " \t") {
+		return "", false
+	}
+	return token, true
+}
+
+type principalKey struct{}
+
+// AuthMiddleware requires a bearer token accepted by verify. Requests
+// without a well-formed token, or whose token verify rejects, answer 401
+// with a WWW-Authenticate: Bearer challenge. The principal returned by
+// verify is exposed to handlers through PrincipalFromRequest.
+func AuthMiddleware(verify func(token string) (any, error)) Middleware {
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			token, ok := req.BearerToken()
+			if !ok {
+				return unauthorizedResponse(req, "")
+			}
+			principal, err := verify(token)
+			if err != nil {
+				return unauthorizedResponse(req, "invalid_token")
+			}
+
+			ctx := context.WithValue(requestContext(req), principalKey{}, principal)
+			return safeInvoke(next, withRequestContext(req, ctx))
+		}
+	}
+}
+
+// PrincipalFromRequest returns the principal stored by AuthMiddleware. It
+// reports false when the middleware did not run or verify returned nil.
+func PrincipalFromRequest(req *Request) (any, bool) {
+	principal := requestContext(req).Value(principalKey{})
+	return principal, principal != nil
+}
+
+// unauthorizedResponse answers 401 with a Bearer challenge, naming the
+// RFC 6750 error code when one is given.
+func unauthorizedResponse(req *Request, errorCode string) *Response {
+	resp := statusResponse(401)
+	challenge := "Bearer"
+	if errorCode != "" {
+		challenge += ` error="` + errorCode + `"`
+	}
+	resp.SetHeader("WWW-Authenticate", challenge)
+	return withoutBodyForHead(req, resp)
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestRequest_BearerToken verifies bearer token extraction from the Authorization header.
+func TestRequest_BearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		token  string
+		ok     bool
+	}{
+		{name: "valid", header: "Bearer abc.def.ghi", token: "abc.def.ghi", ok: true},
+		{name: "case-insensitive scheme and padding", header: "  bEaReR   opaque-123  ", token: "opaque-123", ok: true},
+		{name: "missing header", header: "", ok: false},
+		{name: "basic scheme", header: "Basic dXNlcjpwYXNz", ok: false},
+		{name: "scheme without token", header: "Bearer ", ok: false},
+		{name: "scheme without separator", header: "Bearerabc", ok: false},
+		{name: "token with space", header: "Bearer abc def", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Headers: map[string]string{}}
+			if tt.header != "" {
+				req.Headers["authorization"] = tt.header
+			}
+			token, ok := req.BearerToken()
+			if token != tt.token || ok != tt.ok {
+				t.Fatalf("expected (%q, %v), got (%q, %v)", tt.token, tt.ok, token, ok)
+			}
+		})
+	}
+}
+
+// TestAuthMiddleware verifies verified principals reach the handler and failures answer 401.
+func TestAuthMiddleware(t *testing.T) {
+	handler := AuthMiddleware(func(token string) (any, error) {
+		if token != "good" {
+			return nil, errors.New("unknown token")
+		}
+		return "alice", nil
+	})(func(req *Request) *Response {
+		principal, ok := PrincipalFromRequest(req)
+		if !ok {
+			t.Fatalf("expected a principal on the request")
+		}
+		return NewResponse().WriteString(principal.(string))
+	})
+
+	resp := handler(&Request{Method: "GET", Path: "/", Headers: map[string]string{"authorization": "Bearer good"}})
+	if resp.StatusCode != 200 || string(resp.Body) != "alice" {
+		t.Fatalf("expected the principal to reach the handler, got %d %q", resp.StatusCode, resp.Body)
+	}
+
+	tests := []struct {
+		name      string
+		header    string
+		challenge string
+	}{
+		{name: "missing header", challenge: "Bearer"},
+		{name: "malformed scheme", header: "Token good", challenge: "Bearer"},
+		{name: "rejected token", header: "Bearer bad", challenge: `Bearer error="invalid_token"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Method: "GET", Path: "/", Headers: map[string]string{}}
+			if tt.header != "" {
+				req.Headers["authorization"] = tt.header
+			}
+			resp := handler(req)
+			if resp.StatusCode != 401 {
+				t.Fatalf("expected 401, got %d", resp.StatusCode)
+			}
+			if got := resp.Headers["WWW-Authenticate"]; got != tt.challenge {
+				t.Fatalf("expected challenge %q, got %q", tt.challenge, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrUnsupportedContentEncoding indicates a request Content-Encoding that
+// BodyStream cannot decode.
+var ErrUnsupportedContentEncoding = errors.New("unsupported content encoding")
+
+// BodyStream returns a reader over the request body that transparently
+// decodes Content-Encoding: gzip. Reading past maxDecompressed decoded bytes
+// fails with ErrBodyTooLarge, so compressed bodies cannot expand without
+// bound; a non-positive maxDecompressed disables the limit. Encodings other
+// than gzip and identity return ErrUnsupportedContentEncoding.
+func (r *Request) BodyStream(maxDecompressed int64) (io.Reader, error) {
+	var body io.Reader = bytes.NewReader(r.Body)
+
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Headers["content-encoding"])); encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		body = zr
+	default:
+		return nil, ErrUnsupportedContentEncoding
+	}
+
+	if maxDecompressed <= 0 {
+		return body, nil
+	}
+	return &limitedBodyReader{r: body, remaining: maxDecompressed}, nil
+}
+
+// limitedBodyReader yields at most remaining bytes and fails with
+// ErrBodyTooLarge once the underlying reader has more.
+type limitedBodyReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+// Read reads from the underlying reader, enforcing the byte limit.
+func (l *limitedBodyReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = 0
+		return n, ErrBodyTooLarge
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
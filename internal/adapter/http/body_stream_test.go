@@ -0,0 +1,78 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+)
+
+// gzipBytes compresses data for test request bodies.
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip write failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestRequestBodyStream verifies plain and gzip bodies are streamed within the limit.
+func TestRequestBodyStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    []byte
+	}{
+		{name: "plain", body: []byte("hello world")},
+		{name: "gzip", headers: map[string]string{"content-encoding": "gzip"}, body: gzipBytes(t, []byte("hello world"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Headers: tt.headers, Body: tt.body}
+			stream, err := req.BodyStream(int64(len("hello world")))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := io.ReadAll(stream)
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if string(got) != "hello world" {
+				t.Fatalf("expected hello world, got %q", string(got))
+			}
+		})
+	}
+}
+
+// TestRequestBodyStream_GzipBombTripsLimit verifies decompression stops at the limit.
+func TestRequestBodyStream_GzipBombTripsLimit(t *testing.T) {
+	bomb := gzipBytes(t, make([]byte, 10<<20))
+	req := &Request{Headers: map[string]string{"content-encoding": "gzip"}, Body: bomb}
+
+	stream, err := req.BodyStream(64 << 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := io.ReadAll(stream)
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("expected ErrBodyTooLarge, got %v", err)
+	}
+	if len(got) != 64<<10 {
+		t.Fatalf("expected exactly the limit to be read, got %d bytes", len(got))
+	}
+}
+
+// TestRequestBodyStream_UnsupportedEncoding verifies unknown encodings are refused.
+func TestRequestBodyStream_UnsupportedEncoding(t *testing.T) {
+	req := &Request{Headers: map[string]string{"content-encoding": "br"}, Body: []byte("x")}
+	if _, err := req.BodyStream(0); !errors.Is(err, ErrUnsupportedContentEncoding) {
+		t.Fatalf("expected ErrUnsupportedContentEncoding, got %v", err)
+	}
+}
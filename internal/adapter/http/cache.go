@@ -0,0 +1,171 @@
+package http
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CacheMaxEntries bounds the responses one CacheMiddleware keeps; when
+	// full, expired entries are dropped first and then the oldest one.
+	CacheMaxEntries = 1024
+	// CacheMaxBodyBytes is the largest response body CacheMiddleware stores.
+	CacheMaxBodyBytes = 1 << 20
+)
+
+// CacheMiddleware serves repeated GET requests from an in-memory copy of the
+// response for ttl without calling the handler. Entries are keyed by
+// keyFunc, or by method, path, and raw query when keyFunc is nil. Requests
+// carrying Authorization or Cookie bypass the cache, since their responses
+// may differ per user. Only 2xx responses are stored, and never streamed,
+// raw, Set-Cookie, or Cache-Control no-store or private responses, or
+// bodies above CacheMaxBodyBytes. Cached responses carry Age and, unless
+// the handler set one, a Cache-Control max-age for the remaining lifetime.
+func CacheMiddleware(ttl time.Duration, keyFunc func(*Request) string) Middleware {
+	return CacheMiddlewareWithClock(ttl, keyFunc, SystemClock)
+}
+
+// CacheMiddlewareWithClock is CacheMiddleware measuring expiry on clock.
+func CacheMiddlewareWithClock(ttl time.Duration, keyFunc func(*Request) string, clock Clock) Middleware {
+	clock = clockOrDefault(clock)
+	if keyFunc == nil {
+		keyFunc = defaultCacheKey
+	}
+	cache := &responseCache{entries: make(map[string]cacheEntry)}
+
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			if req == nil || req.Method != "GET" || ttl <= 0 || isPerUserRequest(req) {
+				return safeInvoke(next, req)
+			}
+			key := keyFunc(req)
+			now := clock.Now()
+			if entry, ok := cache.get(key, now, ttl); ok {
+				return entry.serve(now, ttl)
+			}
+
+			resp := safeInvoke(next, req)
+			if isCacheable(resp) {
+				cache.put(key, cacheEntry{resp: cloneResponse(resp), storedAt: now}, now, ttl)
+			}
+			return resp
+		}
+	}
+}
+
+// defaultCacheKey keys a request by method, path, and raw query.
+func defaultCacheKey(req *Request) string {
+	return req.Method + " " + req.Path + "?" + req.RawQuery
+}
+
+// isPerUserRequest reports whether req carries credentials that may make
+// its response specific to one user.
+func isPerUserRequest(req *Request) bool {
+	return hasHeaderIgnoreCase(req.Headers, "Authorization") || hasHeaderIgnoreCase(req.Headers, "Cookie")
+}
+
+// isCacheable reports whether resp may be stored by CacheMiddleware.
+func isCacheable(resp *Response) bool {
+	if resp == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false
+	}
+	if resp.Stream != nil || resp.Raw != nil || len(resp.Body) > CacheMaxBodyBytes {
+		return false
+	}
+	if hasHeaderIgnoreCase(resp.Headers, "Set-Cookie") {
+		return false
+	}
+	for _, directive := range strings.Split(headerValueIgnoreCase(resp.Headers, "Cache-Control"), ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if strings.EqualFold(name, "no-store") || strings.EqualFold(name, "private") {
+			return false
+		}
+	}
+	return true
+}
+
+// responseCache is a bounded, expiring map of stored responses.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	resp     *Response
+	storedAt time.Time
+}
+
+// serve returns a copy of the stored response with Age and Cache-Control set.
+func (e cacheEntry) serve(now time.Time, ttl time.Duration) *Response {
+	resp := cloneResponse(e.resp)
+	age := now.Sub(e.storedAt)
+	resp.SetHeader("Age", strconv.Itoa(int(age/time.Second)))
+	if !hasHeaderIgnoreCase(resp.Headers, "Cache-Control") {
+		resp.SetHeader("Cache-Control", "max-age="+strconv.Itoa(int((ttl-age)/time.Second)))
+	}
+	return resp
+}
+
+// get returns the unexpired entry for key, dropping it once expired.
+func (c *responseCache) get(key string, now time.Time, ttl time.Duration) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if now.Sub(entry.storedAt) >= ttl {
+		delete(c.entries, key)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// put stores entry under key, making room when the cache is full.
+func (c *responseCache) put(key string, entry cacheEntry, now time.Time, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= CacheMaxEntries {
+		oldestKey, oldest := "", now
+		for k, e := range c.entries {
+			if now.Sub(e.storedAt) >= ttl {
+				delete(c.entries, k)
+			} else if !e.storedAt.After(oldest) {
+				oldestKey, oldest = k, e.storedAt
+			}
+		}
+		if len(c.entries) >= CacheMaxEntries {
+			delete(c.entries, oldestKey)
+		}
+	}
+	c.entries[key] = entry
+}
+
+// cloneResponse copies resp deeply enough that changes to the copy's
+// status, headers, or body leave resp untouched.
+func cloneResponse(resp *Response) *Response {
+	clone := &Response{
+		StatusCode: resp.StatusCode,
+		Headers:    make(map[string]string, len(resp.Headers)),
+		Body:       append([]byte{}, resp.Body...),
+		Version:    resp.Version,
+	}
+	for key, value := range resp.Headers {
+		clone.Headers[key] = value
+	}
+	if resp.extraHeaders != nil {
+		clone.extraHeaders = make(map[string][]string, len(resp.extraHeaders))
+		for key, values := range resp.extraHeaders {
+			clone.extraHeaders[key] = append([]string(nil), values...)
+		}
+	}
+	if resp.Trailers != nil {
+		clone.Trailers = make(map[string]string, len(resp.Trailers))
+		for key, value := range resp.Trailers {
+			clone.Trailers[key] = value
+		}
+	}
+	return clone
+}
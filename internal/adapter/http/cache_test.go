@@ -0,0 +1,102 @@
+package http
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestCacheMiddleware_MissThenHit verifies the second request is served from cache with Age and Cache-Control.
+func TestCacheMiddleware_MissThenHit(t *testing.T) {
+	clock := newFakeClock()
+	calls := 0
+	handler := CacheMiddlewareWithClock(time.Minute, nil, clock)(func(req *Request) *Response {
+		calls++
+		return NewResponse().WriteString("report " + strconv.Itoa(calls))
+	})
+	req := &Request{Method: "GET", Path: "/report", RawQuery: "year=2024", Headers: map[string]string{}}
+
+	first := handler(req)
+	if _, ok := first.Headers["Age"]; ok || string(first.Body) != "report 1" {
+		t.Fatalf("expected an uncached first response, got %v %q", first.Headers, string(first.Body))
+	}
+	clock.Advance(20 * time.Second)
+	second := handler(req)
+	if calls != 1 || string(second.Body) != "report 1" {
+		t.Fatalf("expected the cached body without calling the handler, got %d calls and %q", calls, string(second.Body))
+	}
+	if second.Headers["Age"] != "20" || second.Headers["Cache-Control"] != "max-age=40" {
+		t.Fatalf("expected Age 20 and max-age=40, got %v", second.Headers)
+	}
+
+	other := handler(&Request{Method: "GET", Path: "/report", RawQuery: "year=2023", Headers: map[string]string{}})
+	if calls != 2 || string(other.Body) != "report 2" {
+		t.Fatalf("expected a different query to miss, got %d calls and %q", calls, string(other.Body))
+	}
+}
+
+// TestCacheMiddleware_ExpiresAfterTTL verifies entries are refreshed once the TTL elapses and errors are not cached.
+func TestCacheMiddleware_ExpiresAfterTTL(t *testing.T) {
+	clock := newFakeClock()
+	calls := 0
+	status := 500
+	handler := CacheMiddlewareWithClock(time.Minute, func(req *Request) string { return req.Path }, clock)(func(req *Request) *Response {
+		calls++
+		return NewResponse().Status(status).WriteString(strconv.Itoa(calls))
+	})
+	req := &Request{Method: "GET", Path: "/report", Headers: map[string]string{}}
+
+	handler(req)
+	status = 200
+	handler(req)
+	if calls != 2 {
+		t.Fatalf("expected a 500 not to be cached, got %d calls", calls)
+	}
+	clock.Advance(59 * time.Second)
+	if resp := handler(req); calls != 2 || string(resp.Body) != "2" {
+		t.Fatalf("expected a hit before the TTL, got %d calls and %q", calls, string(resp.Body))
+	}
+	clock.Advance(time.Second)
+	if resp := handler(req); calls != 3 || string(resp.Body) != "3" {
+		t.Fatalf("expected a miss at the TTL, got %d calls and %q", calls, string(resp.Body))
+	}
+}
+
+// TestCacheMiddleware_SkipsPerUserRequestsAndResponses verifies credentialed requests and no-store or private responses are never served from cache.
+func TestCacheMiddleware_SkipsPerUserRequestsAndResponses(t *testing.T) {
+	calls := 0
+	cacheControl := ""
+	handler := CacheMiddlewareWithClock(time.Minute, nil, newFakeClock())(func(req *Request) *Response {
+		calls++
+		resp := NewResponse().WriteString(strconv.Itoa(calls))
+		if cacheControl != "" {
+			resp.SetHeader("Cache-Control", cacheControl)
+		}
+		return resp
+	})
+
+	for _, headers := range []map[string]string{
+		{"authorization": "Bearer alice"},
+		{"cookie": "sid=alice"},
+	} {
+		before := calls
+		handler(&Request{Method: "GET", Path: "/me", Headers: headers})
+		if resp := handler(&Request{Method: "GET", Path: "/me", Headers: headers}); calls != before+2 {
+			t.Fatalf("expected %v to bypass the cache, got %d calls and %q", headers, calls-before, string(resp.Body))
+		}
+	}
+	if resp := handler(&Request{Method: "GET", Path: "/me", Headers: map[string]string{}}); resp.Headers["Age"] != "" {
+		t.Fatalf("expected a credentialed response not to be stored, got %v", resp.Headers)
+	}
+
+	for _, value := range []string{"no-store", "private, max-age=60"} {
+		cacheControl = value
+		path := "/" + value
+		handler(&Request{Method: "GET", Path: path, Headers: map[string]string{}})
+		before := calls
+		handler(&Request{Method: "GET", Path: path, Headers: map[string]string{}})
+		if calls != before+1 {
+			t.Fatalf("expected Cache-Control %q not to be cached, got %d calls", value, calls-before)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"errors"
+	"strings"
+)
+
+// CleanPathMode selects how the router treats non-canonical request paths.
+type CleanPathMode int
+
+const (
+	// CleanPathOff matches the path exactly as received.
+	CleanPathOff CleanPathMode = iota
+	// CleanPathRewrite collapses duplicate slashes and resolves "." and ".."
+	// segments before route lookup.
+	CleanPathRewrite
+	// CleanPathRedirect cleans like CleanPathRewrite but answers GET and HEAD
+	// requests for a non-canonical path with a 301 to the cleaned path.
+	CleanPathRedirect
+)
+
+// errPathAboveRoot reports a ".." segment that would climb above "/".
+var errPathAboveRoot = errors.New("path escapes root")
+
+// cleanPath collapses duplicate slashes and resolves "." and ".." segments,
+// keeping a trailing slash. It fails when ".." would climb above the root.
+func cleanPath(path string) (string, error) {
+	if path == "" {
+		return "/", nil
+	}
+
+	segments := strings.Split(path, "/")
+	cleaned := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		switch segment {
+		case "", ".":
+		case "..":
+			if len(cleaned) == 0 {
+				return "", errPathAboveRoot
+			}
+			cleaned = cleaned[:len(cleaned)-1]
+		default:
+			cleaned = append(cleaned, segment)
+		}
+	}
+
+	result := "/" + strings.Join(cleaned, "/")
+	if len(cleaned) > 0 && isDirectoryPath(path) {
+		result += "/"
+	}
+	return result, nil
+}
+
+// isDirectoryPath reports whether path ends in a slash or a trailing dot
+// segment, either of which names a directory after cleaning.
+func isDirectoryPath(path string) bool {
+	return strings.HasSuffix(path, "/") || strings.HasSuffix(path, "/.") || strings.HasSuffix(path, "/..")
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so timeouts and durations can be controlled in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the default Clock backed by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+// Now returns the current wall-clock time.
+func (systemClock) Now() time.Time { return time.Now() }
+
+// After waits for the duration to elapse on the wall clock.
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clockOrDefault returns clock, falling back to SystemClock when nil.
+func clockOrDefault(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
+
+// clockTimeoutContext is canceled when a Clock timer fires and then reports
+// context.DeadlineExceeded, mirroring context.WithTimeout for any Clock.
+type clockTimeoutContext struct {
+	context.Context
+	deadline time.Time
+
+	mu      sync.Mutex
+	expired bool
+}
+
+// withClockTimeout returns a child context that expires after timeout on clock.
+func withClockTimeout(parent context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if clock == SystemClock {
+		return context.WithTimeout(parent, timeout)
+	}
+
+	inner, cancel := context.WithCancel(parent)
+	ctx := &clockTimeoutContext{Context: inner, deadline: clock.Now().Add(timeout)}
+	timer := clock.After(timeout)
+	go func() {
+		select {
+		case <-timer:
+			ctx.mu.Lock()
+			ctx.expired = inner.Err() == nil
+			ctx.mu.Unlock()
+			cancel()
+		case <-inner.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Deadline reports the clock-based deadline.
+func (c *clockTimeoutContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// Err reports context.DeadlineExceeded once the clock timer has fired.
+func (c *clockTimeoutContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.expired {
+		return context.DeadlineExceeded
+	}
+	return c.Context.Err()
+}
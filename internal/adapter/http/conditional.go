@@ -0,0 +1,42 @@
+package http
+
+import "strconv"
+
+// SetETag sets a strong ETag derived from the current body and returns r
+// for chaining. Call it after the body is complete.
+func (r *Response) SetETag() *Response {
+	return r.SetHeader("ETag", contentETag(r.Body))
+}
+
+// ConditionalMiddleware answers GET and HEAD requests with 304 Not Modified
+// and no body when the 200 response the handler produced still matches the
+// client's copy: If-None-Match is compared against the response ETag,
+// computed from the body when the handler set none, and otherwise
+// If-Modified-Since is compared against Last-Modified. The 304 keeps the
+// other headers, including a Content-Length for the omitted body.
+// Streamed and raw responses pass through untouched.
+func ConditionalMiddleware() Middleware {
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			resp := safeInvoke(next, req)
+			method := requestMethod(req)
+			if (method != "GET" && method != "HEAD") || resp.StatusCode != 200 || resp.Stream != nil || resp.Raw != nil {
+				return resp
+			}
+			if !hasHeaderIgnoreCase(resp.Headers, "ETag") {
+				resp.SetETag()
+			}
+			etag := headerValueIgnoreCase(resp.Headers, "ETag")
+			modTime, _ := parseHTTPDate(headerValueIgnoreCase(resp.Headers, "Last-Modified"))
+			if checkPreconditions(req, method, etag, modTime) != 304 {
+				return resp
+			}
+			if !hasHeaderIgnoreCase(resp.Headers, "Content-Length") && len(resp.Body) > 0 {
+				resp.SetHeader("Content-Length", strconv.Itoa(len(resp.Body)))
+			}
+			resp.StatusCode = 304
+			resp.Body = nil
+			return resp
+		}
+	}
+}
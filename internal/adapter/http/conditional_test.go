@@ -0,0 +1,52 @@
+package http
+
+import (
+	"testing"
+	"time"
+)
+
+// TestConditionalMiddleware_IfNoneMatch verifies a matching ETag yields 304 and a stale one the full body.
+func TestConditionalMiddleware_IfNoneMatch(t *testing.T) {
+	handler := ConditionalMiddleware()(func(req *Request) *Response {
+		return NewResponse().WriteString("catalog").SetETag()
+	})
+	etag := handler(&Request{Method: "GET", Path: "/", Headers: map[string]string{}}).Headers["ETag"]
+	if etag == "" {
+		t.Fatalf("expected an ETag on the first response")
+	}
+
+	matched := handler(&Request{Method: "GET", Path: "/", Headers: map[string]string{"if-none-match": `"stale", ` + etag}})
+	if matched.StatusCode != 304 || len(matched.Body) != 0 || matched.Headers["ETag"] != etag {
+		t.Fatalf("expected 304 with ETag and no body, got %d %q %v", matched.StatusCode, string(matched.Body), matched.Headers)
+	}
+	if matched.Headers["Content-Length"] != "7" {
+		t.Fatalf("expected the omitted body length, got %q", matched.Headers["Content-Length"])
+	}
+
+	stale := handler(&Request{Method: "GET", Path: "/", Headers: map[string]string{"if-none-match": `"stale"`}})
+	if stale.StatusCode != 200 || string(stale.Body) != "catalog" {
+		t.Fatalf("expected 200 with body, got %d %q", stale.StatusCode, string(stale.Body))
+	}
+}
+
+// TestConditionalMiddleware_IfModifiedSince verifies Last-Modified is compared when no If-None-Match is sent.
+func TestConditionalMiddleware_IfModifiedSince(t *testing.T) {
+	modified := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	handler := ConditionalMiddleware()(func(req *Request) *Response {
+		return NewResponse().Header("Last-Modified", modified.Format(httpDateFormat)).WriteString("catalog")
+	})
+
+	tests := []struct {
+		since time.Time
+		want  int
+	}{
+		{since: modified, want: 304},
+		{since: modified.Add(-time.Second), want: 200},
+	}
+	for _, tt := range tests {
+		resp := handler(&Request{Method: "GET", Path: "/", Headers: map[string]string{"if-modified-since": tt.since.Format(httpDateFormat)}})
+		if resp.StatusCode != tt.want {
+			t.Fatalf("If-Modified-Since %v: expected %d, got %d", tt.since, tt.want, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"mime"
+	nethttp "net/http"
+	"strings"
+)
+
+// sniffableTypes are the media types content sniffing recognizes from a
+// leading signature, so a body declared or detected as one of them can be
+// checked against the other.
+var sniffableTypes = map[string]struct{}{
+	"image/x-icon": {}, "image/bmp": {}, "image/gif": {}, "image/webp": {},
+	"image/png": {}, "image/jpeg": {},
+	"audio/basic": {}, "audio/aiff": {}, "audio/mpeg": {}, "audio/midi": {},
+	"audio/wave": {}, "application/ogg": {},
+	"video/avi": {}, "video/mp4": {}, "video/webm": {},
+	"font/ttf": {}, "font/otf": {}, "font/collection": {}, "font/woff": {}, "font/woff2": {},
+	"application/x-gzip": {}, "application/zip": {}, "application/x-rar-compressed": {},
+	"application/wasm": {}, "application/pdf": {}, "application/postscript": {},
+}
+
+// mediaTypeAliases maps common alternative names to the ones sniffing reports.
+var mediaTypeAliases = map[string]string{
+	"image/jpg":                "image/jpeg",
+	"image/vnd.microsoft.icon": "image/x-icon",
+	"audio/wav":                "audio/wave",
+	"audio/x-wav":              "audio/wave",
+	"application/gzip":         "application/x-gzip",
+	"application/vnd.rar":      "application/x-rar-compressed",
+}
+
+// EnforceDeclaredContentTypeMiddleware answers 415 when a request body's
+// leading bytes contradict its Content-Type, such as text labeled
+// image/png or a PNG labeled text/plain. Only types recognizable from a
+// signature are checked; requests without a body or Content-Type, and
+// bodies declared application/octet-stream, pass through.
+func EnforceDeclaredContentTypeMiddleware() Middleware {
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			if req != nil && len(req.Body) > 0 && !declaredTypeMatchesBody(req.Headers["content-type"], req.Body) {
+				return withoutBodyForHead(req, statusResponse(415))
+			}
+			return safeInvoke(next, req)
+		}
+	}
+}
+
+// declaredTypeMatchesBody reports whether body is compatible with the
+// declared Content-Type value.
+func declaredTypeMatchesBody(declared string, body []byte) bool {
+	declaredType := normalizeMediaType(declared)
+	if declaredType == "" || declaredType == "application/octet-stream" {
+		return true
+	}
+	sniffedType := normalizeMediaType(nethttp.DetectContentType(body))
+	_, declaredSniffable := sniffableTypes[declaredType]
+	_, sniffedSniffable := sniffableTypes[sniffedType]
+	if !declaredSniffable && !sniffedSniffable {
+		return true
+	}
+	return declaredType == sniffedType
+}
+
+// normalizeMediaType returns the lowercase media type of a Content-Type
+// value without parameters, resolving aliases.
+func normalizeMediaType(value string) string {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(value)), ";")
+	}
+	if alias, ok := mediaTypeAliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
@@ -0,0 +1,40 @@
+package http
+
+import "testing"
+
+// TestEnforceDeclaredContentTypeMiddleware verifies bodies are checked against their declared signature type.
+func TestEnforceDeclaredContentTypeMiddleware(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	handler := EnforceDeclaredContentTypeMiddleware()(func(req *Request) *Response {
+		return NewResponse().Status(201)
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        int
+	}{
+		{name: "png labeled png", contentType: "image/png", body: png, want: 201},
+		{name: "text labeled png", contentType: "image/png", body: []byte("definitely not an image"), want: 415},
+		{name: "png labeled text", contentType: "text/plain; charset=utf-8", body: png, want: 415},
+		{name: "json labeled json", contentType: "application/json", body: []byte(`{"ok":true}`), want: 201},
+		{name: "png labeled octet-stream", contentType: "application/octet-stream", body: png, want: 201},
+		{name: "no content type", body: []byte("anything"), want: 201},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.contentType != "" {
+				headers["content-type"] = tt.contentType
+			}
+			resp := handler(&Request{Method: "POST", Path: "/avatar", Headers: headers, Body: tt.body})
+			if resp.StatusCode != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+			if tt.want == 415 && string(resp.Body) != "Unsupported Media Type" {
+				t.Fatalf("expected reason phrase body, got %q", string(resp.Body))
+			}
+		})
+	}
+}
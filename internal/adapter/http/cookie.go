@@ -0,0 +1,191 @@
+package http
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SameSite is the SameSite attribute of a cookie.
+type SameSite string
+
+// SameSite attribute values; the empty value omits the attribute.
+const (
+	SameSiteLax    SameSite = "Lax"
+	SameSiteStrict SameSite = "Strict"
+	SameSiteNone   SameSite = "None"
+)
+
+// Cookie describes a cookie sent with Response.SetCookie. A zero Expires is
+// omitted; MaxAge zero omits Max-Age, and a negative MaxAge sends Max-Age=0
+// to delete the cookie.
+type Cookie struct {
+	Name     string
+	Value    string
+	Path     string
+	Domain   string
+	Expires  time.Time
+	MaxAge   int
+	Secure   bool
+	HttpOnly bool
+	SameSite SameSite
+}
+
+// String serializes the cookie as a Set-Cookie header value. Value bytes
+// that are not allowed in a cookie are percent-encoded. Like net/http, it
+// returns "" when Name is not a valid token, drops control characters and
+// ';' from Path, and omits a Domain or SameSite value that is not valid, so
+// no field can inject attributes or headers.
+func (c Cookie) String() string {
+	if !isCookieNameValid(c.Name) {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(c.Name)
+	b.WriteString("=")
+	b.WriteString(escapeCookieValue(c.Value))
+	if path := sanitizeCookiePath(c.Path); path != "" {
+		b.WriteString("; Path=")
+		b.WriteString(path)
+	}
+	if c.Domain != "" && isCookieDomainValid(c.Domain) {
+		b.WriteString("; Domain=")
+		b.WriteString(c.Domain)
+	}
+	if !c.Expires.IsZero() {
+		b.WriteString("; Expires=")
+		b.WriteString(c.Expires.UTC().Format(httpDateFormat))
+	}
+	switch {
+	case c.MaxAge > 0:
+		b.WriteString("; Max-Age=")
+		b.WriteString(strconv.Itoa(c.MaxAge))
+	case c.MaxAge < 0:
+		b.WriteString("; Max-Age=0")
+	}
+	if c.HttpOnly {
+		b.WriteString("; HttpOnly")
+	}
+	if c.Secure {
+		b.WriteString("; Secure")
+	}
+	switch c.SameSite {
+	case SameSiteLax, SameSiteStrict, SameSiteNone:
+		b.WriteString("; SameSite=")
+		b.WriteString(string(c.SameSite))
+	}
+	return b.String()
+}
+
+// SetCookie adds a Set-Cookie header for c, keeping cookies set earlier. A
+// cookie with an invalid name is not sent. It returns r for chaining.
+func (r *Response) SetCookie(c Cookie) *Response {
+	value := c.String()
+	if value == "" {
+		return r
+	}
+	return r.AddHeader("Set-Cookie", value)
+}
+
+// isCookieNameValid reports whether name is a non-empty HTTP token.
+func isCookieNameValid(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeCookiePath drops control characters and ';' from a Path value.
+func sanitizeCookiePath(path string) string {
+	return strings.Map(func(c rune) rune {
+		if c < 0x20 || c == 0x7f || c == ';' {
+			return -1
+		}
+		return c
+	}, path)
+}
+
+// isCookieDomainValid reports whether domain holds only letters, digits,
+// '-', '.', and ':' for IPv6 literals.
+func isCookieDomainValid(domain string) bool {
+	for i := 0; i < len(domain); i++ {
+		c := domain[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == ':') {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeCookieValue percent-encodes bytes outside the RFC 6265 cookie-octet
+// set, plus '%' itself so the encoding can be reversed.
+func escapeCookieValue(value string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isCookieOctet(c) && c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
+
+// isCookieOctet reports whether c may appear unquoted in a cookie value.
+func isCookieOctet(c byte) bool {
+	return c == 0x21 || (c >= 0x23 && c <= 0x2b) || (c >= 0x2d && c <= 0x3a) ||
+		(c >= 0x3c && c <= 0x5b) || (c >= 0x5d && c <= 0x7e)
+}
+
+// Cookies returns the request cookies parsed from the Cookie header. Pairs
+// are split on ';' and trimmed; surrounding double quotes are removed from
+// values, segments without a name are skipped, and the first occurrence of a
+// name wins. Values are returned as sent, without percent-decoding. The
+// result is cached, so it must not be modified and later header changes are
+// not observed.
+func (r *Request) Cookies() map[string]string {
+	if r == nil {
+		return map[string]string{}
+	}
+	if r.cookies == nil {
+		r.cookies = parseCookieHeader(r.Headers["cookie"])
+	}
+	return r.cookies
+}
+
+// Cookie returns the value of the named request cookie.
+func (r *Request) Cookie(name string) (string, bool) {
+	value, ok := r.Cookies()[name]
+	return value, ok
+}
+
+// parseCookieHeader parses a Cookie header value into name/value pairs.
+func parseCookieHeader(header string) map[string]string {
+	cookies := make(map[string]string)
+	for _, segment := range strings.Split(header, ";") {
+		name, value, _ := strings.Cut(segment, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, seen := cookies[name]; seen {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		cookies[name] = value
+	}
+	return cookies
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestCookie_String verifies attribute formatting and value encoding.
+func TestCookie_String(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie Cookie
+		want   string
+	}{
+		{
+			name:   "session attributes",
+			cookie: Cookie{Name: "session", Value: "abc123", Path: "/", HttpOnly: true, Secure: true, SameSite: SameSiteLax},
+			want:   "session=abc123; Path=/; HttpOnly; Secure; SameSite=Lax",
+		},
+		{
+			name:   "max age and domain",
+			cookie: Cookie{Name: "theme", Value: "dark", Domain: "example.com", MaxAge: 3600},
+			want:   "theme=dark; Domain=example.com; Max-Age=3600",
+		},
+		{
+			name:   "delete with expires",
+			cookie: Cookie{Name: "old", MaxAge: -1, Expires: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+			want:   "old=; Expires=Tue, 02 Jan 2024 03:04:05 GMT; Max-Age=0",
+		},
+		{
+			name:   "invalid name",
+			cookie: Cookie{Name: "a b\r\nX-Injected: 1", Value: "v"},
+			want:   "",
+		},
+		{
+			name:   "unsafe path, domain, and same-site",
+			cookie: Cookie{Name: "id", Value: "1", Path: "/app;\r\nX-Injected: 1", Domain: "example.com; Secure", SameSite: "Lax; Domain=evil.test"},
+			want:   "id=1; Path=/appX-Injected: 1",
+		},
+		{
+			name:   "escaped value",
+			cookie: Cookie{Name: "note", Value: `a b;c,"d"%`},
+			want:   "note=a%20b%3Bc%2C%22d%22%25",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cookie.String(); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestResponse_SetCookieKeepsEarlierCookies verifies several cookies coexist as Set-Cookie values.
+func TestResponse_SetCookieKeepsEarlierCookies(t *testing.T) {
+	resp := NewResponse().
+		SetCookie(Cookie{Name: "a", Value: "1", HttpOnly: true}).
+		SetCookie(Cookie{Name: "b", Value: "2", SameSite: SameSiteStrict})
+
+	want := []string{"a=1; HttpOnly", "b=2; SameSite=Strict"}
+	if got := resp.HeaderValues("Set-Cookie"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected Set-Cookie values %v, got %v", want, got)
+	}
+}
+
+// TestRequest_Cookies verifies Cookie header parsing, including malformed input.
+func TestRequest_Cookies(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   map[string]string
+	}{
+		{name: "single", header: "session=abc", want: map[string]string{"session": "abc"}},
+		{name: "multiple", header: `session=abc; theme="dark";lang=en`, want: map[string]string{"session": "abc", "theme": "dark", "lang": "en"}},
+		{name: "malformed", header: `;; =orphan; flag; a=1; a=2; "=x; b="`, want: map[string]string{"flag": "", "a": "1", `"`: "x", "b": `"`}},
+		{name: "absent", want: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Headers: map[string]string{}}
+			if tt.header != "" {
+				req.Headers["cookie"] = tt.header
+			}
+			if got := req.Cookies(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected cookies %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestRequest_CookieLookupIsCached verifies Cookie reads the cached parse.
+func TestRequest_CookieLookupIsCached(t *testing.T) {
+	req := &Request{Headers: map[string]string{"cookie": "id=42"}}
+	if value, ok := req.Cookie("id"); !ok || value != "42" {
+		t.Fatalf("expected id=42, got %q, %v", value, ok)
+	}
+	req.Headers["cookie"] = "id=7"
+	if value, _ := req.Cookie("id"); value != "42" {
+		t.Fatalf("expected the cached value 42, got %q", value)
+	}
+	if _, ok := req.Cookie("missing"); ok {
+		t.Fatalf("expected missing cookie to be absent")
+	}
+}
+
+// TestResponse_SetCookieSkipsInvalidName verifies a cookie with an invalid name adds no header.
+func TestResponse_SetCookieSkipsInvalidName(t *testing.T) {
+	resp := NewResponse().SetCookie(Cookie{Name: "bad;name", Value: "1"})
+	if got := resp.HeaderValues("Set-Cookie"); len(got) != 0 {
+		t.Fatalf("expected no Set-Cookie header, got %v", got)
+	}
+}
+
+// TestRequest_CookiesNilReceiver verifies a nil request has no cookies.
+func TestRequest_CookiesNilReceiver(t *testing.T) {
+	var req *Request
+	if got := req.Cookies(); len(got) != 0 {
+		t.Fatalf("expected no cookies, got %v", got)
+	}
+	if _, ok := req.Cookie("id"); ok {
+		t.Fatalf("expected a nil request to have no cookie")
+	}
+}
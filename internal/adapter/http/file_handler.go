@@ -0,0 +1,117 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileHandler serves the file under root named by the request path, for
+// GET and HEAD, with the Range and conditional handling of ServeContent.
+// The file is streamed from disk rather than read into memory, and its ETag
+// is derived from its size and modification time. The Content-Type is
+// guessed from the file extension. Missing files and directories answer
+// 404, paths that would climb out of root answer 403, and other methods
+// answer 405. Mount it under a prefix together with StripPrefixMiddleware.
+// Symlinks are followed only while they resolve inside root; one leading
+// outside root answers 404.
+func FileHandler(root string) HandlerAdapter {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	realRoot := root
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		realRoot = resolved
+	}
+	return func(req *Request) *Response {
+		method := requestMethod(req)
+		if method != "GET" && method != "HEAD" {
+			return withoutBodyForHead(req, methodNotAllowedResponse([]string{"GET", "HEAD"}))
+		}
+
+		name, ok := resolveFilePath(root, requestPath(req))
+		if !ok {
+			return withoutBodyForHead(req, statusResponse(403))
+		}
+		target, err := filepath.EvalSymlinks(name)
+		if err == nil && !withinRoot(realRoot, target) {
+			return withoutBodyForHead(req, statusResponse(404))
+		}
+		var info fs.FileInfo
+		if err == nil {
+			info, err = statFile(target)
+		}
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return withoutBodyForHead(req, statusResponse(404))
+		case errors.Is(err, fs.ErrPermission):
+			return withoutBodyForHead(req, statusResponse(403))
+		case err != nil:
+			return withoutBodyForHead(req, internalServerErrorResponse())
+		case info.IsDir():
+			return withoutBodyForHead(req, statusResponse(404))
+		}
+		size := int(info.Size())
+		return serveContent(req, mime.TypeByExtension(filepath.Ext(name)), info.ModTime(), fileETag(info), size,
+			func(resp *Response, contentType string, ranges []byteRange, boundary string) *Response {
+				if ranges == nil {
+					ranges = []byteRange{{start: 0, end: size}}
+				}
+				if method == "HEAD" {
+					if len(ranges) == 1 {
+						resp.SetHeader("Content-Length", strconv.Itoa(ranges[0].end-ranges[0].start))
+					}
+					return resp
+				}
+				resp.Stream = func(w io.Writer) error {
+					file, err := os.Open(target)
+					if err != nil {
+						return err
+					}
+					defer file.Close()
+					return writeRanges(w, file, size, contentType, ranges, boundary)
+				}
+				return resp
+			})
+	}
+}
+
+// statFile opens name to check it is readable and returns its FileInfo.
+func statFile(name string) (fs.FileInfo, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return file.Stat()
+}
+
+// fileETag returns a strong ETag for a file from its size and modification
+// time, so serving it does not require hashing its content.
+func fileETag(info fs.FileInfo) string {
+	return `"` + strconv.FormatInt(info.ModTime().UnixNano(), 16) + "-" + strconv.FormatInt(info.Size(), 16) + `"`
+}
+
+// resolveFilePath maps a request path to a file path under root. It
+// reports false when the cleaned path leaves root or the path holds a NUL
+// byte or, on Windows, a backslash that could smuggle in a separator.
+func resolveFilePath(root, path string) (string, bool) {
+	if strings.ContainsRune(path, 0) || (filepath.Separator != '/' && strings.ContainsRune(path, filepath.Separator)) {
+		return "", false
+	}
+	name := filepath.Join(root, filepath.FromSlash(path))
+	if !withinRoot(root, name) {
+		return "", false
+	}
+	return name, true
+}
+
+// withinRoot reports whether the cleaned path name is root or lies under it.
+func withinRoot(root, name string) bool {
+	return name == root || strings.HasPrefix(name, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFileHandler verifies files are served with type and length, and missing or escaping paths are refused.
+func TestFileHandler(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "public")
+	if err := os.MkdirAll(filepath.Join(root, "css"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "css", "site.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	handler := FileHandler(root)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "served file", path: "/css/site.css", want: 200},
+		{name: "missing file", path: "/css/missing.css", want: 404},
+		{name: "directory", path: "/css", want: 404},
+		{name: "traversal", path: "/../../etc/passwd", want: 403},
+		{name: "sibling escape", path: "/../secret.txt", want: 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := handler(&Request{Method: "GET", Path: tt.path, Headers: map[string]string{}})
+			if resp.StatusCode != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+			if tt.want != 200 {
+				return
+			}
+			if resp.Stream == nil {
+				t.Fatalf("expected the file to be streamed, got body %q", string(resp.Body))
+			}
+			var body bytes.Buffer
+			if err := resp.Stream(&body); err != nil {
+				t.Fatalf("stream failed: %v", err)
+			}
+			if body.String() != "body{}" {
+				t.Fatalf("expected file body, got %q", body.String())
+			}
+			if got := resp.Headers["Content-Type"]; got != "text/css; charset=utf-8" {
+				t.Fatalf("expected a CSS content type, got %q", got)
+			}
+		})
+	}
+}
+
+// TestFileHandler_RangesAndHead verifies ranges are streamed from the file and HEAD states the length without a body.
+func TestFileHandler_RangesAndHead(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "data.txt"), []byte("0123456789"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	handler := FileHandler(root)
+
+	resp := handler(&Request{Method: "GET", Path: "/data.txt", Headers: map[string]string{"range": "bytes=2-5"}})
+	if resp.StatusCode != 206 || resp.Headers["Content-Range"] != "bytes 2-5/10" {
+		t.Fatalf("expected 206 for bytes 2-5, got %d %v", resp.StatusCode, resp.Headers)
+	}
+	var body bytes.Buffer
+	if err := resp.Stream(&body); err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if body.String() != "2345" {
+		t.Fatalf("expected range body %q, got %q", "2345", body.String())
+	}
+
+	resp = handler(&Request{Method: "GET", Path: "/data.txt", Headers: map[string]string{"range": "bytes=0-1,8-9"}})
+	if resp.StatusCode != 206 {
+		t.Fatalf("expected 206 for multiple ranges, got %d", resp.StatusCode)
+	}
+	body.Reset()
+	if err := resp.Stream(&body); err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if !strings.Contains(body.String(), "01") || !strings.Contains(body.String(), "89") || !strings.Contains(body.String(), "bytes 8-9/10") {
+		t.Fatalf("expected multipart body with both ranges, got %q", body.String())
+	}
+
+	etag := resp.Headers["ETag"]
+	resp = handler(&Request{Method: "HEAD", Path: "/data.txt", Headers: map[string]string{}})
+	if resp.StatusCode != 200 || resp.Stream != nil || len(resp.Body) != 0 || resp.Headers["Content-Length"] != "10" {
+		t.Fatalf("expected bodiless HEAD with Content-Length 10, got %d %v", resp.StatusCode, resp.Headers)
+	}
+
+	resp = handler(&Request{Method: "GET", Path: "/data.txt", Headers: map[string]string{"if-none-match": etag}})
+	if resp.StatusCode != 304 {
+		t.Fatalf("expected 304 for a matching ETag, got %d", resp.StatusCode)
+	}
+}
+
+// TestFileHandler_Symlinks verifies symlinks resolving inside root are served and ones leading outside answer 404.
+func TestFileHandler_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "public")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "index.txt"), []byte("inside"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "index.txt"), filepath.Join(root, "alias.txt")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "leak.txt")); err != nil {
+		t.Fatalf("symlink failed: %v", err)
+	}
+	if err := os.Symlink(dir, filepath.Join(root, "parent")); err != nil {
+		t.Fatalf("symlink failed: %v", err)
+	}
+	handler := FileHandler(root)
+
+	resp := handler(&Request{Method: "GET", Path: "/alias.txt", Headers: map[string]string{}})
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected a symlink inside root to be served, got %d", resp.StatusCode)
+	}
+	for _, path := range []string{"/leak.txt", "/parent/secret.txt"} {
+		if resp := handler(&Request{Method: "GET", Path: path, Headers: map[string]string{}}); resp.StatusCode != 404 {
+			t.Fatalf("expected %s leading outside root to answer 404, got %d", path, resp.StatusCode)
+		}
+	}
+}
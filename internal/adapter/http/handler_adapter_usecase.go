@@ -1,31 +1,117 @@
 package http
 
 import (
+	"context"
 	"errors"
 
 	"github.com/jamalishaq/light_serve/internal/domain"
 	"github.com/jamalishaq/light_serve/internal/usecase"
 )
 
+// StatusClientClosedRequest is the non-standard status for requests whose
+// client went away before a response was produced.
+const StatusClientClosedRequest = 499
+
+// UseCaseAdapterOptions configures AdaptUseCaseHandlerWithOptions.
+type UseCaseAdapterOptions struct {
+	// CanceledStatus is returned when the use case fails with context.Canceled.
+	// Zero defaults to StatusClientClosedRequest.
+	CanceledStatus int
+	// EnsureCorrelationID generates an X-Correlation-Id when the request
+	// has none, so the use case context and input always carry one, and
+	// echoes the id in the X-Correlation-Id response header.
+	EnsureCorrelationID bool
+}
+
 // AdaptUseCaseHandler translates HTTP requests to use case input and back to HTTP responses.
 func AdaptUseCaseHandler(handler usecase.Handler) HandlerAdapter {
+	return AdaptUseCaseHandlerWithOptions(handler, UseCaseAdapterOptions{})
+}
+
+// AdaptUseCaseHandlerWithOptions adapts a use case handler with explicit options.
+func AdaptUseCaseHandlerWithOptions(handler usecase.Handler, opts UseCaseAdapterOptions) HandlerAdapter {
+	if opts.CanceledStatus == 0 {
+		opts.CanceledStatus = StatusClientClosedRequest
+	}
+
 	return func(req *Request) *Response {
 		if handler == nil {
 			return internalServerErrorResponse()
 		}
 
-		input := toUseCaseInput(req)
-		output, err := handler.Handle(req.Context(), input)
-		if err != nil {
-			return mapUseCaseError(err)
+		if !opts.EnsureCorrelationID {
+			return handleUseCase(handler, req, opts)
+		}
+		req, correlationID := withCorrelationID(req)
+		resp := handleUseCase(handler, req, opts)
+		resp.SetHeader("X-Correlation-Id", correlationID)
+		return resp
+	}
+}
+
+// handleUseCase runs handler for req and maps its result to a response.
+func handleUseCase(handler usecase.Handler, req *Request, opts UseCaseAdapterOptions) *Response {
+	input := toUseCaseInput(req)
+	output, err := handler.Handle(useCaseContext(req), input)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return statusResponse(opts.CanceledStatus)
 		}
+		return mapUseCaseError(err)
+	}
+
+	return fromUseCaseOutput(output)
+}
 
-		resp := NewResponse()
+// withCorrelationID returns req and its X-Correlation-Id, or a copy of req
+// carrying a generated id when it has none.
+func withCorrelationID(req *Request) (*Request, string) {
+	if _, correlationID := requestIdentifiers(req); correlationID != "" {
+		return req, correlationID
+	}
+	correlationID := randomHex(16)
+	var cloned Request
+	if req != nil {
+		cloned = *req
+	}
+	cloned.Headers = copyHeaders(cloned.Headers)
+	if cloned.Headers == nil {
+		cloned.Headers = make(map[string]string, 1)
+	}
+	cloned.Headers["x-correlation-id"] = correlationID
+	return &cloned, correlationID
+}
+
+// fromUseCaseOutput converts use case output into an HTTP response, defaulting
+// the status to 200 and Content-Type to text/plain.
+func fromUseCaseOutput(output usecase.ResponseOutput) *Response {
+	resp := NewResponse()
+	resp.StatusCode = output.StatusCode
+	if resp.StatusCode == 0 {
 		resp.StatusCode = 200
+	}
+	for key, value := range output.Headers {
+		resp.SetHeader(key, value)
+	}
+	if !hasHeaderIgnoreCase(resp.Headers, "Content-Type") {
 		resp.SetHeader("Content-Type", "text/plain")
-		resp.WriteBytes(output.Body)
-		return resp
 	}
+	resp.WriteBytes(output.Body)
+	return resp
+}
+
+// useCaseContext derives the context passed to a use case from the request,
+// carrying its X-Request-Id (generated when absent) and X-Correlation-Id.
+func useCaseContext(req *Request) context.Context {
+	requestID, correlationID := requestIdentifiers(req)
+	if requestID == "" {
+		requestID = randomHex(16)
+	}
+	ctx := usecase.WithRequestID(requestContext(req), requestID)
+	if correlationID != "" {
+		ctx = usecase.WithCorrelationID(ctx, correlationID)
+	}
+	return ctx
 }
 
 // toUseCaseInput converts an HTTP request into transport-agnostic use case input.
@@ -67,24 +153,34 @@ func copyBody(body []byte) []byte {
 
 // mapUseCaseError maps domain and application errors to HTTP responses.
 func mapUseCaseError(err error) *Response {
-	resp := NewResponse()
-	resp.SetHeader("Content-Type", "text/plain")
-
 	switch {
 	case errors.Is(err, domain.ErrBadRequest):
-		resp.StatusCode = 400
-		resp.WriteString("Bad Request")
+		return statusResponse(400)
 	case errors.Is(err, domain.ErrUnauthorized):
-		resp.StatusCode = 401
-		resp.WriteString("Unauthorized")
+		return statusResponse(401)
+	case errors.Is(err, domain.ErrForbidden):
+		return statusResponse(403)
 	case errors.Is(err, domain.ErrNotFound):
-		resp.StatusCode = 404
-		resp.WriteString("Not Found")
+		return statusResponse(404)
+	case errors.Is(err, domain.ErrConflict):
+		return statusResponse(409)
+	case errors.Is(err, domain.ErrValidation):
+		return statusResponse(422)
+	case errors.Is(err, domain.ErrRateLimited):
+		return statusResponse(429)
+	case errors.Is(err, context.DeadlineExceeded):
+		return statusResponse(504)
 	default:
-		resp.StatusCode = 500
-		resp.WriteString("Internal Server Error")
+		return statusResponse(500)
 	}
+}
 
+// statusResponse returns a plain-text response whose body is the reason phrase.
+func statusResponse(status int) *Response {
+	resp := NewResponse()
+	resp.StatusCode = status
+	resp.SetHeader("Content-Type", "text/plain")
+	resp.WriteString(statusText(status))
 	return resp
 }
 
@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/jamalishaq/light_serve/internal/domain"
@@ -61,6 +62,58 @@ func TestAdaptUseCaseHandler_ValidFlow(t *testing.T) {
 	}
 }
 
+// TestAdaptUseCaseHandler_OutputStatusAndHeaders verifies use case status and headers are honored.
+func TestAdaptUseCaseHandler_OutputStatusAndHeaders(t *testing.T) {
+	headers := map[string]string{
+		"Location":     "/users/42",
+		"Content-Type": "application/json",
+	}
+	stub := &stubUseCaseHandler{
+		output: usecase.ResponseOutput{
+			StatusCode: 201,
+			Headers:    headers,
+			Body:       []byte(`{"id":"42"}`),
+		},
+	}
+
+	resp := AdaptUseCaseHandler(stub)(&Request{Method: "POST", Path: "/users"})
+
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	if resp.Headers["Location"] != "/users/42" {
+		t.Fatalf("expected Location header, got %#v", resp.Headers)
+	}
+	if resp.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("expected use case Content-Type to be kept, got %#v", resp.Headers)
+	}
+	if string(resp.Body) != `{"id":"42"}` {
+		t.Fatalf("unexpected body %q", string(resp.Body))
+	}
+
+	resp.Headers["Location"] = "/mutated"
+	if headers["Location"] != "/users/42" {
+		t.Fatalf("expected response headers to be copied, use case map was mutated")
+	}
+}
+
+// TestAdaptUseCaseHandler_ZeroOutputDefaults verifies a zero-value output falls back to defaults.
+func TestAdaptUseCaseHandler_ZeroOutputDefaults(t *testing.T) {
+	stub := &stubUseCaseHandler{}
+
+	resp := AdaptUseCaseHandler(stub)(&Request{Method: "GET", Path: "/"})
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected default status 200, got %d", resp.StatusCode)
+	}
+	if resp.Headers["Content-Type"] != "text/plain" {
+		t.Fatalf("expected default Content-Type text/plain, got %#v", resp.Headers)
+	}
+	if len(resp.Body) != 0 {
+		t.Fatalf("expected empty body, got %q", string(resp.Body))
+	}
+}
+
 // TestAdaptUseCaseHandler_UsesRequestContext verifies request context is propagated.
 func TestAdaptUseCaseHandler_UsesRequestContext(t *testing.T) {
 	stub := &stubUseCaseHandler{
@@ -75,8 +128,70 @@ func TestAdaptUseCaseHandler_UsesRequestContext(t *testing.T) {
 	if resp.StatusCode != 200 {
 		t.Fatalf("expected status 200, got %d", resp.StatusCode)
 	}
-	if stub.gotCtx != reqCtx {
-		t.Fatalf("expected adapter to pass request context through")
+	cancel()
+	if stub.gotCtx.Err() != context.Canceled {
+		t.Fatalf("expected use case context to derive from the request context, got err %v", stub.gotCtx.Err())
+	}
+}
+
+// TestAdaptUseCaseHandler_PropagatesRequestIdentifiers verifies request and correlation IDs reach the use case context.
+func TestAdaptUseCaseHandler_PropagatesRequestIdentifiers(t *testing.T) {
+	stub := &stubUseCaseHandler{}
+	adapter := AdaptUseCaseHandler(stub)
+
+	adapter(&Request{Path: "/ids", Headers: map[string]string{
+		"x-request-id":     "req-123",
+		"x-correlation-id": "corr-456",
+	}})
+	if got := usecase.RequestIDFromContext(stub.gotCtx); got != "req-123" {
+		t.Fatalf("expected request ID req-123, got %q", got)
+	}
+	if got := usecase.CorrelationIDFromContext(stub.gotCtx); got != "corr-456" {
+		t.Fatalf("expected correlation ID corr-456, got %q", got)
+	}
+
+	adapter(&Request{Path: "/ids"})
+	first := usecase.RequestIDFromContext(stub.gotCtx)
+	if first == "" {
+		t.Fatalf("expected a generated request ID when the header is absent")
+	}
+	if got := usecase.CorrelationIDFromContext(stub.gotCtx); got != "" {
+		t.Fatalf("expected no correlation ID without the header, got %q", got)
+	}
+	adapter(&Request{Path: "/ids"})
+	if second := usecase.RequestIDFromContext(stub.gotCtx); second == first {
+		t.Fatalf("expected distinct generated request IDs, got %q twice", first)
+	}
+}
+
+// TestAdaptUseCaseHandlerWithOptions_EnsureCorrelationID verifies a generated correlation ID reaches the use case and the response.
+func TestAdaptUseCaseHandlerWithOptions_EnsureCorrelationID(t *testing.T) {
+	stub := &stubUseCaseHandler{}
+	adapter := AdaptUseCaseHandlerWithOptions(stub, UseCaseAdapterOptions{EnsureCorrelationID: true})
+
+	req := &Request{Path: "/ids", Headers: map[string]string{}}
+	resp := adapter(req)
+	generated := usecase.CorrelationIDFromContext(stub.gotCtx)
+	if len(generated) != 32 {
+		t.Fatalf("expected a generated correlation ID in the use case context, got %q", generated)
+	}
+	if stub.got.Headers["x-correlation-id"] != generated {
+		t.Fatalf("expected the generated ID in the use case input, got %q", stub.got.Headers["x-correlation-id"])
+	}
+	if resp.Headers["X-Correlation-Id"] != generated {
+		t.Fatalf("expected the generated ID echoed in the response, got %q", resp.Headers["X-Correlation-Id"])
+	}
+	if _, ok := req.Headers["x-correlation-id"]; ok {
+		t.Fatalf("expected the caller's request to be left unchanged")
+	}
+
+	stub.err = domain.ErrNotFound
+	resp = adapter(&Request{Path: "/ids", Headers: map[string]string{"x-correlation-id": "corr-456"}})
+	if got := usecase.CorrelationIDFromContext(stub.gotCtx); got != "corr-456" {
+		t.Fatalf("expected the supplied correlation ID to be kept, got %q", got)
+	}
+	if resp.StatusCode != 404 || resp.Headers["X-Correlation-Id"] != "corr-456" {
+		t.Fatalf("expected the supplied ID echoed on the error response, got %d %v", resp.StatusCode, resp.Headers)
 	}
 }
 
@@ -90,7 +205,13 @@ func TestAdaptUseCaseHandler_ErrorMapping(t *testing.T) {
 	}{
 		{name: "bad request", err: domain.ErrBadRequest, status: 400, body: "Bad Request"},
 		{name: "unauthorized", err: domain.ErrUnauthorized, status: 401, body: "Unauthorized"},
+		{name: "forbidden", err: domain.ErrForbidden, status: 403, body: "Forbidden"},
 		{name: "not found", err: domain.ErrNotFound, status: 404, body: "Not Found"},
+		{name: "conflict", err: fmt.Errorf("create user: %w", domain.ErrConflict), status: 409, body: "Conflict"},
+		{name: "validation", err: domain.ErrValidation, status: 422, body: "Unprocessable Entity"},
+		{name: "rate limited", err: domain.ErrRateLimited, status: 429, body: "Too Many Requests"},
+		{name: "client canceled", err: context.Canceled, status: 499, body: "Client Closed Request"},
+		{name: "deadline exceeded", err: fmt.Errorf("load: %w", context.DeadlineExceeded), status: 504, body: "Gateway Timeout"},
 		{name: "unknown", err: errors.New("boom"), status: 500, body: "Internal Server Error"},
 	}
 
@@ -119,3 +240,16 @@ func TestAdaptUseCaseHandler_NilHandler(t *testing.T) {
 	}
 }
 
+// TestAdaptUseCaseHandlerWithOptions_CanceledStatus verifies the canceled status is configurable.
+func TestAdaptUseCaseHandlerWithOptions_CanceledStatus(t *testing.T) {
+	stub := &stubUseCaseHandler{err: context.Canceled}
+	adapter := AdaptUseCaseHandlerWithOptions(stub, UseCaseAdapterOptions{CanceledStatus: 408})
+
+	resp := adapter(&Request{Path: "/x"})
+	if resp.StatusCode != 408 {
+		t.Fatalf("expected configured status 408, got %d", resp.StatusCode)
+	}
+	if string(resp.Body) != "Request Timeout" {
+		t.Fatalf("expected reason phrase body, got %q", string(resp.Body))
+	}
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// AllowedHostsOptions configures AllowedHostsMiddleware.
+type AllowedHostsOptions struct {
+	// Hosts lists the served host names, matched case-insensitively and
+	// ignoring any port in the Host header.
+	Hosts []string
+	// MisdirectedOnUnknownHost answers unknown hosts with 421 Misdirected
+	// Request, inviting clients to retry on a fresh connection, instead of
+	// 400 Bad Request. Useful behind TLS where SNI picked the connection.
+	MisdirectedOnUnknownHost bool
+}
+
+// AllowedHostsMiddleware rejects requests whose Host header is not one of
+// the configured hosts. Register it with Router.UsePreRouting so unknown
+// hosts are answered before route resolution.
+func AllowedHostsMiddleware(opts AllowedHostsOptions) Middleware {
+	allowed := make(map[string]struct{}, len(opts.Hosts))
+	for _, host := range opts.Hosts {
+		allowed[normalizeHost(host)] = struct{}{}
+	}
+	status := 400
+	if opts.MisdirectedOnUnknownHost {
+		status = 421
+	}
+
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			var host string
+			if req != nil {
+				host = req.Headers["host"]
+			}
+			if _, ok := allowed[normalizeHost(host)]; !ok {
+				return withoutBodyForHead(req, statusResponse(status))
+			}
+			return safeInvoke(next, req)
+		}
+	}
+}
+
+// normalizeHost lowercases a host and strips any port and trailing dot.
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.Trim(host, "[]")
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// HTTPSRedirectHandler answers every request with a 308 redirect to the same
+// host, path, and query over HTTPS on httpsPort, leaving the default port 443
+// out of the Location. A missing or malformed Host header is answered with
+// 400 so the redirect never points at an attacker-shaped URL.
+func HTTPSRedirectHandler(httpsPort int) HandlerAdapter {
+	return func(req *Request) *Response {
+		host := strings.TrimSpace(req.Headers["host"])
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+		host = strings.Trim(host, "[]")
+		if host == "" || strings.ContainsAny(host, "/\\@?# \t") {
+			return withoutBodyForHead(req, statusResponse(400))
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		target := req.RawPath
+		if !strings.HasPrefix(target, "/") {
+			target = "/"
+		}
+		if req.RawQuery != "" {
+			target += "?" + req.RawQuery
+		}
+		return withoutBodyForHead(req, redirectResponse(308, "https://"+host+target))
+	}
+}
@@ -0,0 +1,76 @@
+package http
+
+import "testing"
+
+// TestAllowedHostsMiddleware_UnknownHost verifies unknown hosts get 400, or 421 when configured.
+func TestAllowedHostsMiddleware_UnknownHost(t *testing.T) {
+	tests := []struct {
+		name        string
+		misdirected bool
+		host        string
+		want        int
+	}{
+		{name: "configured host with port", host: "API.example.com:8443", want: 200},
+		{name: "unknown host", host: "other.example.com", want: 400},
+		{name: "unknown host misdirected", misdirected: true, host: "other.example.com", want: 421},
+		{name: "missing host misdirected", misdirected: true, want: 421},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter()
+			router.Register("GET", "/", func(req *Request) *Response { return NewResponse() })
+			router.UsePreRouting(AllowedHostsMiddleware(AllowedHostsOptions{
+				Hosts:                    []string{"api.example.com"},
+				MisdirectedOnUnknownHost: tt.misdirected,
+			}))
+
+			req := &Request{Method: "GET", Path: "/", Headers: map[string]string{}}
+			if tt.host != "" {
+				req.Headers["host"] = tt.host
+			}
+			resp := router.ServeRequest(req)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+			if tt.want == 421 && string(resp.Body) != "Misdirected Request" {
+				t.Fatalf("expected reason phrase body, got %q", string(resp.Body))
+			}
+		})
+	}
+}
+
+// TestHTTPSRedirectHandler verifies the Location keeps host, path, and query and swaps in the HTTPS port.
+func TestHTTPSRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     int
+		host     string
+		rawPath  string
+		rawQuery string
+		want     int
+		location string
+	}{
+		{name: "default port", port: 443, host: "example.com:80", rawPath: "/a%20b", rawQuery: "x=1", want: 308, location: "https://example.com/a%20b?x=1"},
+		{name: "custom port", port: 8443, host: "example.com:8080", rawPath: "/login", want: 308, location: "https://example.com:8443/login"},
+		{name: "ipv6 host", port: 443, host: "[::1]:80", rawPath: "/", want: 308, location: "https://[::1]/"},
+		{name: "missing host", port: 443, rawPath: "/", want: 400},
+		{name: "malformed host", port: 443, host: "evil.com/x", rawPath: "/", want: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &Request{Method: "GET", RawPath: tt.rawPath, RawQuery: tt.rawQuery, Headers: map[string]string{}}
+			if tt.host != "" {
+				req.Headers["host"] = tt.host
+			}
+			resp := HTTPSRedirectHandler(tt.port)(req)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+			if got := resp.Headers["Location"]; got != tt.location {
+				t.Fatalf("expected Location %q, got %q", tt.location, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ListenAndServe listens on addr and serves every request with handler,
+// wrapped in logging and panic recovery. It blocks until SIGINT or SIGTERM,
+// then stops accepting and waits for open connections to finish.
+func ListenAndServe(addr string, handler HandlerAdapter) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return serveUntilSignal(listener, handler)
+}
+
+// ListenAndServeTLS is ListenAndServe over TLS using the given certificate
+// and key files.
+func ListenAndServeTLS(addr, certFile, keyFile string, handler HandlerAdapter) error {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	listener, err := tls.Listen("tcp", addr, &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+	})
+	if err != nil {
+		return err
+	}
+	return serveUntilSignal(listener, handler)
+}
+
+// Serve accepts connections on listener and answers every request with
+// handler until ctx is canceled or accepting fails permanently; temporary
+// accept errors are retried with backoff. Connections are closed on
+// cancellation and Serve returns once they have all finished.
+func Serve(ctx context.Context, listener net.Listener, handler HandlerAdapter) error {
+	opts := currentServerOptions()
+	router := newSingleHandlerRouter(handler, opts)
+	clock := clockOrDefault(opts.Clock)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = listener.Close()
+		case <-stop:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	backoff := time.Duration(0)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) && isTransientAcceptErr(err) {
+				backoff = nextAcceptBackoff(backoff)
+				logError(opts.Logger, "accept failed", "error", err, "retry_in", backoff.String())
+				select {
+				case <-ctx.Done():
+				case <-clock.After(backoff):
+				}
+				continue
+			}
+			_ = listener.Close()
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		backoff = 0
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				select {
+				case <-ctx.Done():
+					_ = conn.Close()
+				case <-done:
+				}
+			}()
+			HandleConnWithOptions(conn, router, ctx, currentServerOptions())
+		}()
+	}
+}
+
+// serveUntilSignal runs Serve until the process receives SIGINT or SIGTERM.
+func serveUntilSignal(listener net.Listener, handler HandlerAdapter) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return Serve(ctx, listener, handler)
+}
+
+// newSingleHandlerRouter builds a router with no routes whose not-found
+// handler is handler, so every request reaches it behind the default logging
+// and recovery middleware.
+func newSingleHandlerRouter(handler HandlerAdapter, opts ServerOptions) *Router {
+	router := NewRouter()
+	router.Use(LoggingMiddleware(opts.Logger), RecoveryMiddleware(opts.Logger))
+	router.SetNotFoundHandler(handler)
+	return router
+}
+
+const (
+	// minAcceptBackoff and maxAcceptBackoff bound the delay before Serve
+	// retries a temporary accept error.
+	minAcceptBackoff = 5 * time.Millisecond
+	maxAcceptBackoff = time.Second
+)
+
+// isTransientAcceptErr reports whether an accept error is worth retrying.
+func isTransientAcceptErr(err error) bool {
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// nextAcceptBackoff doubles the accept retry delay within its bounds.
+func nextAcceptBackoff(current time.Duration) time.Duration {
+	if current <= 0 {
+		return minAcceptBackoff
+	}
+	if next := current * 2; next < maxAcceptBackoff {
+		return next
+	}
+	return maxAcceptBackoff
+}
+
+// TLSInfo describes the TLS session a request arrived on.
+type TLSInfo struct {
+	// Version is the negotiated protocol version, e.g. "TLS 1.3".
+	Version string
+	// CipherSuite is the negotiated cipher suite name.
+	CipherSuite string
+	// ServerName is the SNI host name the client asked for, if any.
+	ServerName string
+	// NegotiatedProtocol is the ALPN protocol agreed on, if any.
+	NegotiatedProtocol string
+}
+
+// newTLSInfo summarizes a completed handshake, or returns nil before the
+// handshake is complete.
+func newTLSInfo(state tls.ConnectionState) *TLSInfo {
+	if !state.HandshakeComplete {
+		return nil
+	}
+	return &TLSInfo{
+		Version:            tls.VersionName(state.Version),
+		CipherSuite:        tls.CipherSuiteName(state.CipherSuite),
+		ServerName:         state.ServerName,
+		NegotiatedProtocol: state.NegotiatedProtocol,
+	}
+}
+
+// verifiedClientCertCN returns the subject common name of the verified
+// client certificate in state. A certificate the client presented without
+// it being verified, as with tls.RequestClientCert, yields "".
+func verifiedClientCertCN(state tls.ConnectionState) string {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return state.VerifiedChains[0][0].Subject.CommonName
+}
@@ -0,0 +1,216 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServe_SingleHandlerAnswersEveryPath verifies the convenience server routes all requests to one handler.
+func TestServe_SingleHandlerAnswersEveryPath(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, listener, func(req *Request) *Response {
+			resp := NewResponse()
+			resp.WriteString(req.Method + " " + req.Path)
+			return resp
+		})
+	}()
+
+	for _, target := range []string{"POST /anything", "GET /deep/nested/path"} {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		if _, err := conn.Write([]byte(target + " HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")); err != nil {
+			t.Fatalf("write request failed: %v", err)
+		}
+
+		reader := bufio.NewReader(conn)
+		statusLine, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read status failed: %v", err)
+		}
+		if statusLine != "HTTP/1.1 200 OK\r\n" {
+			t.Fatalf("expected 200 status line, got %q", statusLine)
+		}
+		var rest strings.Builder
+		_, _ = reader.WriteTo(&rest)
+		if !strings.HasSuffix(rest.String(), target) {
+			t.Fatalf("expected handler echo %q, got %q", target, rest.String())
+		}
+		_ = conn.Close()
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+}
+
+// TestServe_RetriesTemporaryAcceptErrors verifies temporary accept errors are retried instead of stopping Serve.
+func TestServe_RetriesTemporaryAcceptErrors(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	listener := &flakyListener{Listener: inner, failures: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, listener, func(req *Request) *Response {
+			return NewResponse().WriteString("ok")
+		})
+	}()
+
+	conn, err := net.Dial("tcp", inner.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	resp, err := io.ReadAll(conn)
+	if err != nil || !strings.HasPrefix(string(resp), "HTTP/1.1 200 OK\r\n") {
+		t.Fatalf("expected the request to be served after retries, got %q (%v)", resp, err)
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatalf("expected nil after cancellation, got %v", err)
+	}
+}
+
+// flakyListener fails its first Accept calls with a temporary error.
+type flakyListener struct {
+	net.Listener
+	failures int
+}
+
+// Accept returns a temporary error until failures are used up.
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.failures > 0 {
+		l.failures--
+		return nil, temporaryAcceptError{}
+	}
+	return l.Listener.Accept()
+}
+
+// temporaryAcceptError is a net.Error reporting itself as temporary.
+type temporaryAcceptError struct{}
+
+func (temporaryAcceptError) Error() string   { return "temporary accept failure" }
+func (temporaryAcceptError) Timeout() bool   { return false }
+func (temporaryAcceptError) Temporary() bool { return true }
+
+// TestVerifiedClientCertCN verifies only a verified client certificate is exposed.
+func TestVerifiedClientCertCN(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing-service"}}
+
+	if got := verifiedClientCertCN(tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}); got != "" {
+		t.Fatalf("expected unverified certificate to be rejected, got %q", got)
+	}
+	state := tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert}},
+	}
+	if got := verifiedClientCertCN(state); got != "billing-service" {
+		t.Fatalf("expected verified common name, got %q", got)
+	}
+}
+
+// TestHandleConnWithOptions_PopulatesTLSInfo verifies handlers see the negotiated TLS session, and nil over plain connections.
+func TestHandleConnWithOptions_PopulatesTLSInfo(t *testing.T) {
+	seen := make(chan *TLSInfo, 1)
+	router := NewRouter()
+	router.Register("GET", "/", func(req *Request) *Response {
+		seen <- req.TLS
+		return NewResponse()
+	})
+	request := []byte("GET / HTTP/1.1\r\nHost: api.example.com\r\nConnection: close\r\n\r\n")
+
+	serverConn, clientConn := net.Pipe()
+	tlsServer := tls.Server(serverConn, &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		Certificates: []tls.Certificate{newTestCertificate(t)},
+		NextProtos:   []string{"http/1.1"},
+	})
+	go HandleConnWithOptions(tlsServer, router, context.Background(), ServerOptions{})
+
+	tlsClient := tls.Client(clientConn, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         "api.example.com",
+		NextProtos:         []string{"http/1.1"},
+	})
+	defer tlsClient.Close()
+	if _, err := tlsClient.Write(request); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	_, _ = io.ReadAll(tlsClient)
+
+	info := <-seen
+	if info == nil {
+		t.Fatalf("expected TLS info on a TLS connection")
+	}
+	want := TLSInfo{
+		Version:            "TLS 1.3",
+		CipherSuite:        tls.CipherSuiteName(tlsClient.ConnectionState().CipherSuite),
+		ServerName:         "api.example.com",
+		NegotiatedProtocol: "http/1.1",
+	}
+	if *info != want {
+		t.Fatalf("expected %+v, got %+v", want, *info)
+	}
+
+	serverConn, clientConn = net.Pipe()
+	go HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{})
+	defer clientConn.Close()
+	if _, err := clientConn.Write(request); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	_, _ = io.ReadAll(clientConn)
+	if info := <-seen; info != nil {
+		t.Fatalf("expected nil TLS info on a plain connection, got %+v", info)
+	}
+}
+
+// newTestCertificate creates a self-signed certificate for in-memory TLS tests.
+func newTestCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"api.example.com"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the request
+// latency histogram buckets.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// MetricsRegistry collects request counts and latency histograms in memory
+// and renders them in the Prometheus text exposition format. It is safe for
+// concurrent use.
+type MetricsRegistry struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[metricLabels]*requestSeries
+}
+
+// metricLabels identifies one series.
+type metricLabels struct {
+	method string
+	path   string
+	status string
+}
+
+// requestSeries holds the counter and histogram of one series; buckets
+// counts observations per bucket, not cumulatively.
+type requestSeries struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+// NewMetricsRegistry creates an empty registry using DefaultLatencyBuckets.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		buckets: DefaultLatencyBuckets,
+		series:  make(map[metricLabels]*requestSeries),
+	}
+}
+
+// Observe records one request with its labels and duration.
+func (m *MetricsRegistry) Observe(method, path string, status int, duration time.Duration) {
+	labels := metricLabels{method: method, path: path, status: strconv.Itoa(status)}
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	series, ok := m.series[labels]
+	if !ok {
+		series = &requestSeries{buckets: make([]uint64, len(m.buckets))}
+		m.series[labels] = series
+	}
+	series.count++
+	series.sum += seconds
+	if i := sort.SearchFloat64s(m.buckets, seconds); i < len(m.buckets) {
+		series.buckets[i]++
+	}
+}
+
+// Handler serves the collected metrics in the Prometheus text format,
+// typically registered at GET /metrics.
+func (m *MetricsRegistry) Handler() HandlerAdapter {
+	return func(req *Request) *Response {
+		resp := NewResponse()
+		resp.SetHeader("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		resp.WriteString(m.render())
+		return withoutBodyForHead(req, resp)
+	}
+}
+
+// render formats every series, sorted by labels for stable output.
+func (m *MetricsRegistry) render() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]metricLabels, 0, len(m.series))
+	for labels := range m.series {
+		keys = append(keys, labels)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP http_requests_total Total HTTP requests handled.\n")
+	b.WriteString("# TYPE http_requests_total counter\n")
+	for _, labels := range keys {
+		b.WriteString("http_requests_total")
+		writeMetricLabels(&b, labels, "")
+		b.WriteString(" " + strconv.FormatUint(m.series[labels].count, 10) + "\n")
+	}
+
+	b.WriteString("# HELP http_request_duration_seconds HTTP request latency in seconds.\n")
+	b.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, labels := range keys {
+		series := m.series[labels]
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += series.buckets[i]
+			b.WriteString("http_request_duration_seconds_bucket")
+			writeMetricLabels(&b, labels, strconv.FormatFloat(bound, 'g', -1, 64))
+			b.WriteString(" " + strconv.FormatUint(cumulative, 10) + "\n")
+		}
+		b.WriteString("http_request_duration_seconds_bucket")
+		writeMetricLabels(&b, labels, "+Inf")
+		b.WriteString(" " + strconv.FormatUint(series.count, 10) + "\n")
+		b.WriteString("http_request_duration_seconds_sum")
+		writeMetricLabels(&b, labels, "")
+		b.WriteString(" " + strconv.FormatFloat(series.sum, 'g', -1, 64) + "\n")
+		b.WriteString("http_request_duration_seconds_count")
+		writeMetricLabels(&b, labels, "")
+		b.WriteString(" " + strconv.FormatUint(series.count, 10) + "\n")
+	}
+	return b.String()
+}
+
+// writeMetricLabels writes the label set, adding le when non-empty.
+func writeMetricLabels(b *strings.Builder, labels metricLabels, le string) {
+	b.WriteString(`{method="` + escapeLabelValue(labels.method))
+	b.WriteString(`",path="` + escapeLabelValue(labels.path))
+	b.WriteString(`",status="` + labels.status + `"`)
+	if le != "" {
+		b.WriteString(`,le="` + le + `"`)
+	}
+	b.WriteString("}")
+}
+
+// labelValueEscaper escapes label values per the text exposition format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes a label value for the text exposition format.
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// unmatchedMetricsPath labels requests that matched no route.
+const unmatchedMetricsPath = "unmatched"
+
+// MetricsMiddleware records every request in registry, labeled by method,
+// status, and the matched route pattern rather than the raw path, so
+// /users/1 and /users/2 share the "/users/:id" series. Register it with
+// Router.Use, since the pattern is only known once the route is resolved;
+// unmatched requests reach it only through a custom not-found or
+// method-not-allowed handler, and are labeled "unmatched". Responses the
+// router answers without its middleware chain, such as the default 404, are
+// recorded by Router.SetMetricsRegistry instead.
+func MetricsMiddleware(registry *MetricsRegistry) Middleware {
+	return MetricsMiddlewareWithClock(registry, SystemClock)
+}
+
+// MetricsMiddlewareWithClock is MetricsMiddleware measuring latency on clock.
+func MetricsMiddlewareWithClock(registry *MetricsRegistry, clock Clock) Middleware {
+	clock = clockOrDefault(clock)
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			startedAt := clock.Now()
+			resp := safeInvoke(next, req)
+			path := unmatchedMetricsPath
+			if req != nil && req.Route != "" {
+				path = req.Route
+			}
+			registry.Observe(requestMethod(req), path, metricsStatus(resp), clock.Now().Sub(startedAt))
+			return resp
+		}
+	}
+}
+
+// metricsStatus returns the status recorded for resp, treating an unset
+// status as 200.
+func metricsStatus(resp *Response) int {
+	if resp.StatusCode == 0 {
+		return 200
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// metricSampleLine matches one sample line of the Prometheus text format.
+var metricSampleLine = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*\{([a-z_]+="(\\.|[^"\\])*",?)*\} [0-9.e+-]+$`)
+
+// TestMetricsMiddleware_CountsByRoutePattern verifies requests are counted per route pattern and the output parses.
+func TestMetricsMiddleware_CountsByRoutePattern(t *testing.T) {
+	clock := newFakeClock()
+	registry := NewMetricsRegistry()
+	router := NewRouter()
+	router.Use(MetricsMiddlewareWithClock(registry, clock))
+	router.Register("GET", "/users/:id", func(req *Request) *Response {
+		clock.Advance(30 * time.Millisecond)
+		return NewResponse()
+	})
+	router.Register("GET", "/metrics", registry.Handler())
+	router.SetNotFoundHandler(func(req *Request) *Response { return statusResponse(404) })
+
+	router.ServeRequest(&Request{Method: "GET", Path: "/users/1"})
+	router.ServeRequest(&Request{Method: "GET", Path: "/users/2"})
+	router.ServeRequest(&Request{Method: "GET", Path: "/missing"})
+
+	resp := router.ServeRequest(&Request{Method: "GET", Path: "/metrics"})
+	if !strings.HasPrefix(resp.Headers["Content-Type"], "text/plain; version=0.0.4") {
+		t.Fatalf("expected the Prometheus text content type, got %q", resp.Headers["Content-Type"])
+	}
+	output := string(resp.Body)
+	for _, want := range []string{
+		`http_requests_total{method="GET",path="/users/:id",status="200"} 2`,
+		`http_requests_total{method="GET",path="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",path="/users/:id",status="200",le="0.025"} 0`,
+		`http_request_duration_seconds_bucket{method="GET",path="/users/:id",status="200",le="0.05"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",path="/users/:id",status="200",le="+Inf"} 2`,
+		`http_request_duration_seconds_sum{method="GET",path="/users/:id",status="200"} 0.06`,
+		`http_request_duration_seconds_count{method="GET",path="/users/:id",status="200"} 2`,
+	} {
+		if !strings.Contains(output, want+"\n") {
+			t.Fatalf("expected %q in metrics output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "/users/1") {
+		t.Fatalf("expected raw paths to be absent from labels:\n%s", output)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		if strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE ") {
+			continue
+		}
+		if !metricSampleLine.MatchString(strings.Replace(line, `le="+Inf"`, `le="Inf"`, 1)) {
+			t.Fatalf("expected a valid sample line, got %q", line)
+		}
+	}
+}
+
+// TestRouter_SetMetricsRegistryCountsUnmatched verifies default 404 and 405 responses, which bypass the middleware chain, are counted as unmatched.
+func TestRouter_SetMetricsRegistryCountsUnmatched(t *testing.T) {
+	registry := NewMetricsRegistry()
+	router := NewRouter()
+	router.Use(MetricsMiddleware(registry))
+	router.SetMetricsRegistry(registry)
+	router.Register("GET", "/users/:id", func(req *Request) *Response { return NewResponse() })
+
+	router.ServeRequest(&Request{Method: "GET", Path: "/wp-login.php"})
+	router.ServeRequest(&Request{Method: "GET", Path: "/.env"})
+	router.ServeRequest(&Request{Method: "DELETE", Path: "/users/1"})
+	buildRoutedResponse(router, &Request{Method: "TRACE", Path: "/users/1", Version: "HTTP/1.1"}, ServerOptions{AllowedMethods: []string{"GET"}})
+	router.ServeRequest(&Request{Method: "GET", Path: "/users/1"})
+
+	output := registry.render()
+	for _, want := range []string{
+		`http_requests_total{method="GET",path="unmatched",status="404"} 2`,
+		`http_requests_total{method="DELETE",path="unmatched",status="405"} 1`,
+		`http_requests_total{method="TRACE",path="unmatched",status="405"} 1`,
+		`http_requests_total{method="GET",path="/users/:id",status="200"} 1`,
+	} {
+		if !strings.Contains(output, want+"\n") {
+			t.Fatalf("expected %q in metrics output:\n%s", want, output)
+		}
+	}
+}
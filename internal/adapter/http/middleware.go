@@ -3,52 +3,97 @@ package http
 import (
 	"context"
 	"errors"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/jamalishaq/light_serve/internal/usecase"
 )
 
-// LoggingMiddleware logs method, path, status code, and request duration.
+// LoggingMiddleware logs method, path, status code, request duration, client
+// address, response body size, user agent, and referer.
 func LoggingMiddleware(logger usecase.Logger) Middleware {
+	return LoggingMiddlewareWithOptions(logger, LoggingOptions{})
+}
+
+// LoggingMiddlewareWithOptions logs each request using the configured access-log format.
+func LoggingMiddlewareWithOptions(logger usecase.Logger, opts LoggingOptions) Middleware {
+	formatter := opts.formatter()
+	clock := clockOrDefault(opts.Clock)
 	return func(next HandlerAdapter) HandlerAdapter {
 		return func(req *Request) *Response {
-			startedAt := time.Now()
+			startedAt := clock.Now()
 			resp := safeInvoke(next, req)
-			duration := time.Since(startedAt)
+			duration := clock.Now().Sub(startedAt)
 
-			method := ""
-			path := ""
-			if req != nil {
-				method = req.Method
-				path = req.Path
+			fields := accessLogFields(req, resp, startedAt, duration)
+			if formatter != nil {
+				logInfo(logger, formatter(fields))
+				return resp
 			}
 
-			statusCode := resp.StatusCode
-			if statusCode == 0 {
-				statusCode = 200
+			durationKey, durationValue := "duration", any(fields.Duration.String())
+			if opts.DurationMillis {
+				durationKey, durationValue = "duration_ms", float64(fields.Duration)/float64(time.Millisecond)
 			}
-
-			requestID, correlationID := requestIdentifiers(req)
 			logInfo(logger, "http request",
-				"method", method,
-				"path", path,
-				"status", statusCode,
-				"duration", duration.String(),
-				"request_id", requestID,
-				"correlation_id", correlationID,
+				"method", fields.Method,
+				"path", fields.Path,
+				"status", fields.Status,
+				durationKey, durationValue,
+				"request_id", fields.RequestID,
+				"correlation_id", fields.CorrelationID,
+				"remote_addr", fields.RemoteAddr,
+				"bytes", fields.Bytes,
+				"user_agent", fields.UserAgent,
+				"referer", fields.Referer,
 			)
 			return resp
 		}
 	}
 }
 
+// accessLogFields collects the loggable request and response values.
+func accessLogFields(req *Request, resp *Response, startedAt time.Time, duration time.Duration) AccessLogFields {
+	fields := AccessLogFields{
+		Time:     startedAt,
+		Method:   requestMethod(req),
+		Path:     requestPath(req),
+		Status:   resp.StatusCode,
+		Bytes:    len(resp.Body),
+		Duration: duration,
+	}
+	if fields.Status == 0 {
+		fields.Status = 200
+	}
+	fields.RequestID, fields.CorrelationID = requestIdentifiers(req)
+	if req != nil {
+		fields.Version = req.Version
+		fields.UserAgent = req.Headers["user-agent"]
+		fields.Referer = req.Headers["referer"]
+		fields.RemoteAddr = req.RemoteAddr
+	}
+	return fields
+}
+
 // RecoveryMiddleware recovers panics from downstream handlers and returns 500.
+// The panic is logged with the goroutine stack captured at recovery.
 func RecoveryMiddleware(logger usecase.Logger) Middleware {
+	return RecoveryMiddlewareWithHandler(logger, nil)
+}
+
+// RecoveryMiddlewareWithHandler is RecoveryMiddleware answering with the
+// response onPanic builds from the recovered value and stack, e.g. after
+// forwarding them to an error tracker. A nil onPanic, or a nil response
+// from it, yields the default 500.
+func RecoveryMiddlewareWithHandler(logger usecase.Logger, onPanic func(recovered any, stack []byte) *Response) Middleware {
 	return func(next HandlerAdapter) HandlerAdapter {
 		return func(req *Request) (resp *Response) {
 			defer func() {
 				if recovered := recover(); recovered != nil {
+					stack := debug.Stack()
 					requestID, correlationID := requestIdentifiers(req)
 					logError(logger, "panic recovered",
 						"method", requestMethod(req),
@@ -56,12 +101,15 @@ func RecoveryMiddleware(logger usecase.Logger) Middleware {
 						"panic", recovered,
 						"request_id", requestID,
 						"correlation_id", correlationID,
+						"stack", string(stack),
 					)
 
-					resp = NewResponse()
-					resp.StatusCode = 500
-					resp.SetHeader("Content-Type", "text/plain")
-					resp.WriteString("Internal Server Error")
+					if onPanic != nil {
+						resp = onPanic(recovered, stack)
+					}
+					if resp == nil {
+						resp = internalServerErrorResponse()
+					}
 				}
 			}()
 
@@ -70,22 +118,46 @@ func RecoveryMiddleware(logger usecase.Logger) Middleware {
 	}
 }
 
+// timeoutHandlersRunning counts handler goroutines started by
+// TimeoutMiddleware that have not yet returned.
+var timeoutHandlersRunning atomic.Int64
+
+// TimeoutHandlersRunning reports how many handlers started by
+// TimeoutMiddleware are still running, including those whose request has
+// already been answered with 408. A count that keeps growing points at
+// handlers that ignore req.Context().
+func TimeoutHandlersRunning() int64 {
+	return timeoutHandlersRunning.Load()
+}
+
 // TimeoutMiddleware returns 408 when downstream handling exceeds the timeout.
+// The handler runs on its own goroutine, and the only signal it gets is the
+// cancellation of req.Context() at the deadline: Go cannot stop a goroutine,
+// so a handler that ignores the context keeps running, and holding whatever
+// it holds, after the 408 is sent. See TimeoutHandlersRunning.
 func TimeoutMiddleware(timeout time.Duration) Middleware {
+	return TimeoutMiddlewareWithClock(timeout, SystemClock)
+}
+
+// TimeoutMiddlewareWithClock is TimeoutMiddleware measuring the timeout on clock.
+func TimeoutMiddlewareWithClock(timeout time.Duration, clock Clock) Middleware {
+	clock = clockOrDefault(clock)
 	return func(next HandlerAdapter) HandlerAdapter {
 		return func(req *Request) *Response {
 			if timeout <= 0 {
 				return safeInvoke(next, req)
 			}
 
-			timeoutCtx, cancel := context.WithTimeout(requestContext(req), timeout)
+			timeoutCtx, cancel := withClockTimeout(requestContext(req), clock, timeout)
 			defer cancel()
 
 			reqWithTimeout := withRequestContext(req, timeoutCtx)
 			responseCh := make(chan *Response, 1)
 			panicCh := make(chan any, 1)
 
+			timeoutHandlersRunning.Add(1)
 			go func() {
+				defer timeoutHandlersRunning.Add(-1)
 				defer func() {
 					if recovered := recover(); recovered != nil {
 						panicCh <- recovered
@@ -118,6 +190,199 @@ func TimeoutMiddleware(timeout time.Duration) Middleware {
 	}
 }
 
+// TimeoutBudgetHeader carries the server's request deadline budget in
+// whole milliseconds so hedging clients know when to give up.
+const TimeoutBudgetHeader = "X-Timeout-Budget"
+
+// TimeoutBudgetMiddleware advertises timeout through TimeoutBudgetHeader. A
+// non-positive timeout falls back to the time left on the request context
+// deadline, and no header is set when there is none.
+func TimeoutBudgetMiddleware(timeout time.Duration) Middleware {
+	return TimeoutBudgetMiddlewareWithClock(timeout, SystemClock)
+}
+
+// TimeoutBudgetMiddlewareWithClock is TimeoutBudgetMiddleware measuring the
+// remaining context deadline on clock.
+func TimeoutBudgetMiddlewareWithClock(timeout time.Duration, clock Clock) Middleware {
+	clock = clockOrDefault(clock)
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			budget := timeout
+			if budget <= 0 {
+				deadline, ok := requestContext(req).Deadline()
+				if !ok {
+					return safeInvoke(next, req)
+				}
+				budget = deadline.Sub(clock.Now())
+				if budget < 0 {
+					budget = 0
+				}
+			}
+
+			resp := safeInvoke(next, req)
+			resp.SetHeader(TimeoutBudgetHeader, strconv.FormatInt(budget.Milliseconds(), 10))
+			return resp
+		}
+	}
+}
+
+// SlowRequestMiddleware logs a warn-level "slow request" entry, with the
+// route and duration, for requests whose handling takes longer than
+// threshold. Faster requests are not logged.
+func SlowRequestMiddleware(threshold time.Duration, logger usecase.Logger) Middleware {
+	return SlowRequestMiddlewareWithClock(threshold, logger, SystemClock)
+}
+
+// SlowRequestMiddlewareWithClock is SlowRequestMiddleware measuring on clock.
+func SlowRequestMiddlewareWithClock(threshold time.Duration, logger usecase.Logger, clock Clock) Middleware {
+	clock = clockOrDefault(clock)
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			startedAt := clock.Now()
+			resp := safeInvoke(next, req)
+			duration := clock.Now().Sub(startedAt)
+			if duration <= threshold {
+				return resp
+			}
+
+			status := resp.StatusCode
+			if status == 0 {
+				status = 200
+			}
+			requestID, correlationID := requestIdentifiers(req)
+			logWarn(logger, "slow request",
+				"method", requestMethod(req),
+				"path", requestPath(req),
+				"status", status,
+				"duration", duration.String(),
+				"threshold", threshold.String(),
+				"request_id", requestID,
+				"correlation_id", correlationID,
+			)
+			return resp
+		}
+	}
+}
+
+// ServerTimingMiddleware reports handler duration in a Server-Timing header
+// as "app;dur=<milliseconds>". A Server-Timing value set by the handler is
+// kept and the app metric appended to it.
+func ServerTimingMiddleware() Middleware {
+	return ServerTimingMiddlewareWithClock(SystemClock)
+}
+
+// ServerTimingMiddlewareWithClock is ServerTimingMiddleware measuring on clock.
+func ServerTimingMiddlewareWithClock(clock Clock) Middleware {
+	clock = clockOrDefault(clock)
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			startedAt := clock.Now()
+			resp := safeInvoke(next, req)
+			elapsed := clock.Now().Sub(startedAt)
+
+			metric := "app;dur=" + strconv.FormatFloat(float64(elapsed)/float64(time.Millisecond), 'f', 1, 64)
+			for key, value := range resp.Headers {
+				if strings.EqualFold(key, "Server-Timing") {
+					resp.Headers[key] = value + ", " + metric
+					return resp
+				}
+			}
+			resp.SetHeader("Server-Timing", metric)
+			return resp
+		}
+	}
+}
+
+type strippedPrefixKey struct{}
+
+// StripPrefixMiddleware removes a leading path prefix before the request reaches
+// next, answering 404 when the path does not start with it. Register it with
+// Router.UsePreRouting so routes match the stripped path.
+func StripPrefixMiddleware(prefix string) Middleware {
+	prefix = "/" + strings.Trim(prefix, "/")
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			path, rawQuery := splitRequestTarget(requestPath(req))
+			var stripped string
+			switch {
+			case prefix == "/":
+				stripped = path
+			case path == prefix:
+				stripped = "/"
+			case strings.HasPrefix(path, prefix+"/"):
+				stripped = strings.TrimPrefix(path, prefix)
+			default:
+				return withoutBodyForHead(req, notFoundResponse())
+			}
+			if rawQuery != "" {
+				stripped += "?" + rawQuery
+			}
+
+			ctx := context.WithValue(requestContext(req), strippedPrefixKey{}, StrippedPrefix(req)+strings.TrimSuffix(prefix, "/"))
+			rewritten := withRequestContext(req, ctx)
+			rewritten.Path = stripped
+			return safeInvoke(next, rewritten)
+		}
+	}
+}
+
+// StrippedPrefix returns the path prefix removed by StripPrefixMiddleware, for
+// building absolute URLs from handler-relative paths.
+func StrippedPrefix(req *Request) string {
+	prefix, _ := requestContext(req).Value(strippedPrefixKey{}).(string)
+	return prefix
+}
+
+// BodyTransformMiddleware rewrites the request body with decode before next
+// runs, answering 400 when decoding fails, and rewrites the response body with
+// encode afterwards, answering 500 when encoding fails. An explicit
+// Content-Length is updated to the encoded size. Nil hooks and raw responses
+// are passed through unchanged.
+func BodyTransformMiddleware(decode, encode func([]byte) ([]byte, error)) Middleware {
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			if decode != nil && req != nil {
+				decoded, err := decode(req.Body)
+				if err != nil {
+					return statusResponse(400)
+				}
+				req = withRequestContext(req, requestContext(req))
+				req.Body = decoded
+			}
+
+			resp := safeInvoke(next, req)
+			if encode == nil || resp.Raw != nil {
+				return resp
+			}
+			encoded, err := encode(resp.Body)
+			if err != nil {
+				return internalServerErrorResponse()
+			}
+			resp.Body = encoded
+			for key := range resp.Headers {
+				if strings.EqualFold(key, "Content-Length") {
+					resp.Headers[key] = strconv.Itoa(len(encoded))
+				}
+			}
+			return resp
+		}
+	}
+}
+
+// MaxHeadersMiddleware answers requests carrying more than n header fields
+// with 431, as a per-route policy stricter than the parser's header count
+// limit. Repeated header lines count once, since the parser keeps the last.
+func MaxHeadersMiddleware(n int) Middleware {
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			if req != nil && len(req.Headers) > n {
+				return withoutBodyForHead(req, statusResponse(431))
+			}
+			return safeInvoke(next, req)
+		}
+	}
+}
+
 // requestContext returns req.Context(), tolerating nil request values.
 func requestContext(req *Request) context.Context {
 	if req == nil {
@@ -177,6 +442,14 @@ func requestIdentifiers(req *Request) (string, string) {
 	return strings.TrimSpace(req.Headers["x-request-id"]), strings.TrimSpace(req.Headers["x-correlation-id"])
 }
 
+// logDebug logs a debug event when a logger is provided.
+func logDebug(logger usecase.Logger, msg string, keysAndValues ...any) {
+	if logger == nil {
+		return
+	}
+	logger.Debug(msg, keysAndValues...)
+}
+
 // logInfo logs an info event when a logger is provided.
 func logInfo(logger usecase.Logger, msg string, keysAndValues ...any) {
 	if logger == nil {
@@ -185,6 +458,14 @@ func logInfo(logger usecase.Logger, msg string, keysAndValues ...any) {
 	logger.Info(msg, keysAndValues...)
 }
 
+// logWarn logs a warning event when a logger is provided.
+func logWarn(logger usecase.Logger, msg string, keysAndValues ...any) {
+	if logger == nil {
+		return
+	}
+	logger.Warn(msg, keysAndValues...)
+}
+
 // logError logs an error event when a logger is provided.
 func logError(logger usecase.Logger, msg string, keysAndValues ...any) {
 	if logger == nil {
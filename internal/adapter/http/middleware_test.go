@@ -2,25 +2,95 @@ package http
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
-// stubLogger captures middleware log messages for assertions.
+// stubLogger captures middleware log messages for assertions; levels holds
+// the level of each entry.
 type stubLogger struct {
 	entries []string
+	levels  []string
+}
+
+// Debug stores debug-level log entries for test verification.
+func (l *stubLogger) Debug(msg string, keysAndValues ...any) {
+	l.record("DEBUG", msg, keysAndValues)
 }
 
 // Info stores info-level log entries for test verification.
 func (l *stubLogger) Info(msg string, keysAndValues ...any) {
-	l.entries = append(l.entries, fmt.Sprintf("%s %v", msg, keysAndValues))
+	l.record("INFO", msg, keysAndValues)
+}
+
+// Warn stores warn-level log entries for test verification.
+func (l *stubLogger) Warn(msg string, keysAndValues ...any) {
+	l.record("WARN", msg, keysAndValues)
 }
 
 // Error stores error-level log entries for test verification.
 func (l *stubLogger) Error(msg string, keysAndValues ...any) {
+	l.record("ERROR", msg, keysAndValues)
+}
+
+// record stores one entry with its level.
+func (l *stubLogger) record(level, msg string, keysAndValues []any) {
 	l.entries = append(l.entries, fmt.Sprintf("%s %v", msg, keysAndValues))
+	l.levels = append(l.levels, level)
+}
+
+// fakeClock is a manually advanced Clock for deterministic time-based tests.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeClockWaiter
+}
+
+type fakeClockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// newFakeClock returns a fake clock starting at a fixed instant.
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// Now returns the fake current time.
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that fires once the clock is advanced past d.
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeClockWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires any timers that became due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
 }
 
 // TestRecoveryMiddleware_RecoversPanic verifies panic recovery to 500 responses.
@@ -59,6 +129,29 @@ func TestRecoveryMiddleware_RecoversPanic(t *testing.T) {
 	if !strings.Contains(entry, "correlation_id corr-789") {
 		t.Fatalf("expected correlation_id in panic log entry, got %q", entry)
 	}
+	if !strings.Contains(entry, "stack goroutine ") || !strings.Contains(entry, "TestRecoveryMiddleware_RecoversPanic") {
+		t.Fatalf("expected a stack field tracing the panicking handler, got %q", entry)
+	}
+}
+
+// TestRecoveryMiddlewareWithHandler_CustomResponse verifies the hook receives the panic and stack and builds the response.
+func TestRecoveryMiddlewareWithHandler_CustomResponse(t *testing.T) {
+	var gotRecovered any
+	var gotStack []byte
+	handler := RecoveryMiddlewareWithHandler(&stubLogger{}, func(recovered any, stack []byte) *Response {
+		gotRecovered, gotStack = recovered, stack
+		return NewResponse().Status(503).WriteString("try again")
+	})(func(req *Request) *Response {
+		panic("boom")
+	})
+
+	resp := handler(&Request{Method: "GET", Path: "/panic"})
+	if resp.StatusCode != 503 || string(resp.Body) != "try again" {
+		t.Fatalf("expected the hook response, got %d %q", resp.StatusCode, string(resp.Body))
+	}
+	if gotRecovered != "boom" || !strings.Contains(string(gotStack), "goroutine ") {
+		t.Fatalf("expected the recovered value and stack, got %v and %q", gotRecovered, gotStack)
+	}
 }
 
 // TestTimeoutMiddleware_ReturnsTimeout verifies timeout middleware returns 408.
@@ -133,6 +226,213 @@ func TestTimeoutMiddleware_InjectsTimeoutContext(t *testing.T) {
 	}
 }
 
+// TestTimeoutMiddleware_ContextAwareHandlerExits verifies a handler watching req.Context() is unblocked at the deadline and its goroutine exits.
+func TestTimeoutMiddleware_ContextAwareHandlerExits(t *testing.T) {
+	exited := make(chan struct{})
+	handler := TimeoutMiddleware(5 * time.Millisecond)(func(req *Request) *Response {
+		defer close(exited)
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+			t.Errorf("expected the handler context to be canceled at the deadline")
+		}
+		return NewResponse()
+	})
+
+	if resp := handler(&Request{Method: "GET", Path: "/slow"}); resp.StatusCode != 408 {
+		t.Fatalf("expected status 408, got %d", resp.StatusCode)
+	}
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the handler goroutine to exit after cancellation")
+	}
+	deadline := time.Now().Add(time.Second)
+	for TimeoutHandlersRunning() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected no running timeout handlers, got %d", TimeoutHandlersRunning())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestTimeoutMiddlewareWithClock_FakeClockTriggersTimeout verifies a fake clock drives the timeout without sleeping.
+func TestTimeoutMiddlewareWithClock_FakeClockTriggersTimeout(t *testing.T) {
+	clock := newFakeClock()
+	started := make(chan struct{})
+	ctxErr := make(chan error, 1)
+
+	handler := TimeoutMiddlewareWithClock(time.Hour, clock)(func(req *Request) *Response {
+		close(started)
+		<-req.Context().Done()
+		ctxErr <- req.Context().Err()
+		return NewResponse()
+	})
+
+	go func() {
+		<-started
+		clock.Advance(time.Hour)
+	}()
+
+	resp := handler(&Request{Method: "GET", Path: "/slow"})
+	if resp.StatusCode != 408 {
+		t.Fatalf("expected status 408, got %d", resp.StatusCode)
+	}
+	if err := <-ctxErr; err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded on handler context, got %v", err)
+	}
+}
+
+// TestTimeoutBudgetMiddleware_AdvertisesConfiguredTimeout verifies the header reflects the configured timeout.
+func TestTimeoutBudgetMiddleware_AdvertisesConfiguredTimeout(t *testing.T) {
+	handler := TimeoutBudgetMiddleware(2500 * time.Millisecond)(func(req *Request) *Response {
+		return NewResponse()
+	})
+
+	resp := handler(&Request{Method: "GET", Path: "/budget"})
+	if got := resp.Headers[TimeoutBudgetHeader]; got != "2500" {
+		t.Fatalf("expected %s 2500, got %q", TimeoutBudgetHeader, got)
+	}
+}
+
+// TestTimeoutBudgetMiddlewareWithClock_UsesContextDeadline verifies the remaining deadline is advertised without a configured timeout.
+func TestTimeoutBudgetMiddlewareWithClock_UsesContextDeadline(t *testing.T) {
+	clock := newFakeClock()
+	handler := TimeoutBudgetMiddlewareWithClock(0, clock)(func(req *Request) *Response {
+		return NewResponse()
+	})
+
+	ctx, cancel := context.WithDeadline(context.Background(), clock.Now().Add(750*time.Millisecond))
+	defer cancel()
+	resp := handler(&Request{Ctx: ctx, Method: "GET", Path: "/budget"})
+	if got := resp.Headers[TimeoutBudgetHeader]; got != "750" {
+		t.Fatalf("expected %s 750, got %q", TimeoutBudgetHeader, got)
+	}
+
+	resp = handler(&Request{Method: "GET", Path: "/budget"})
+	if _, ok := resp.Headers[TimeoutBudgetHeader]; ok {
+		t.Fatalf("expected no %s without a deadline, got %#v", TimeoutBudgetHeader, resp.Headers)
+	}
+}
+
+// TestSlowRequestMiddleware_LogsOnlyAboveThreshold verifies slow requests warn and fast ones stay silent.
+func TestSlowRequestMiddleware_LogsOnlyAboveThreshold(t *testing.T) {
+	clock := newFakeClock()
+	logger := &stubLogger{}
+	delays := map[string]time.Duration{"/slow": 250 * time.Millisecond, "/fast": 10 * time.Millisecond}
+	handler := SlowRequestMiddlewareWithClock(100*time.Millisecond, logger, clock)(func(req *Request) *Response {
+		clock.Advance(delays[req.Path])
+		return NewResponse()
+	})
+
+	handler(&Request{Method: "GET", Path: "/fast"})
+	if len(logger.entries) != 0 {
+		t.Fatalf("expected no log for fast request, got %v", logger.entries)
+	}
+
+	handler(&Request{Method: "GET", Path: "/slow"})
+	if len(logger.entries) != 1 {
+		t.Fatalf("expected one slow request log, got %v", logger.entries)
+	}
+	if logger.levels[0] != "WARN" {
+		t.Fatalf("expected a warn-level entry, got %s", logger.levels[0])
+	}
+	for _, want := range []string{"slow request", "path /slow", "duration 250ms", "threshold 100ms"} {
+		if !strings.Contains(logger.entries[0], want) {
+			t.Fatalf("expected %q in log entry, got %q", want, logger.entries[0])
+		}
+	}
+}
+
+// TestServerTimingMiddleware_AddsHandlerDuration verifies a plausible app duration is reported.
+func TestServerTimingMiddleware_AddsHandlerDuration(t *testing.T) {
+	handler := ServerTimingMiddleware()(func(req *Request) *Response {
+		time.Sleep(5 * time.Millisecond)
+		return NewResponse()
+	})
+
+	resp := handler(&Request{Method: "GET", Path: "/timed"})
+	value, ok := strings.CutPrefix(resp.Headers["Server-Timing"], "app;dur=")
+	if !ok {
+		t.Fatalf("expected app Server-Timing metric, got %q", resp.Headers["Server-Timing"])
+	}
+	dur, err := strconv.ParseFloat(value, 64)
+	if err != nil || dur < 5 || dur > 5000 {
+		t.Fatalf("expected a plausible duration in ms, got %q", value)
+	}
+}
+
+// TestServerTimingMiddlewareWithClock_AppendsToHandlerValue verifies a handler-set Server-Timing is kept.
+func TestServerTimingMiddlewareWithClock_AppendsToHandlerValue(t *testing.T) {
+	clock := newFakeClock()
+	handler := ServerTimingMiddlewareWithClock(clock)(func(req *Request) *Response {
+		clock.Advance(12300 * time.Microsecond)
+		return NewResponse().Header("Server-Timing", "db;dur=4.0")
+	})
+
+	resp := handler(&Request{Method: "GET", Path: "/timed"})
+	if got := resp.Headers["Server-Timing"]; got != "db;dur=4.0, app;dur=12.3" {
+		t.Fatalf("expected appended metric, got %q", got)
+	}
+}
+
+// TestLoggingMiddlewareWithOptions_ClockMeasuresDuration verifies the logged duration comes from the clock.
+func TestLoggingMiddlewareWithOptions_ClockMeasuresDuration(t *testing.T) {
+	logger := &stubLogger{}
+	clock := newFakeClock()
+	handler := LoggingMiddlewareWithOptions(logger, LoggingOptions{Clock: clock})(func(req *Request) *Response {
+		clock.Advance(1500 * time.Millisecond)
+		return NewResponse()
+	})
+
+	handler(&Request{Method: "GET", Path: "/timed"})
+	if len(logger.entries) != 1 || !strings.Contains(logger.entries[0], "duration 1.5s") {
+		t.Fatalf("expected duration 1.5s in log entry, got %v", logger.entries)
+	}
+}
+
+// TestLoggingMiddlewareWithOptions_DurationMillis verifies the duration can be logged as a numeric millisecond field.
+func TestLoggingMiddlewareWithOptions_DurationMillis(t *testing.T) {
+	logger := &stubLogger{}
+	clock := newFakeClock()
+	handler := LoggingMiddlewareWithOptions(logger, LoggingOptions{Clock: clock, DurationMillis: true})(func(req *Request) *Response {
+		clock.Advance(1250 * time.Microsecond)
+		return NewResponse()
+	})
+
+	handler(&Request{Method: "GET", Path: "/timed"})
+	if len(logger.entries) != 1 || !strings.Contains(logger.entries[0], "duration_ms 1.25 ") || strings.Contains(logger.entries[0], "duration 1.25ms") {
+		t.Fatalf("expected duration_ms 1.25 in place of the duration string, got %v", logger.entries)
+	}
+}
+
+// TestLoggingMiddleware_LogsClientFields verifies client address, size, user agent, and referer are logged, empty when absent.
+func TestLoggingMiddleware_LogsClientFields(t *testing.T) {
+	logger := &stubLogger{}
+	handler := LoggingMiddleware(logger)(func(req *Request) *Response {
+		return NewResponse().WriteString("hello")
+	})
+
+	handler(&Request{
+		Method:     "GET",
+		Path:       "/",
+		RemoteAddr: "203.0.113.7:52100",
+		Headers:    map[string]string{"user-agent": "curl/8.0", "referer": "https://example.com/"},
+	})
+	handler(&Request{Method: "GET", Path: "/", Headers: map[string]string{}})
+	if len(logger.entries) != 2 {
+		t.Fatalf("expected two log entries, got %v", logger.entries)
+	}
+	for _, want := range []string{"remote_addr 203.0.113.7:52100", "bytes 5", "user_agent curl/8.0", "referer https://example.com/", "status 200"} {
+		if !strings.Contains(logger.entries[0], want) {
+			t.Fatalf("expected %q in log entry, got %q", want, logger.entries[0])
+		}
+	}
+	if !strings.Contains(logger.entries[1], "user_agent  referer ") {
+		t.Fatalf("expected empty user_agent and referer values, got %q", logger.entries[1])
+	}
+}
+
 // TestLoggingMiddleware_LogsRequest verifies request metadata is logged.
 func TestLoggingMiddleware_LogsRequest(t *testing.T) {
 	logger := &stubLogger{}
@@ -176,3 +476,91 @@ func TestLoggingMiddleware_LogsRequest(t *testing.T) {
 		t.Fatalf("expected correlation_id in log entry, got %q", entry)
 	}
 }
+
+// TestStripPrefixMiddleware_RoutesStrippedPath verifies prefixed paths are stripped before routing.
+func TestStripPrefixMiddleware_RoutesStrippedPath(t *testing.T) {
+	router := NewRouter()
+	router.UsePreRouting(StripPrefixMiddleware("/api"))
+	router.Register("GET", "/users", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString(StrippedPrefix(req) + req.Path)
+		return resp
+	})
+
+	resp := router.ServeRequest(&Request{Method: "GET", Path: "/api/users"})
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if string(resp.Body) != "/api/users" {
+		t.Fatalf("expected stripped prefix to be recorded, got %q", string(resp.Body))
+	}
+}
+
+// TestStripPrefixMiddleware_NonMatchingPathReturns404 verifies paths outside the prefix are 404.
+func TestStripPrefixMiddleware_NonMatchingPathReturns404(t *testing.T) {
+	router := NewRouter()
+	router.UsePreRouting(StripPrefixMiddleware("/api/"))
+	router.Register("GET", "/users", func(req *Request) *Response {
+		return NewResponse()
+	})
+
+	for _, path := range []string{"/users", "/apiusers"} {
+		resp := router.ServeRequest(&Request{Method: "GET", Path: path})
+		if resp.StatusCode != 404 {
+			t.Fatalf("expected status 404 for %s, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
+// TestBodyTransformMiddleware_Base64RoundTrip verifies bodies are decoded for handlers and encoded for clients.
+func TestBodyTransformMiddleware_Base64RoundTrip(t *testing.T) {
+	decode := func(b []byte) ([]byte, error) { return base64.StdEncoding.DecodeString(string(b)) }
+	encode := func(b []byte) ([]byte, error) { return []byte(base64.StdEncoding.EncodeToString(b)), nil }
+
+	var seen string
+	handler := BodyTransformMiddleware(decode, encode)(func(req *Request) *Response {
+		seen = string(req.Body)
+		resp := NewResponse()
+		resp.SetHeader("Content-Length", "5")
+		resp.WriteString("reply")
+		return resp
+	})
+
+	resp := handler(&Request{Method: "POST", Path: "/secure", Body: []byte(base64.StdEncoding.EncodeToString([]byte("secret")))})
+	if seen != "secret" {
+		t.Fatalf("expected handler to see decoded body, got %q", seen)
+	}
+	if want := base64.StdEncoding.EncodeToString([]byte("reply")); string(resp.Body) != want {
+		t.Fatalf("expected encoded response %q, got %q", want, string(resp.Body))
+	}
+	if got := resp.Headers["Content-Length"]; got != "8" {
+		t.Fatalf("expected Content-Length to match encoded body, got %q", got)
+	}
+
+	resp = handler(&Request{Method: "POST", Path: "/secure", Body: []byte("not base64!")})
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected 400 for undecodable body, got %d", resp.StatusCode)
+	}
+}
+
+// TestMaxHeadersMiddleware_RejectsAboveCap verifies a request under the parser limit but over the cap gets 431.
+func TestMaxHeadersMiddleware_RejectsAboveCap(t *testing.T) {
+	router := NewRouter()
+	router.Use(MaxHeadersMiddleware(3))
+	router.Register("GET", "/public", func(req *Request) *Response { return NewResponse().WriteString("ok") })
+
+	raw := "GET /public HTTP/1.1\r\nHost: example.com\r\nAccept: */*\r\nX-A: 1\r\nX-B: 2\r\n\r\n"
+	req, _, err := ParseRequest([]byte(raw))
+	if err != nil {
+		t.Fatalf("expected parser to accept the request, got %v", err)
+	}
+	resp := router.ServeRequest(req)
+	if resp.StatusCode != 431 || string(resp.Body) != "Request Header Fields Too Large" {
+		t.Fatalf("expected 431, got %d %q", resp.StatusCode, string(resp.Body))
+	}
+
+	req, _, _ = ParseRequest([]byte("GET /public HTTP/1.1\r\nHost: example.com\r\nAccept: */*\r\n\r\n"))
+	if resp := router.ServeRequest(req); resp.StatusCode != 0 && resp.StatusCode != 200 {
+		t.Fatalf("expected request within the cap to pass, got %d", resp.StatusCode)
+	}
+}
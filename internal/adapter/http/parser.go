@@ -1,6 +1,7 @@
 package http
 
 import (
+	"bytes"
 	"errors"
 	"strconv"
 	"strings"
@@ -11,6 +12,7 @@ const (
 	maxHeadersBytes     = 16 * 1024
 	maxHeaderCount      = 50
 	maxBodyBytes        = 256 * 1024
+	maxChunkLineBytes   = 4096
 )
 
 var (
@@ -36,60 +38,139 @@ var (
 	ErrTooManyHeaders       = errors.New("too many headers")
 	// ErrBodyTooLarge indicates body size exceeds parser limits.
 	ErrBodyTooLarge         = errors.New("body too large")
+	// ErrMultipleHost indicates the request carries more than one Host header.
+	ErrMultipleHost         = errors.New("multiple Host headers")
+	// ErrInvalidChunk indicates a malformed chunk-size line or chunk terminator.
+	ErrInvalidChunk         = errors.New("invalid chunk")
+	// ErrConflictingLength indicates both Content-Length and Transfer-Encoding
+	// were sent, or Content-Length was repeated with differing values.
+	ErrConflictingLength    = errors.New("conflicting message length headers")
+	// ErrInvalidPathChar indicates a raw request path byte outside the allowed set.
+	ErrInvalidPathChar      = errors.New("invalid character in request path")
+	// ErrUnsupportedTransferCoding indicates a Transfer-Encoding other than chunked.
+	ErrUnsupportedTransferCoding = errors.New("unsupported transfer-coding")
 )
 
+// ParserLimits bounds the size of a request the parser accepts. A zero field
+// uses the built-in default: 4 KiB request line, 16 KiB of headers, 50
+// header fields, and a 256 KiB body.
+type ParserLimits struct {
+	// MaxRequestLineBytes caps the request line; longer lines are
+	// ErrRequestLineTooLong.
+	MaxRequestLineBytes int
+	// MaxHeadersBytes caps the request line and header section together, and
+	// separately a chunked body's trailer section; larger ones are
+	// ErrHeadersTooLarge.
+	MaxHeadersBytes int
+	// MaxHeaderCount caps the number of header fields; more are
+	// ErrTooManyHeaders.
+	MaxHeaderCount int
+	// MaxBodyBytes caps the decoded body; larger ones are ErrBodyTooLarge.
+	MaxBodyBytes int
+}
+
+// withDefaults returns l with each zero field set to its built-in default.
+func (l ParserLimits) withDefaults() ParserLimits {
+	if l.MaxRequestLineBytes <= 0 {
+		l.MaxRequestLineBytes = maxRequestLineBytes
+	}
+	if l.MaxHeadersBytes <= 0 {
+		l.MaxHeadersBytes = maxHeadersBytes
+	}
+	if l.MaxHeaderCount <= 0 {
+		l.MaxHeaderCount = maxHeaderCount
+	}
+	if l.MaxBodyBytes <= 0 {
+		l.MaxBodyBytes = maxBodyBytes
+	}
+	return l
+}
+
+// ParseOptions tunes ParseRequestWithOptions.
+type ParseOptions struct {
+	// AliasBody makes a Content-Length body share data's backing array
+	// instead of being copied. The caller must keep data alive and unmodified
+	// for as long as the request body is in use; the aliased slice is capped
+	// so appending to it never writes into data. Chunked bodies are always
+	// decoded into fresh memory.
+	AliasBody bool
+	// Limits bounds the accepted request size; see ParserLimits.
+	Limits ParserLimits
+}
+
 // ParseRequest parses a raw HTTP request from bytes.
 // It returns the parsed request, bytes consumed, and an error.
+// The body is copied, so data may be reused once ParseRequest returns.
 func ParseRequest(data []byte) (*Request, int, error) {
+	return ParseRequestWithOptions(data, ParseOptions{})
+}
+
+// ParseRequestWithLimits parses a raw HTTP request from bytes, enforcing
+// limits instead of the built-in defaults.
+func ParseRequestWithLimits(data []byte, limits ParserLimits) (*Request, int, error) {
+	return ParseRequestWithOptions(data, ParseOptions{Limits: limits})
+}
+
+// ParseRequestWithOptions parses a raw HTTP request from bytes using opts.
+func ParseRequestWithOptions(data []byte, opts ParseOptions) (*Request, int, error) {
 	if len(data) == 0 {
 		return nil, 0, ErrEmptyRequest
 	}
+	limits := opts.Limits.withDefaults()
 	headerEnd, delimiterLen := findHeaderDelimiter(data)
-	if len(data) > maxHeadersBytes && headerEnd < 0 {
+	if len(data) > limits.MaxHeadersBytes && headerEnd < 0 {
 		return nil, 0, ErrHeadersTooLarge
 	}
 	if headerEnd < 0 {
 		return nil, 0, ErrIncompleteRequest
 	}
-	if headerEnd > maxHeadersBytes {
+	if headerEnd > limits.MaxHeadersBytes {
 		return nil, 0, ErrHeadersTooLarge
 	}
 
-	head := string(data[:headerEnd])
-	lines := splitLines(head)
-	if len(lines) == 0 || strings.TrimSpace(lines[0]) == "" {
+	requestLine, rest, more := nextLine(data[:headerEnd])
+	if len(bytes.TrimSpace(requestLine)) == 0 {
 		return nil, 0, ErrMalformedRequestLine
 	}
-	if len(lines[0]) > maxRequestLineBytes {
+	if len(requestLine) > limits.MaxRequestLineBytes {
 		return nil, 0, ErrRequestLineTooLong
 	}
 
-	method, path, version, err := parseRequestLine(lines[0])
+	method, target, version, err := parseRequestLine(requestLine)
 	if err != nil {
 		return nil, 0, err
 	}
+	path, rawQuery := splitRequestTarget(target)
 
 	headers := make(map[string]string)
 	headerCount := 0
-	for _, line := range lines[1:] {
-		if strings.TrimSpace(line) == "" {
+	for more {
+		var line []byte
+		line, rest, more = nextLine(rest)
+		if len(bytes.TrimSpace(line)) == 0 {
 			continue
 		}
 		headerCount++
-		if headerCount > maxHeaderCount {
+		if headerCount > limits.MaxHeaderCount {
 			return nil, 0, ErrTooManyHeaders
 		}
 
-		colon := strings.Index(line, ":")
+		colon := bytes.IndexByte(line, ':')
 		if colon <= 0 {
 			return nil, 0, ErrInvalidHeader
 		}
 
-		key := strings.ToLower(strings.TrimSpace(line[:colon]))
-		value := strings.TrimSpace(line[colon+1:])
+		key := lowerHeaderKey(bytes.TrimSpace(line[:colon]))
+		value := string(bytes.TrimSpace(line[colon+1:]))
 		if key == "" {
 			return nil, 0, ErrInvalidHeader
 		}
+		if _, seen := headers[key]; seen && key == "host" {
+			return nil, 0, ErrMultipleHost
+		}
+		if previous, seen := headers[key]; seen && key == "content-length" && previous != value {
+			return nil, 0, ErrConflictingLength
+		}
 
 		headers[key] = value
 	}
@@ -99,44 +180,199 @@ func ParseRequest(data []byte) (*Request, int, error) {
 		return nil, 0, ErrIncompleteRequest
 	}
 
-	contentLength := 0
-	if rawLen, ok := headers["content-length"]; ok {
-		if rawLen == "" {
-			return nil, 0, ErrInvalidContentLength
+	var body []byte
+	consumed := bodyStart
+	if rawTE, ok := headers["transfer-encoding"]; ok {
+		if _, hasLength := headers["content-length"]; hasLength {
+			return nil, 0, ErrConflictingLength
+		}
+		if err := validateTransferEncoding(rawTE); err != nil {
+			return nil, 0, err
 		}
 
-		n, convErr := strconv.Atoi(rawLen)
-		if convErr != nil || n < 0 {
-			return nil, 0, ErrInvalidContentLength
+		decoded, n, chunkErr := decodeChunkedBody(data[bodyStart:], limits)
+		if chunkErr != nil {
+			return nil, 0, chunkErr
 		}
-		if n > maxBodyBytes {
-			return nil, 0, ErrBodyTooLarge
+		body = decoded
+		consumed += n
+	} else {
+		contentLength := 0
+		if rawLen, ok := headers["content-length"]; ok {
+			n, lenErr := parseContentLength(rawLen, limits.MaxBodyBytes)
+			if lenErr != nil {
+				return nil, 0, lenErr
+			}
+			contentLength = n
 		}
-		contentLength = n
-	}
 
-	if len(data)-bodyStart < contentLength {
-		return nil, 0, ErrIncompleteBody
+		if len(data)-bodyStart < contentLength {
+			return nil, 0, ErrIncompleteBody
+		}
+
+		bodyEnd := bodyStart + contentLength
+		if opts.AliasBody {
+			body = data[bodyStart:bodyEnd:bodyEnd]
+		} else {
+			body = make([]byte, contentLength)
+			copy(body, data[bodyStart:bodyEnd])
+		}
+		consumed += contentLength
 	}
 
-	body := make([]byte, contentLength)
-	copy(body, data[bodyStart:bodyStart+contentLength])
+	var query map[string][]string
+	if rawQuery != "" {
+		query, _ = ParseQuery(rawQuery, false)
+	}
 
 	req := &Request{
-		Method:  method,
-		Path:    path,
-		Version: version,
-		Headers: headers,
-		Body:    body,
+		Method:   method,
+		Path:     decodeRequestPath(path),
+		RawPath:  path,
+		RawQuery: rawQuery,
+		Query:    query,
+		Version:  version,
+		Headers:  headers,
+		Body:     body,
 	}
 
-	return req, bodyStart + contentLength, nil
+	return req, consumed, nil
+}
+
+// parseContentLength parses a Content-Length value of one or more decimal
+// digits. Signs and other bytes are ErrInvalidContentLength; a well-formed
+// value above maxBody, however many digits, is ErrBodyTooLarge.
+func parseContentLength(raw string, maxBody int) (int, error) {
+	if raw == "" {
+		return 0, ErrInvalidContentLength
+	}
+	n := 0
+	tooLarge := false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c < '0' || c > '9' {
+			return 0, ErrInvalidContentLength
+		}
+		if !tooLarge {
+			n = n*10 + int(c-'0')
+			tooLarge = n > maxBody
+		}
+	}
+	if tooLarge {
+		return 0, ErrBodyTooLarge
+	}
+	return n, nil
+}
+
+// validateTransferEncoding accepts exactly one chunked coding. Any other
+// coding is unsupported; an empty or repeated chunked entry is malformed.
+func validateTransferEncoding(raw string) error {
+	codings := strings.Split(raw, ",")
+	for _, coding := range codings {
+		coding = strings.TrimSpace(coding)
+		if coding == "" {
+			return ErrInvalidHeader
+		}
+		if !strings.EqualFold(coding, "chunked") {
+			return ErrUnsupportedTransferCoding
+		}
+	}
+	if len(codings) > 1 {
+		return ErrInvalidHeader
+	}
+	return nil
+}
+
+// decodeChunkedBody decodes a chunked message body, returning the body and the
+// bytes consumed through the terminating chunk and trailer section.
+// Trailer fields are read and discarded. The body and trailer section are
+// bounded by limits.
+func decodeChunkedBody(data []byte, limits ParserLimits) ([]byte, int, error) {
+	body := make([]byte, 0)
+	pos := 0
+	for {
+		sizeLine, next, ok := chunkLine(data[pos:])
+		if !ok {
+			return nil, 0, incompleteChunkErr(data[pos:])
+		}
+		if semi := bytes.IndexByte(sizeLine, ';'); semi >= 0 {
+			sizeLine = sizeLine[:semi]
+		}
+		sizeText := string(bytes.TrimSpace(sizeLine))
+		size, err := strconv.ParseUint(sizeText, 16, 64)
+		if sizeText == "" || (err != nil && !errors.Is(err, strconv.ErrRange)) {
+			return nil, 0, ErrInvalidChunk
+		}
+		if err != nil || size > uint64(limits.MaxBodyBytes-len(body)) {
+			return nil, 0, ErrBodyTooLarge
+		}
+		pos += next
+
+		if size == 0 {
+			trailerStart := pos
+			for {
+				trailer, next, ok := chunkLine(data[pos:])
+				if !ok {
+					return nil, 0, incompleteChunkErr(data[pos:])
+				}
+				pos += next
+				if pos-trailerStart > limits.MaxHeadersBytes {
+					return nil, 0, ErrHeadersTooLarge
+				}
+				if len(trailer) == 0 {
+					return body, pos, nil
+				}
+			}
+		}
+
+		end := pos + int(size)
+		if len(data) < end {
+			return nil, 0, ErrIncompleteBody
+		}
+		body = append(body, data[pos:end]...)
+		pos = end
+
+		terminator, next, ok := chunkLine(data[pos:])
+		if !ok {
+			if len(data)-pos >= 2 {
+				return nil, 0, ErrInvalidChunk
+			}
+			return nil, 0, ErrIncompleteBody
+		}
+		if len(terminator) != 0 {
+			return nil, 0, ErrInvalidChunk
+		}
+		pos += next
+	}
+}
+
+// incompleteChunkErr reports ErrIncompleteBody for a partial chunk line, or
+// ErrInvalidChunk once the unterminated line exceeds maxChunkLineBytes.
+func incompleteChunkErr(rest []byte) error {
+	if len(rest) > maxChunkLineBytes {
+		return ErrInvalidChunk
+	}
+	return ErrIncompleteBody
+}
+
+// chunkLine returns the next LF or CRLF terminated line of a chunked body
+// and the bytes it spans, or false when the line is not complete yet.
+func chunkLine(data []byte) ([]byte, int, bool) {
+	end := bytes.IndexByte(data, '\n')
+	if end < 0 {
+		return nil, 0, false
+	}
+	line := data[:end]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, end + 1, true
 }
 
 // findHeaderDelimiter locates the end of the HTTP headers and delimiter length.
 func findHeaderDelimiter(data []byte) (int, int) {
-	crlf := strings.Index(string(data), "\r\n\r\n")
-	lf := strings.Index(string(data), "\n\n")
+	crlf := bytes.Index(data, []byte("\r\n\r\n"))
+	lf := bytes.Index(data, []byte("\n\n"))
 
 	switch {
 	case crlf >= 0 && lf >= 0:
@@ -153,26 +389,171 @@ func findHeaderDelimiter(data []byte) (int, int) {
 	}
 }
 
-// splitLines normalizes line endings and splits the header block into lines.
-func splitLines(head string) []string {
-	normalized := strings.ReplaceAll(head, "\r\n", "\n")
-	return strings.Split(normalized, "\n")
+// nextLine returns the first line of head without its LF or CRLF terminator,
+// the remaining bytes, and whether a terminator was found.
+func nextLine(head []byte) ([]byte, []byte, bool) {
+	end := bytes.IndexByte(head, '\n')
+	if end < 0 {
+		return head, nil, false
+	}
+	line := head[:end]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, head[end+1:], true
 }
 
 // parseRequestLine parses and validates an HTTP request line.
-func parseRequestLine(line string) (string, string, string, error) {
-	parts := strings.Fields(line)
-	if len(parts) != 3 {
+func parseRequestLine(line []byte) (string, string, string, error) {
+	var parts [3][]byte
+	count := 0
+	if isASCII(line) {
+		for start := 0; start < len(line); {
+			if isASCIISpace(line[start]) {
+				start++
+				continue
+			}
+			end := start
+			for end < len(line) && !isASCIISpace(line[end]) {
+				end++
+			}
+			if count == len(parts) {
+				return "", "", "", ErrMalformedRequestLine
+			}
+			parts[count] = line[start:end]
+			count++
+			start = end
+		}
+	} else {
+		fields := bytes.Fields(line)
+		if len(fields) <= len(parts) {
+			count = copy(parts[:], fields)
+		} else {
+			count = len(fields)
+		}
+	}
+	if count != 3 {
 		return "", "", "", ErrMalformedRequestLine
 	}
 
-	method := parts[0]
-	path := parts[1]
-	version := parts[2]
-
-	if version != "HTTP/1.1" && version != "HTTP/1.0" {
+	var version string
+	switch string(parts[2]) {
+	case "HTTP/1.1":
+		version = "HTTP/1.1"
+	case "HTTP/1.0":
+		version = "HTTP/1.0"
+	default:
 		return "", "", "", ErrInvalidHTTPVersion
 	}
 
-	return method, path, version, nil
+	method, ok := commonMethods[string(parts[0])]
+	if !ok {
+		method = string(parts[0])
+	}
+	if !isValidRequestTarget(method, parts[1]) {
+		return "", "", "", ErrMalformedRequestLine
+	}
+	return method, string(parts[1]), version, nil
+}
+
+// isValidRequestTarget accepts the request-target forms the server handles:
+// origin-form starting with "/", absolute-form such as "http://host/path",
+// and the asterisk-form "*" of a server-wide OPTIONS request.
+func isValidRequestTarget(method string, target []byte) bool {
+	switch {
+	case len(target) > 0 && target[0] == '/':
+		return true
+	case len(target) == 1 && target[0] == '*':
+		return method == "OPTIONS"
+	default:
+		return isAbsoluteForm(target)
+	}
+}
+
+// isAbsoluteForm reports whether target starts with an RFC 3986 scheme
+// followed by "://".
+func isAbsoluteForm(target []byte) bool {
+	scheme, _, found := bytes.Cut(target, []byte("://"))
+	if !found || len(scheme) == 0 || !isASCIILetter(scheme[0]) {
+		return false
+	}
+	for _, c := range scheme[1:] {
+		if !isASCIILetter(c) && !(c >= '0' && c <= '9') && c != '+' && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// isASCIILetter reports whether c is an ASCII letter.
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// commonMethods interns frequent request methods to avoid per-request allocations.
+var commonMethods = map[string]string{
+	"GET":     "GET",
+	"HEAD":    "HEAD",
+	"POST":    "POST",
+	"PUT":     "PUT",
+	"PATCH":   "PATCH",
+	"DELETE":  "DELETE",
+	"OPTIONS": "OPTIONS",
+}
+
+// commonHeaderKeys interns frequent lowercase header names to avoid allocations.
+var commonHeaderKeys = map[string]string{
+	"accept":            "accept",
+	"accept-charset":    "accept-charset",
+	"accept-encoding":   "accept-encoding",
+	"accept-language":   "accept-language",
+	"authorization":     "authorization",
+	"cache-control":     "cache-control",
+	"connection":        "connection",
+	"content-length":    "content-length",
+	"content-type":      "content-type",
+	"cookie":            "cookie",
+	"host":              "host",
+	"referer":           "referer",
+	"te":                "te",
+	"transfer-encoding": "transfer-encoding",
+	"user-agent":        "user-agent",
+	"x-correlation-id":  "x-correlation-id",
+	"x-request-id":      "x-request-id",
+}
+
+// lowerHeaderKey lowercases a header name, reusing interned common names.
+// Non-ASCII names fall back to strings.ToLower for identical results.
+func lowerHeaderKey(raw []byte) string {
+	var buf [32]byte
+	if len(raw) > len(buf) || !isASCII(raw) {
+		return strings.ToLower(string(raw))
+	}
+
+	lower := buf[:len(raw)]
+	for i, c := range raw {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	if key, ok := commonHeaderKeys[string(lower)]; ok {
+		return key
+	}
+	return string(lower)
+}
+
+// isASCII reports whether b contains only ASCII bytes.
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// isASCIISpace matches the ASCII characters treated as space by bytes.Fields.
+func isASCIISpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
 }
@@ -2,6 +2,7 @@ package http
 
 import (
 	"errors"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -30,6 +31,28 @@ func TestParseRequest_ValidMinimal(t *testing.T) {
 	}
 }
 
+// TestParseRequest_RequestTargetForms verifies origin, absolute, and asterisk forms are accepted.
+func TestParseRequest_RequestTargetForms(t *testing.T) {
+	tests := []struct {
+		raw  string
+		path string
+	}{
+		{raw: "GET /users/42 HTTP/1.1\r\n\r\n", path: "/users/42"},
+		{raw: "GET http://example.com/users HTTP/1.1\r\n\r\n", path: "http://example.com/users"},
+		{raw: "OPTIONS * HTTP/1.1\r\n\r\n", path: "*"},
+	}
+
+	for _, tt := range tests {
+		req, _, err := ParseRequest([]byte(tt.raw))
+		if err != nil {
+			t.Fatalf("ParseRequest(%q): unexpected error: %v", tt.raw, err)
+		}
+		if req.Path != tt.path {
+			t.Fatalf("ParseRequest(%q): expected path %q, got %q", tt.raw, tt.path, req.Path)
+		}
+	}
+}
+
 // TestParseRequest_ValidWithHeadersAndBody verifies parsing headers and body.
 func TestParseRequest_ValidWithHeadersAndBody(t *testing.T) {
 	raw := []byte("POST /echo HTTP/1.1\r\nHost: localhost\r\nContent-Type: text/plain\r\nContent-Length: 5\r\n\r\nhello")
@@ -55,16 +78,52 @@ func TestParseRequest_ValidWithHeadersAndBody(t *testing.T) {
 	}
 }
 
-// TestParseRequest_PathWithQuery verifies query strings are preserved in path.
+// TestParseRequest_PathWithQuery verifies the query string is split from the path.
 func TestParseRequest_PathWithQuery(t *testing.T) {
-	raw := []byte("GET /users?id=1 HTTP/1.1\r\n\r\n")
+	raw := []byte("GET /users?id=1&id=2 HTTP/1.1\r\n\r\n")
 	req, _, err := ParseRequest(raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if req.Path != "/users?id=1" {
+	if req.Path != "/users" {
 		t.Fatalf("unexpected path: %q", req.Path)
 	}
+	if req.RawQuery != "id=1&id=2" {
+		t.Fatalf("unexpected raw query: %q", req.RawQuery)
+	}
+	if got := req.Query["id"]; !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Fatalf("expected repeated values to accumulate, got %v", got)
+	}
+}
+
+// TestParseRequest_QueryEdgeCases verifies empty queries, bare keys, and encoded characters.
+func TestParseRequest_QueryEdgeCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		wantPath  string
+		wantQuery map[string][]string
+	}{
+		{name: "no query", target: "/users", wantPath: "/users"},
+		{name: "empty query", target: "/users?", wantPath: "/users"},
+		{name: "key without value", target: "/users?flag", wantPath: "/users", wantQuery: map[string][]string{"flag": {""}}},
+		{name: "encoded characters", target: "/my%20files?name=a%20b&q=x+y", wantPath: "/my files", wantQuery: map[string][]string{"name": {"a b"}, "q": {"x y"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _, err := ParseRequest([]byte("GET " + tt.target + " HTTP/1.1\r\n\r\n"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if req.Path != tt.wantPath {
+				t.Fatalf("expected path %q, got %q", tt.wantPath, req.Path)
+			}
+			if len(req.Query) != len(tt.wantQuery) || (len(tt.wantQuery) > 0 && !reflect.DeepEqual(req.Query, tt.wantQuery)) {
+				t.Fatalf("expected query %v, got %v", tt.wantQuery, req.Query)
+			}
+		})
+	}
 }
 
 // TestParseRequest_LFOnlyLineEndings verifies LF-only requests are accepted.
@@ -81,13 +140,13 @@ func TestParseRequest_LFOnlyLineEndings(t *testing.T) {
 
 // TestParseRequest_HeaderNormalizationAndLastWins verifies normalized keys and overwrite behavior.
 func TestParseRequest_HeaderNormalizationAndLastWins(t *testing.T) {
-	raw := []byte("GET / HTTP/1.1\r\nHost: a\r\nhost: b\r\n\r\n")
+	raw := []byte("GET / HTTP/1.1\r\nX-Tag: a\r\nx-tag: b\r\n\r\n")
 	req, _, err := ParseRequest(raw)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if req.Headers["host"] != "b" {
-		t.Fatalf("expected last host header to win, got %q", req.Headers["host"])
+	if req.Headers["x-tag"] != "b" {
+		t.Fatalf("expected last x-tag header to win, got %q", req.Headers["x-tag"])
 	}
 }
 
@@ -121,6 +180,23 @@ func TestParseRequest_ContentLengthZero(t *testing.T) {
 	}
 }
 
+// TestParseRequest_DuplicateContentLength verifies repeated Content-Length headers must agree.
+func TestParseRequest_DuplicateContentLength(t *testing.T) {
+	raw := []byte("POST /upload HTTP/1.1\r\nContent-Length: 5\r\ncontent-length: 5\r\n\r\nhello")
+	req, _, err := ParseRequest(raw)
+	if err != nil {
+		t.Fatalf("unexpected error for identical lengths: %v", err)
+	}
+	if string(req.Body) != "hello" {
+		t.Fatalf("expected body %q, got %q", "hello", string(req.Body))
+	}
+
+	raw = []byte("POST /upload HTTP/1.1\r\nContent-Length: 5\r\nContent-Length: 3\r\n\r\nhello")
+	if _, _, err := ParseRequest(raw); !errors.Is(err, ErrConflictingLength) {
+		t.Fatalf("expected %v for differing lengths, got %v", ErrConflictingLength, err)
+	}
+}
+
 // TestParseRequest_Errors verifies malformed and incomplete request error handling.
 func TestParseRequest_Errors(t *testing.T) {
 	tests := []struct {
@@ -148,6 +224,16 @@ func TestParseRequest_Errors(t *testing.T) {
 			raw:  []byte("GET / HTTP/1.1 extra\r\n\r\n"),
 			want: ErrMalformedRequestLine,
 		},
+		{
+			name: "relative request target",
+			raw:  []byte("GET foo HTTP/1.1\r\n\r\n"),
+			want: ErrMalformedRequestLine,
+		},
+		{
+			name: "asterisk target outside OPTIONS",
+			raw:  []byte("GET * HTTP/1.1\r\n\r\n"),
+			want: ErrMalformedRequestLine,
+		},
 		{
 			name: "invalid version",
 			raw:  []byte("GET / HTTP/2.0\r\n\r\n"),
@@ -163,11 +249,31 @@ func TestParseRequest_Errors(t *testing.T) {
 			raw:  []byte("GET / HTTP/1.1\r\n: value\r\n\r\n"),
 			want: ErrInvalidHeader,
 		},
+		{
+			name: "multiple host headers",
+			raw:  []byte("GET / HTTP/1.1\r\nHost: a\r\nhost: b\r\n\r\n"),
+			want: ErrMultipleHost,
+		},
 		{
 			name: "invalid content-length non-numeric",
 			raw:  []byte("POST / HTTP/1.1\r\nContent-Length: abc\r\n\r\n"),
 			want: ErrInvalidContentLength,
 		},
+		{
+			name: "negative content-length",
+			raw:  []byte("POST / HTTP/1.1\r\nContent-Length: -1\r\n\r\n"),
+			want: ErrInvalidContentLength,
+		},
+		{
+			name: "signed content-length",
+			raw:  []byte("POST / HTTP/1.1\r\nContent-Length: +5\r\n\r\nhello"),
+			want: ErrInvalidContentLength,
+		},
+		{
+			name: "overflowing content-length",
+			raw:  []byte("POST / HTTP/1.1\r\nContent-Length: 99999999999999999999\r\n\r\n"),
+			want: ErrBodyTooLarge,
+		},
 		{
 			name: "content-length mismatch incomplete body",
 			raw:  []byte("POST / HTTP/1.1\r\nContent-Length: 5\r\n\r\nhey"),
@@ -213,3 +319,168 @@ func buildHeaders(count int) string {
 	}
 	return strings.Join(lines, "\r\n")
 }
+
+// TestParseRequest_ChunkedBody verifies chunked bodies are decoded and fully consumed.
+func TestParseRequest_ChunkedBody(t *testing.T) {
+	raw := "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"5\r\nhello\r\n7;ext=1\r\n, world\r\n0\r\nX-Trailer: done\r\n\r\n"
+	next := "GET / HTTP/1.1\r\n\r\n"
+
+	req, consumed, err := ParseRequest([]byte(raw + next))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(req.Body) != "hello, world" {
+		t.Fatalf("expected decoded body, got %q", string(req.Body))
+	}
+	if consumed != len(raw) {
+		t.Fatalf("expected %d consumed bytes, got %d", len(raw), consumed)
+	}
+}
+
+// TestParseRequest_ChunkedBodyErrors verifies partial, malformed, and conflicting chunked requests.
+func TestParseRequest_ChunkedBodyErrors(t *testing.T) {
+	head := "POST /upload HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n"
+	tests := []struct {
+		name string
+		raw  string
+		want error
+	}{
+		{name: "partial size line", raw: head + "5", want: ErrIncompleteBody},
+		{name: "partial chunk data", raw: head + "5\r\nhel", want: ErrIncompleteBody},
+		{name: "missing terminating chunk", raw: head + "5\r\nhello\r\n", want: ErrIncompleteBody},
+		{name: "missing final CRLF", raw: head + "0\r\n", want: ErrIncompleteBody},
+		{name: "non-hex size", raw: head + "zz\r\nhello\r\n0\r\n\r\n", want: ErrInvalidChunk},
+		{name: "empty size", raw: head + "\r\nhello\r\n0\r\n\r\n", want: ErrInvalidChunk},
+		{name: "data overruns size", raw: head + "2\r\nhello\r\n0\r\n\r\n", want: ErrInvalidChunk},
+		{name: "oversized chunk", raw: head + "ffffffffffffffffff\r\n", want: ErrBodyTooLarge},
+		{
+			name: "content-length and transfer-encoding",
+			raw:  "POST /upload HTTP/1.1\r\nContent-Length: 5\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n",
+			want: ErrConflictingLength,
+		},
+		{
+			name: "unsupported deflate coding",
+			raw:  "POST /upload HTTP/1.1\r\nTransfer-Encoding: deflate\r\n\r\nabc",
+			want: ErrUnsupportedTransferCoding,
+		},
+		{
+			name: "coding stacked before chunked",
+			raw:  "POST /upload HTTP/1.1\r\nTransfer-Encoding: gzip, chunked\r\n\r\n0\r\n\r\n",
+			want: ErrUnsupportedTransferCoding,
+		},
+		{
+			name: "chunked applied twice",
+			raw:  "POST /upload HTTP/1.1\r\nTransfer-Encoding: chunked, chunked\r\n\r\n0\r\n\r\n",
+			want: ErrInvalidHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseRequest([]byte(tt.raw))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestParseRequestWithOptions_AliasBody verifies the body aliases the input buffer without a copy.
+func TestParseRequestWithOptions_AliasBody(t *testing.T) {
+	raw := []byte("POST /upload HTTP/1.1\r\nContent-Length: 5\r\n\r\nhelloGET / HTTP/1.1\r\n\r\n")
+
+	req, consumed, err := ParseRequestWithOptions(raw, ParseOptions{AliasBody: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(req.Body) != "hello" {
+		t.Fatalf("expected aliased body hello, got %q", string(req.Body))
+	}
+	if &req.Body[0] != &raw[consumed-len(req.Body)] {
+		t.Fatalf("expected body to share the input buffer")
+	}
+	if cap(req.Body) != len(req.Body) {
+		t.Fatalf("expected aliased body capacity to be capped, got cap %d", cap(req.Body))
+	}
+
+	copied, _, err := ParseRequest(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw[consumed-1] = '!'
+	if string(req.Body) != "hell!" || string(copied.Body) != "hello" {
+		t.Fatalf("expected only the aliased body to observe buffer writes, got %q and %q", string(req.Body), string(copied.Body))
+	}
+}
+
+// TestParseRequestWithLimits verifies configured limits replace the defaults in both directions.
+func TestParseRequestWithLimits(t *testing.T) {
+	body := strings.Repeat("a", maxBodyBytes+1)
+	large := []byte("POST /upload HTTP/1.1\r\nContent-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body)
+	if _, _, err := ParseRequest(large); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("expected default limit to reject body, got %v", err)
+	}
+	req, _, err := ParseRequestWithLimits(large, ParserLimits{MaxBodyBytes: 2 * maxBodyBytes})
+	if err != nil {
+		t.Fatalf("expected larger limit to accept body, got %v", err)
+	}
+	if len(req.Body) != len(body) {
+		t.Fatalf("expected %d body bytes, got %d", len(body), len(req.Body))
+	}
+
+	chunked := []byte("POST /upload HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n" + strconv.FormatInt(int64(len(body)), 16) + "\r\n" + body + "\r\n0\r\n\r\n")
+	if _, _, err := ParseRequestWithLimits(chunked, ParserLimits{MaxBodyBytes: 2 * maxBodyBytes}); err != nil {
+		t.Fatalf("expected larger limit to accept chunked body, got %v", err)
+	}
+
+	small := ParserLimits{MaxRequestLineBytes: 16, MaxHeaderCount: 1, MaxHeadersBytes: 64}
+	tests := []struct {
+		raw  string
+		want error
+	}{
+		{raw: "GET /a-long-path HTTP/1.1\r\n\r\n", want: ErrRequestLineTooLong},
+		{raw: "GET / HTTP/1.1\r\nA: 1\r\nB: 2\r\n\r\n", want: ErrTooManyHeaders},
+		{raw: "GET / HTTP/1.1\r\nA: " + strings.Repeat("x", 64) + "\r\n\r\n", want: ErrHeadersTooLarge},
+	}
+	for _, tt := range tests {
+		if _, _, err := ParseRequestWithLimits([]byte(tt.raw), small); !errors.Is(err, tt.want) {
+			t.Fatalf("ParseRequestWithLimits(%q): expected %v, got %v", tt.raw, tt.want, err)
+		}
+	}
+}
+
+// BenchmarkParseRequestBody compares copied and aliased Content-Length bodies.
+func BenchmarkParseRequestBody(b *testing.B) {
+	raw := []byte("POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 65536\r\n\r\n" + strings.Repeat("x", 65536))
+
+	for _, bc := range []struct {
+		name string
+		opts ParseOptions
+	}{
+		{name: "copy", opts: ParseOptions{}},
+		{name: "alias", opts: ParseOptions{AliasBody: true}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := ParseRequestWithOptions(raw, bc.opts); err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParseRequest measures header parsing throughput and allocations.
+func BenchmarkParseRequest(b *testing.B) {
+	raw := []byte("POST /items?id=7 HTTP/1.1\r\nHost: example.com\r\nUser-Agent: bench/1.0\r\nAccept: */*\r\n" +
+		"Content-Type: application/json\r\nX-Request-Id: req-1\r\nContent-Length: 2\r\n\r\n{}")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := ParseRequest(raw); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
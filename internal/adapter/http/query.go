@@ -0,0 +1,86 @@
+package http
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidQueryEscape indicates a malformed percent-escape in the query string.
+var ErrInvalidQueryEscape = errors.New("invalid query escape")
+
+// ParseQuery decodes a raw query string into a multi-valued map.
+// Repeated keys accumulate in order and keys without "=" map to "".
+// In lenient mode a key or value whose escapes fail to decode is kept raw,
+// matching browser tolerance; strict mode returns ErrInvalidQueryEscape.
+func ParseQuery(rawQuery string, strict bool) (map[string][]string, error) {
+	values := make(map[string][]string)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+		key, err := decodeQueryComponent(rawKey, strict)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeQueryComponent(rawValue, strict)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = append(values[key], value)
+	}
+	return values, nil
+}
+
+// splitRequestTarget splits a request target into its path and raw query.
+func splitRequestTarget(target string) (string, string) {
+	path, rawQuery, _ := strings.Cut(target, "?")
+	return path, rawQuery
+}
+
+// decodeRequestPath percent-decodes a request path, keeping it raw when an
+// escape is malformed so lenient clients still reach a route.
+func decodeRequestPath(path string) string {
+	if strings.IndexByte(path, '%') < 0 {
+		return path
+	}
+	decoded, err := url.PathUnescape(path)
+	if err != nil {
+		return path
+	}
+	return decoded
+}
+
+// ValidatePathChars reports ErrInvalidPathChar when a raw, undecoded path
+// holds a byte other than RFC 3986 path characters or a percent sign.
+func ValidatePathChars(rawPath string) error {
+	for i := 0; i < len(rawPath); i++ {
+		if !isPathChar(rawPath[i]) {
+			return ErrInvalidPathChar
+		}
+	}
+	return nil
+}
+
+// isPathChar reports whether c may appear unescaped in a request path.
+func isPathChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-._~!$&'()*+,;=:@/%", c) >= 0
+}
+
+// decodeQueryComponent percent-decodes a query key or value.
+func decodeQueryComponent(raw string, strict bool) (string, error) {
+	decoded, err := url.QueryUnescape(raw)
+	if err == nil {
+		return decoded, nil
+	}
+	if strict {
+		return "", ErrInvalidQueryEscape
+	}
+	return raw, nil
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseQuery_MalformedEscape verifies lenient and strict handling of bad escapes.
+func TestParseQuery_MalformedEscape(t *testing.T) {
+	values, err := ParseQuery("x=%ZZ&y=a%20b", false)
+	if err != nil {
+		t.Fatalf("unexpected lenient error: %v", err)
+	}
+	want := map[string][]string{"x": {"%ZZ"}, "y": {"a b"}}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("unexpected lenient values: got %v, want %v", values, want)
+	}
+
+	if _, err := ParseQuery("x=%ZZ", true); !errors.Is(err, ErrInvalidQueryEscape) {
+		t.Fatalf("expected ErrInvalidQueryEscape in strict mode, got %v", err)
+	}
+}
+
+// TestHandleConnWithOptions_StrictQueryEscapes verifies strict mode rejects malformed escapes with 400.
+func TestHandleConnWithOptions_StrictQueryEscapes(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/search", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString("lenient")
+		return resp
+	})
+
+	tests := []struct {
+		name       string
+		opts       ServerOptions
+		wantStatus string
+	}{
+		{name: "strict", opts: ServerOptions{StrictQueryEscapes: true}, wantStatus: "HTTP/1.1 400 Bad Request\r\n"},
+		{name: "lenient", opts: ServerOptions{}, wantStatus: "HTTP/1.1 200 OK\r\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go HandleConnWithOptions(serverConn, router, context.Background(), tc.opts)
+
+			request := "GET /search?x=%ZZ HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+			if _, err := clientConn.Write([]byte(request)); err != nil {
+				t.Fatalf("write request failed: %v", err)
+			}
+
+			respBytes, err := io.ReadAll(clientConn)
+			if err != nil {
+				t.Fatalf("read response failed: %v", err)
+			}
+			if resp := string(respBytes); !strings.HasPrefix(resp, tc.wantStatus) {
+				t.Fatalf("expected %q, got %q", tc.wantStatus, resp)
+			}
+		})
+	}
+}
+
+// TestValidatePathChars verifies raw path validation against the allowed character set.
+func TestValidatePathChars(t *testing.T) {
+	if err := ValidatePathChars("/users/a-b_c~d/%20x;v=1"); err != nil {
+		t.Fatalf("unexpected error for normal path: %v", err)
+	}
+	for _, raw := range []string{"/logs\x01", "/caf\xc3\xa9", "/a<b>"} {
+		if err := ValidatePathChars(raw); !errors.Is(err, ErrInvalidPathChar) {
+			t.Fatalf("expected ErrInvalidPathChar for %q, got %v", raw, err)
+		}
+	}
+}
+
+// TestHandleConnWithOptions_StrictPathChars verifies strict mode rejects control bytes with 400.
+func TestHandleConnWithOptions_StrictPathChars(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/logs\x01", func(req *Request) *Response { return NewResponse() })
+	router.Register("GET", "/logs", func(req *Request) *Response { return NewResponse() })
+
+	tests := []struct {
+		name       string
+		path       string
+		opts       ServerOptions
+		wantStatus string
+	}{
+		{name: "strict control byte", path: "/logs\x01", opts: ServerOptions{StrictPathChars: true}, wantStatus: "HTTP/1.1 400 Bad Request\r\n"},
+		{name: "strict normal path", path: "/logs", opts: ServerOptions{StrictPathChars: true}, wantStatus: "HTTP/1.1 200 OK\r\n"},
+		{name: "lenient control byte", path: "/logs\x01", opts: ServerOptions{}, wantStatus: "HTTP/1.1 200 OK\r\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go HandleConnWithOptions(serverConn, router, context.Background(), tc.opts)
+
+			request := "GET " + tc.path + " HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+			if _, err := clientConn.Write([]byte(request)); err != nil {
+				t.Fatalf("write request failed: %v", err)
+			}
+
+			respBytes, err := io.ReadAll(clientConn)
+			if err != nil {
+				t.Fatalf("read response failed: %v", err)
+			}
+			if resp := string(respBytes); !strings.HasPrefix(resp, tc.wantStatus) {
+				t.Fatalf("expected %q, got %q", tc.wantStatus, resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"context"
+	"time"
+)
+
+// readLimiter paces connection reads to a byte rate. Each read waits until
+// the bytes already read are due at that rate, so idle time earns no burst
+// credit and the client is slowed by TCP backpressure rather than the server
+// buffering ahead.
+type readLimiter struct {
+	bytesPerSecond int
+	clock          Clock
+	next           time.Time
+}
+
+// newReadLimiter returns a limiter for bytesPerSecond, or nil when it is
+// not positive.
+func newReadLimiter(bytesPerSecond int, clock Clock) *readLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &readLimiter{bytesPerSecond: bytesPerSecond, clock: clockOrDefault(clock)}
+}
+
+// wait blocks until the next read is due and returns how many bytes it may
+// take, at most limit. It returns false when ctx ends first.
+func (l *readLimiter) wait(ctx context.Context, limit int) (int, bool) {
+	if delay := l.next.Sub(l.clock.Now()); delay > 0 {
+		var done <-chan struct{}
+		if ctx != nil {
+			done = ctx.Done()
+		}
+		select {
+		case <-l.clock.After(delay):
+		case <-done:
+			return 0, false
+		}
+	}
+	return min(limit, l.bytesPerSecond), true
+}
+
+// record schedules the next read after n bytes were read.
+func (l *readLimiter) record(n int) {
+	now := l.clock.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(n) * time.Second / time.Duration(l.bytesPerSecond))
+}
@@ -3,13 +3,40 @@ package http
 import "context"
 
 // Request is a parsed HTTP request.
+// Path holds the percent-decoded path without the query string and RawPath
+// the path as sent; RawQuery keeps the undecoded query and Query its
+// decoded, multi-valued form. Params holds values bound by router patterns.
 type Request struct {
-	Ctx     context.Context
-	Method  string
-	Path    string
-	Version string
-	Headers map[string]string
-	Body    []byte
+	Ctx      context.Context
+	Method   string
+	Path     string
+	RawPath  string
+	RawQuery string
+	Query    map[string][]string
+	Params   map[string]string
+	// Route is the registered path pattern that matched, such as
+	// "/users/:id", for handlers and router middleware; it is empty before
+	// routing and when no route matched.
+	Route string
+	// RemoteAddr and LocalAddr are the connection's peer and local addresses.
+	RemoteAddr string
+	LocalAddr  string
+	// ClientCertCN is the subject common name of the client certificate
+	// when the connection is TLS and the certificate was verified against
+	// the configured client CAs; it is empty otherwise.
+	ClientCertCN string
+	// TLS describes the negotiated TLS session, or is nil when the request
+	// arrived over a plain connection.
+	TLS *TLSInfo
+	// AllowedMethods lists the methods registered for Path when a custom
+	// method-not-allowed handler runs; it is nil otherwise.
+	AllowedMethods []string
+	Version        string
+	Headers        map[string]string
+	Body           []byte
+
+	// cookies caches the parsed Cookie header; see Cookies.
+	cookies map[string]string
 }
 
 // Context returns the request context or Background when unset.
@@ -0,0 +1,34 @@
+package http
+
+import "time"
+
+// RequestEvent describes a completed request, published to
+// ServerOptions.RequestEvents once its response has been written.
+type RequestEvent struct {
+	Method     string
+	Path       string
+	Status     int
+	Duration   time.Duration
+	RemoteAddr string
+}
+
+// publishRequestEvent sends an event for req without blocking; it is
+// dropped when events is full or nil.
+func publishRequestEvent(events chan<- RequestEvent, req *Request, resp *Response, duration time.Duration) {
+	if events == nil {
+		return
+	}
+	event := RequestEvent{
+		Method:   requestMethod(req),
+		Path:     requestPath(req),
+		Status:   resp.StatusCode,
+		Duration: duration,
+	}
+	if req != nil {
+		event.RemoteAddr = req.RemoteAddr
+	}
+	select {
+	case events <- event:
+	default:
+	}
+}
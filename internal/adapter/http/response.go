@@ -2,15 +2,80 @@ package http
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
+// httpDateFormat is the IMF-fixdate layout used by Date and Expires values.
+const httpDateFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+var (
+	responseDefaultsMu sync.RWMutex
+	responseClock      Clock = SystemClock
+	responseServerName string
+)
+
+// SetResponseClock sets the time source Bytes uses for the Date header.
+// A nil clock restores SystemClock.
+func SetResponseClock(clock Clock) {
+	responseDefaultsMu.Lock()
+	defer responseDefaultsMu.Unlock()
+	responseClock = clockOrDefault(clock)
+}
+
+// SetServerHeader sets the Server header value Bytes adds to responses that
+// do not set one. An empty name disables the header.
+func SetServerHeader(name string) {
+	responseDefaultsMu.Lock()
+	defer responseDefaultsMu.Unlock()
+	responseServerName = name
+}
+
+// currentResponseDefaults returns the Date clock and Server header value.
+func currentResponseDefaults() (Clock, string) {
+	responseDefaultsMu.RLock()
+	defer responseDefaultsMu.RUnlock()
+	return responseClock, responseServerName
+}
+
 // Response is an HTTP response model used by the HTTP adapter layer.
+// Handlers may leave StatusCode unset; a zero status is written as 200 OK.
 type Response struct {
 	StatusCode int
 	Headers    map[string]string
 	Body       []byte
+	// Raw holds pre-serialized wire bytes written verbatim instead of Bytes().
+	Raw []byte
+	// Trailers are sent after the body of a chunked response, declared in a
+	// Trailer header, when the request sent "TE: trailers"; otherwise they
+	// are dropped and the response is sent with Content-Length.
+	Trailers map[string]string
+	// Stream, when set, produces the body incrementally in place of Body.
+	// The server writes the status line and headers with chunked framing,
+	// then calls Stream with a writer that sends each Write as one chunk. A
+	// panic or error from Stream aborts the connection, because the status
+	// line has already been sent. HTTP/1.0 requests get the stream buffered.
+	// See StreamHandlerAdapter for handlers that choose the status while
+	// streaming.
+	Stream func(w io.Writer) error
+	// Version is the protocol of the status line, "HTTP/1.1" when empty.
+	// The server sets "HTTP/1.0" when answering an HTTP/1.0 request.
+	Version string
+
+	// extraHeaders holds values added by AddHeader after the first, which
+	// stays in Headers; see HeaderValues.
+	extraHeaders map[string][]string
+	// err records the last JSON marshal failure; see Err.
+	err error
+	// chunked selects chunked serialization so Trailers can be sent.
+	chunked bool
+	// deferHead delays the status line and headers until Stream first
+	// writes or returns, so a StreamHandler can still set them.
+	deferHead bool
 }
 
 // NewResponse creates a response with default values.
@@ -22,54 +87,345 @@ func NewResponse() *Response {
 	}
 }
 
-// SetHeader sets a response header value, initializing the map if needed.
-func (r *Response) SetHeader(key, value string) {
+// NewRawResponse wraps pre-built wire bytes, including status line, headers,
+// and body, that the server writes verbatim without re-serializing. The bytes
+// should carry their own Content-Length so keep-alive framing stays intact;
+// the server still closes the connection when the request requires it.
+func NewRawResponse(wire []byte) *Response {
+	raw := make([]byte, len(wire))
+	copy(raw, wire)
+	return &Response{
+		StatusCode: rawStatusCode(raw),
+		Headers:    make(map[string]string),
+		Body:       []byte{},
+		Raw:        raw,
+	}
+}
+
+// RawResponseHandler returns a handler that serves the same pre-built wire bytes.
+func RawResponseHandler(wire []byte) HandlerAdapter {
+	template := NewRawResponse(wire)
+	return func(req *Request) *Response {
+		return &Response{
+			StatusCode: template.StatusCode,
+			Headers:    make(map[string]string),
+			Body:       []byte{},
+			Raw:        template.Raw,
+		}
+	}
+}
+
+// jsonErrorEnvelope is the shared JSON error body shape.
+type jsonErrorEnvelope struct {
+	Error jsonErrorBody `json:"error"`
+}
+
+// jsonErrorBody holds the fields of a JSON error envelope.
+type jsonErrorBody struct {
+	Status    int    `json:"status"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// JSONError builds a response with the standard JSON error envelope
+// {"error":{"status":...,"message":"...","request_id":"..."}}.
+// An empty message defaults to the status reason phrase.
+func JSONError(req *Request, status int, message string) *Response {
+	if message == "" {
+		message = statusText(status)
+	}
+	requestID, _ := requestIdentifiers(req)
+
+	resp := NewResponse()
+	resp.StatusCode = status
+	resp.SetHeader("Content-Type", "application/json")
+	body, err := json.Marshal(jsonErrorEnvelope{Error: jsonErrorBody{
+		Status:    status,
+		Message:   message,
+		RequestID: requestID,
+	}})
+	if err != nil {
+		return internalServerErrorResponse()
+	}
+	resp.WriteBytes(body)
+	return resp
+}
+
+// Reset restores the response to NewResponse defaults while keeping its
+// allocations, so responses can be pooled with sync.Pool. The headers map is
+// cleared in place and Body is truncated to length 0 with its capacity kept;
+// append to Body to reuse that capacity. A reset response must not be used by
+// anything still holding a reference from before the reset.
+func (r *Response) Reset() {
+	r.StatusCode = 200
+	if r.Headers == nil {
+		r.Headers = make(map[string]string)
+	} else {
+		for key := range r.Headers {
+			delete(r.Headers, key)
+		}
+	}
+	if r.Body == nil {
+		r.Body = []byte{}
+	} else {
+		r.Body = r.Body[:0]
+	}
+	r.Raw = nil
+	r.Trailers = nil
+	r.Stream = nil
+	r.Version = ""
+	r.extraHeaders = nil
+	r.err = nil
+	r.chunked = false
+	r.deferHead = false
+}
+
+// Status sets the status code and returns r for chaining.
+func (r *Response) Status(code int) *Response {
+	r.StatusCode = code
+	return r
+}
+
+// Header is SetHeader for use in a fluent chain.
+func (r *Response) Header(key, value string) *Response {
+	return r.SetHeader(key, value)
+}
+
+// SetHeader sets a response header value, initializing the map if needed and
+// replacing any values added with AddHeader. It returns r for chaining.
+func (r *Response) SetHeader(key, value string) *Response {
 	if r.Headers == nil {
 		r.Headers = make(map[string]string)
 	}
 	r.Headers[key] = value
+	delete(r.extraHeaders, key)
+	return r
 }
 
-// WriteBytes replaces the response body with the provided bytes.
-func (r *Response) WriteBytes(body []byte) {
+// AddHeader appends a header value, so a header such as Set-Cookie can be
+// sent on several lines. The first value lives in Headers. It returns r.
+func (r *Response) AddHeader(key, value string) *Response {
+	if _, ok := r.Headers[key]; !ok {
+		return r.SetHeader(key, value)
+	}
+	if r.extraHeaders == nil {
+		r.extraHeaders = make(map[string][]string)
+	}
+	r.extraHeaders[key] = append(r.extraHeaders[key], value)
+	return r
+}
+
+// HeaderValues returns every value set for key, in the order added.
+func (r *Response) HeaderValues(key string) []string {
+	first, ok := r.Headers[key]
+	if !ok {
+		return nil
+	}
+	return append([]string{first}, r.extraHeaders[key]...)
+}
+
+// WriteBytes replaces the response body with the provided bytes and returns r.
+func (r *Response) WriteBytes(body []byte) *Response {
 	r.Body = make([]byte, len(body))
 	copy(r.Body, body)
+	return r
 }
 
-// WriteString replaces the response body with the provided string.
-func (r *Response) WriteString(body string) {
+// WriteString replaces the response body with the provided string and returns r.
+func (r *Response) WriteString(body string) *Response {
 	r.Body = []byte(body)
+	return r
+}
+
+// JSON is WriteJSON for use in a fluent chain. A marshal error leaves the
+// response unchanged and is kept for inspection through Err.
+func (r *Response) JSON(v any) *Response {
+	r.err = r.WriteJSON(v)
+	return r
+}
+
+// MustJSON is JSON that panics when v cannot be marshaled.
+func (r *Response) MustJSON(v any) *Response {
+	if err := r.WriteJSON(v); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Err returns the error recorded by the most recent JSON call, if any.
+func (r *Response) Err() error {
+	return r.err
 }
 
-// Bytes serializes the response to HTTP/1.1 wire format.
+// WriteJSON replaces the body with v marshaled as JSON and sets Content-Type to
+// application/json; charset=utf-8 unless a Content-Type is already set. On a
+// marshal error the response is left unchanged and the error is returned.
+func (r *Response) WriteJSON(v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if r.Headers == nil || !hasHeaderIgnoreCase(r.Headers, "Content-Type") {
+		r.SetHeader("Content-Type", "application/json; charset=utf-8")
+	}
+	r.Body = body
+	return nil
+}
+
+// Bytes serializes the response to HTTP/1.1 wire format, or HTTP/1.0 when
+// Version says so.
+// Raw responses are returned as-is.
 func (r *Response) Bytes() []byte {
+	if r.Raw != nil {
+		return r.Raw
+	}
+
 	if r.Headers == nil {
 		r.Headers = make(map[string]string)
 	}
 
-	if !hasHeaderIgnoreCase(r.Headers, "Content-Length") {
+	streaming := r.Stream != nil
+	if r.chunked || streaming {
+		r.setChunkedHeaders()
+	} else if !hasHeaderIgnoreCase(r.Headers, "Content-Length") {
 		r.Headers["Content-Length"] = strconv.Itoa(len(r.Body))
 	}
+	r.setDefaultHeaders()
+
+	statusCode := r.StatusCode
+	if statusCode == 0 {
+		statusCode = 200
+	}
 
 	var buf bytes.Buffer
-	buf.WriteString("HTTP/1.1 ")
-	buf.WriteString(strconv.Itoa(r.StatusCode))
+	if r.Version == "HTTP/1.0" {
+		buf.WriteString("HTTP/1.0 ")
+	} else {
+		buf.WriteString("HTTP/1.1 ")
+	}
+	buf.WriteString(strconv.Itoa(statusCode))
 	buf.WriteString(" ")
-	buf.WriteString(statusText(r.StatusCode))
+	buf.WriteString(statusText(statusCode))
 	buf.WriteString("\r\n")
 
-	for key, value := range r.Headers {
-		buf.WriteString(key)
-		buf.WriteString(": ")
-		buf.WriteString(value)
-		buf.WriteString("\r\n")
+	// Headers are written in sorted order so the output is deterministic.
+	keys := make([]string, 0, len(r.Headers))
+	for key := range r.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeHeaderLine(&buf, key, r.Headers[key])
+		for _, extra := range r.extraHeaders[key] {
+			writeHeaderLine(&buf, key, extra)
+		}
 	}
 
 	buf.WriteString("\r\n")
-	buf.Write(r.Body)
+	switch {
+	case streaming:
+		// The server writes the body chunks as Stream produces them.
+	case r.chunked:
+		r.writeChunkedBody(&buf)
+	default:
+		buf.Write(r.Body)
+	}
 	return buf.Bytes()
 }
 
+// setDefaultHeaders adds Date and, when configured, Server unless the
+// handler already set them.
+func (r *Response) setDefaultHeaders() {
+	clock, serverName := currentResponseDefaults()
+	if !hasHeaderIgnoreCase(r.Headers, "Date") {
+		r.Headers["Date"] = clock.Now().UTC().Format(httpDateFormat)
+	}
+	if serverName != "" && !hasHeaderIgnoreCase(r.Headers, "Server") {
+		r.Headers["Server"] = serverName
+	}
+}
+
+// setChunkedHeaders replaces Content-Length with chunked framing and declares
+// the trailer names.
+func (r *Response) setChunkedHeaders() {
+	for key := range r.Headers {
+		if strings.EqualFold(key, "Content-Length") {
+			delete(r.Headers, key)
+		}
+	}
+	r.Headers["Transfer-Encoding"] = "chunked"
+	if !r.chunked {
+		return
+	}
+	names := make([]string, 0, len(r.Trailers))
+	for key := range r.Trailers {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	r.Headers["Trailer"] = strings.Join(names, ", ")
+}
+
+// writeChunkedBody writes the body as a single chunk followed by the last
+// chunk and the trailer section.
+func (r *Response) writeChunkedBody(buf *bytes.Buffer) {
+	if len(r.Body) > 0 {
+		buf.WriteString(strconv.FormatInt(int64(len(r.Body)), 16))
+		buf.WriteString("\r\n")
+		buf.Write(r.Body)
+		buf.WriteString("\r\n")
+	}
+	r.writeLastChunk(buf)
+}
+
+// writeLastChunk writes the zero-size chunk and, when trailers are being
+// sent, the trailer section, ending the chunked body.
+func (r *Response) writeLastChunk(buf *bytes.Buffer) {
+	buf.WriteString("0\r\n")
+	if r.chunked {
+		for key, value := range r.Trailers {
+			writeHeaderLine(buf, key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+// writeHeaderLine writes one "key: value" header line.
+func writeHeaderLine(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+// headerBytes returns the serialized size of the header lines.
+func (r *Response) headerBytes() int {
+	size := 0
+	for key, value := range r.Headers {
+		size += len(key) + len(": ") + len(value) + len("\r\n")
+		for _, extra := range r.extraHeaders[key] {
+			size += len(key) + len(": ") + len(extra) + len("\r\n")
+		}
+	}
+	return size
+}
+
+// rawStatusCode reads the status code from a serialized status line, or 0.
+func rawStatusCode(wire []byte) int {
+	line := string(wire)
+	if end := strings.Index(line, "\r\n"); end >= 0 {
+		line = line[:end]
+	}
+	parts := strings.Fields(line)
+	if len(parts) < 2 {
+		return 0
+	}
+	code, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0
+	}
+	return code
+}
+
 // statusText returns a reason phrase for a status code.
 func statusText(code int) string {
 	switch code {
@@ -79,18 +435,56 @@ func statusText(code int) string {
 		return "Created"
 	case 204:
 		return "No Content"
+	case 206:
+		return "Partial Content"
+	case 301:
+		return "Moved Permanently"
+	case 304:
+		return "Not Modified"
+	case 308:
+		return "Permanent Redirect"
 	case 400:
 		return "Bad Request"
 	case 401:
 		return "Unauthorized"
+	case 403:
+		return "Forbidden"
 	case 404:
 		return "Not Found"
 	case 405:
 		return "Method Not Allowed"
+	case 406:
+		return "Not Acceptable"
 	case 408:
 		return "Request Timeout"
+	case 409:
+		return "Conflict"
+	case 412:
+		return "Precondition Failed"
+	case 413:
+		return "Content Too Large"
+	case 415:
+		return "Unsupported Media Type"
+	case 416:
+		return "Range Not Satisfiable"
+	case 421:
+		return "Misdirected Request"
+	case 422:
+		return "Unprocessable Entity"
+	case 429:
+		return "Too Many Requests"
+	case 431:
+		return "Request Header Fields Too Large"
+	case StatusClientClosedRequest:
+		return "Client Closed Request"
 	case 500:
 		return "Internal Server Error"
+	case 501:
+		return "Not Implemented"
+	case 503:
+		return "Service Unavailable"
+	case 504:
+		return "Gateway Timeout"
 	default:
 		return "Unknown"
 	}
@@ -105,3 +499,14 @@ func hasHeaderIgnoreCase(headers map[string]string, target string) bool {
 	}
 	return false
 }
+
+// headerValueIgnoreCase returns the value of a header matched
+// case-insensitively, or "" when it is absent.
+func headerValueIgnoreCase(headers map[string]string, target string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, target) {
+			return value
+		}
+	}
+	return ""
+}
@@ -2,8 +2,11 @@ package http
 
 import (
 	"bytes"
+	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestNewResponse_Defaults verifies default response values.
@@ -158,3 +161,246 @@ func TestResponse_Bytes_BodyBytesUnchanged(t *testing.T) {
 		t.Fatalf("expected body %v, got %v", body, gotBody)
 	}
 }
+
+// TestResponse_ResetReusesAllocations verifies reset responses match fresh ones and keep their buffers.
+func TestResponse_ResetReusesAllocations(t *testing.T) {
+	SetResponseClock(newFakeClock())
+	t.Cleanup(func() { SetResponseClock(nil) })
+
+	resp := NewResponse()
+	resp.StatusCode = 404
+	resp.SetHeader("Content-Type", "text/plain")
+	resp.SetHeader("X-Trace", "abc")
+	resp.Body = append(make([]byte, 0, 64), "not found"...)
+
+	bodyPtr := &resp.Body[:1][0]
+	headers := resp.Headers
+	resp.Reset()
+
+	fresh := NewResponse()
+	if resp.StatusCode != fresh.StatusCode {
+		t.Fatalf("expected status %d after reset, got %d", fresh.StatusCode, resp.StatusCode)
+	}
+	if len(resp.Headers) != 0 || len(resp.Body) != 0 {
+		t.Fatalf("expected empty headers and body after reset, got %#v %q", resp.Headers, string(resp.Body))
+	}
+	if !bytes.Equal(resp.Bytes(), fresh.Bytes()) {
+		t.Fatalf("expected reset wire output %q, got %q", string(fresh.Bytes()), string(resp.Bytes()))
+	}
+
+	resp.Reset()
+	resp.Headers["X-Reused"] = "yes"
+	if headers["X-Reused"] != "yes" {
+		t.Fatalf("expected headers map allocation to be reused")
+	}
+	if cap(resp.Body) != 64 {
+		t.Fatalf("expected body capacity 64 to be kept, got %d", cap(resp.Body))
+	}
+	resp.Body = append(resp.Body, 'x')
+	if &resp.Body[0] != bodyPtr {
+		t.Fatalf("expected body backing array to be reused")
+	}
+}
+
+// TestNewRawResponse_StatusFromWire verifies raw responses expose their status for middleware.
+func TestNewRawResponse_StatusFromWire(t *testing.T) {
+	wire := []byte("HTTP/1.1 204 No Content\r\n\r\n")
+	resp := NewRawResponse(wire)
+
+	if resp.StatusCode != 204 {
+		t.Fatalf("expected status 204, got %d", resp.StatusCode)
+	}
+	resp.SetHeader("Connection", "close")
+	if !bytes.Equal(resp.Bytes(), wire) {
+		t.Fatalf("expected verbatim wire bytes, got %q", string(resp.Bytes()))
+	}
+}
+
+// TestJSONError_Envelope verifies the JSON error envelope fields.
+func TestJSONError_Envelope(t *testing.T) {
+	req := &Request{Headers: map[string]string{"x-request-id": "req-42"}}
+	resp := JSONError(req, 404, "")
+
+	if resp.StatusCode != 404 {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+	if got := resp.Headers["Content-Type"]; got != "application/json" {
+		t.Fatalf("expected application/json content type, got %q", got)
+	}
+
+	var envelope struct {
+		Error struct {
+			Status    int    `json:"status"`
+			Message   string `json:"message"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Body, &envelope); err != nil {
+		t.Fatalf("expected valid JSON body, got %q: %v", string(resp.Body), err)
+	}
+	if envelope.Error.Status != 404 || envelope.Error.Message != "Not Found" || envelope.Error.RequestID != "req-42" {
+		t.Fatalf("unexpected envelope: %+v", envelope.Error)
+	}
+}
+
+// TestResponse_WriteJSON verifies marshaling of common value shapes and the content type.
+func TestResponse_WriteJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "struct", value: struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		}{ID: 7, Name: "ada"}, want: `{"id":7,"name":"ada"}`},
+		{name: "map", value: map[string]int{"a": 1, "b": 2}, want: `{"a":1,"b":2}`},
+		{name: "slice", value: []string{"x", "y"}, want: `["x","y"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewResponse()
+			if err := resp.WriteJSON(tt.value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resp.Body) != tt.want {
+				t.Fatalf("expected body %s, got %s", tt.want, string(resp.Body))
+			}
+			if got := resp.Headers["Content-Type"]; got != "application/json; charset=utf-8" {
+				t.Fatalf("expected JSON content type, got %q", got)
+			}
+		})
+	}
+}
+
+// TestResponse_WriteJSON_KeepsContentTypeAndBodyOnError verifies existing state survives.
+func TestResponse_WriteJSON_KeepsContentTypeAndBodyOnError(t *testing.T) {
+	resp := NewResponse()
+	resp.SetHeader("content-type", "application/problem+json")
+	if err := resp.WriteJSON(map[string]bool{"ok": true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, overwritten := resp.Headers["Content-Type"]; overwritten {
+		t.Fatalf("expected preset content type to be kept, got %#v", resp.Headers)
+	}
+
+	resp.WriteString("previous")
+	if err := resp.WriteJSON(make(chan int)); err == nil {
+		t.Fatalf("expected marshal error for channel value")
+	}
+	if string(resp.Body) != "previous" {
+		t.Fatalf("expected body to be unchanged on error, got %q", string(resp.Body))
+	}
+}
+
+// TestResponse_FluentChain verifies the builder methods chain into one expression.
+func TestResponse_FluentChain(t *testing.T) {
+	resp := NewResponse().Status(201).Header("Location", "/users/42").JSON(map[string]string{"id": "42"})
+
+	if resp.Err() != nil {
+		t.Fatalf("unexpected error: %v", resp.Err())
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	if resp.Headers["Location"] != "/users/42" {
+		t.Fatalf("expected Location header, got %#v", resp.Headers)
+	}
+	if resp.Headers["Content-Type"] != "application/json; charset=utf-8" {
+		t.Fatalf("expected JSON content type, got %#v", resp.Headers)
+	}
+	if string(resp.Body) != `{"id":"42"}` {
+		t.Fatalf("unexpected body %q", string(resp.Body))
+	}
+
+	text := NewResponse().Status(202).SetHeader("X-Job", "7").WriteString("queued")
+	if text.StatusCode != 202 || text.Headers["X-Job"] != "7" || string(text.Body) != "queued" {
+		t.Fatalf("unexpected chained response %#v", text)
+	}
+}
+
+// TestResponse_JSONRecordsMarshalError verifies JSON keeps the error and MustJSON panics.
+func TestResponse_JSONRecordsMarshalError(t *testing.T) {
+	resp := NewResponse().WriteString("previous").JSON(make(chan int))
+	if resp.Err() == nil {
+		t.Fatalf("expected marshal error to be recorded")
+	}
+	if string(resp.Body) != "previous" {
+		t.Fatalf("expected body to be unchanged on error, got %q", string(resp.Body))
+	}
+	if resp.JSON(1).Err() != nil {
+		t.Fatalf("expected a successful JSON call to clear the error")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected MustJSON to panic on marshal error")
+		}
+	}()
+	NewResponse().MustJSON(make(chan int))
+}
+
+// TestResponse_AddHeaderEmitsOneLinePerValue verifies repeated headers such as Set-Cookie survive serialization.
+func TestResponse_AddHeaderEmitsOneLinePerValue(t *testing.T) {
+	resp := NewResponse().
+		AddHeader("Set-Cookie", "session=abc; HttpOnly").
+		AddHeader("Set-Cookie", "theme=dark").
+		WriteString("ok")
+
+	wire := string(resp.Bytes())
+	for _, want := range []string{"Set-Cookie: session=abc; HttpOnly\r\n", "Set-Cookie: theme=dark\r\n"} {
+		if !strings.Contains(wire, want) {
+			t.Fatalf("expected %q in wire output, got %q", want, wire)
+		}
+	}
+	if got := strings.Count(wire, "Set-Cookie:"); got != 2 {
+		t.Fatalf("expected 2 Set-Cookie lines, got %d in %q", got, wire)
+	}
+	if got := resp.HeaderValues("Set-Cookie"); !reflect.DeepEqual(got, []string{"session=abc; HttpOnly", "theme=dark"}) {
+		t.Fatalf("unexpected header values %v", got)
+	}
+
+	resp.SetHeader("Set-Cookie", "only=1")
+	if got := resp.HeaderValues("Set-Cookie"); !reflect.DeepEqual(got, []string{"only=1"}) {
+		t.Fatalf("expected SetHeader to replace added values, got %v", got)
+	}
+}
+
+// TestResponse_BytesAddsDateHeader verifies Date is an IMF-fixdate from the response clock.
+func TestResponse_BytesAddsDateHeader(t *testing.T) {
+	clock := newFakeClock()
+	clock.Advance(90 * time.Minute)
+	SetResponseClock(clock)
+	t.Cleanup(func() { SetResponseClock(nil) })
+
+	wire := string(NewResponse().WriteString("ok").Bytes())
+	if !strings.Contains(wire, "Date: Mon, 01 Jan 2024 01:30:00 GMT\r\n") {
+		t.Fatalf("expected Date header from clock, got %q", wire)
+	}
+	if strings.Contains(wire, "Server:") {
+		t.Fatalf("expected no Server header by default, got %q", wire)
+	}
+}
+
+// TestResponse_BytesKeepsHandlerDateAndServer verifies explicit Date and Server values are not overwritten.
+func TestResponse_BytesKeepsHandlerDateAndServer(t *testing.T) {
+	SetServerHeader("light_serve")
+	t.Cleanup(func() { SetServerHeader("") })
+
+	resp := NewResponse().
+		Header("date", "Tue, 02 Jan 2024 00:00:00 GMT").
+		Header("Server", "custom")
+	wire := string(resp.Bytes())
+	if strings.Count(wire, "ate: ") != 1 || !strings.Contains(wire, "date: Tue, 02 Jan 2024 00:00:00 GMT\r\n") {
+		t.Fatalf("expected handler Date to be kept, got %q", wire)
+	}
+	if strings.Count(wire, "Server:") != 1 || !strings.Contains(wire, "Server: custom\r\n") {
+		t.Fatalf("expected handler Server to be kept, got %q", wire)
+	}
+
+	wire = string(NewResponse().Bytes())
+	if !strings.Contains(wire, "Server: light_serve\r\n") {
+		t.Fatalf("expected configured Server header, got %q", wire)
+	}
+}
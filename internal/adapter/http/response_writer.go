@@ -0,0 +1,70 @@
+package http
+
+import "io"
+
+// ResponseWriter lets a StreamHandler send a large or long-lived body as it
+// is produced. The status and headers may be changed until the first Write
+// or Flush sends them; later changes are ignored. Each Write then reaches
+// the client as one chunk. HTTP/1.0 clients get the whole body buffered.
+type ResponseWriter struct {
+	resp *Response
+	w    io.Writer
+	sent bool
+}
+
+// SetHeader sets a response header, replacing any existing values.
+func (w *ResponseWriter) SetHeader(key, value string) {
+	if !w.sent {
+		w.resp.SetHeader(key, value)
+	}
+}
+
+// AddHeader adds a value to a response header.
+func (w *ResponseWriter) AddHeader(key, value string) {
+	if !w.sent {
+		w.resp.AddHeader(key, value)
+	}
+}
+
+// WriteHeader sets the status code sent with the head.
+func (w *ResponseWriter) WriteHeader(status int) {
+	if !w.sent {
+		w.resp.StatusCode = status
+	}
+}
+
+// Write sends the head if still pending, then p as one chunk.
+func (w *ResponseWriter) Write(p []byte) (int, error) {
+	w.sent = true
+	return w.w.Write(p)
+}
+
+// Flush sends the head if still pending, without any body, so the client
+// sees the response start before the first chunk is ready.
+func (w *ResponseWriter) Flush() error {
+	w.sent = true
+	if f, ok := w.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// StreamHandler writes its response through w instead of returning one.
+// An error or panic before anything was sent yields a 500; afterwards the
+// connection is aborted, since the status line has already gone out.
+type StreamHandler func(w *ResponseWriter, req *Request) error
+
+// StreamHandlerAdapter adapts handler for registration on a Router. The
+// handler runs after middleware has returned, while the response is being
+// written, so middleware sees the 200 default rather than the status the
+// handler later chooses. Trailers are not supported.
+func StreamHandlerAdapter(handler StreamHandler) HandlerAdapter {
+	return func(req *Request) *Response {
+		resp := NewResponse()
+		resp.deferHead = true
+		resp.Stream = func(w io.Writer) error {
+			return handler(&ResponseWriter{resp: resp, w: w}, req)
+		}
+		return resp
+	}
+}
@@ -0,0 +1,56 @@
+package http
+
+import "strings"
+
+// RouteGroup registers routes on a router under a shared path prefix and
+// middleware chain.
+type RouteGroup struct {
+	router      *Router
+	prefix      string
+	middlewares []Middleware
+}
+
+// Group returns a route group whose routes live under prefix and run behind
+// middlewares, inside the router's own middleware chain.
+func (r *Router) Group(prefix string, middlewares ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		router:      r,
+		prefix:      joinRoutePath("", prefix),
+		middlewares: append([]Middleware(nil), middlewares...),
+	}
+}
+
+// Group returns a nested group under this group's prefix. Its middlewares
+// run inside this group's.
+func (g *RouteGroup) Group(prefix string, middlewares ...Middleware) *RouteGroup {
+	combined := make([]Middleware, 0, len(g.middlewares)+len(middlewares))
+	combined = append(combined, g.middlewares...)
+	combined = append(combined, middlewares...)
+	return &RouteGroup{
+		router:      g.router,
+		prefix:      joinRoutePath(g.prefix, prefix),
+		middlewares: combined,
+	}
+}
+
+// Register maps method and the group prefix joined with path to handler,
+// wrapped in the group middlewares.
+func (g *RouteGroup) Register(method, path string, handler HandlerAdapter) {
+	g.router.Register(method, joinRoutePath(g.prefix, path), applyMiddleware(handler, g.middlewares))
+}
+
+// joinRoutePath joins a group prefix and a route path with exactly one slash
+// between them; an empty or "/" path maps to the prefix itself.
+func joinRoutePath(prefix, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if path == "" || path == "/" {
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return prefix + path
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRouteGroup_PrefixConcatenation verifies group prefixes join route paths with a single slash.
+func TestRouteGroup_PrefixConcatenation(t *testing.T) {
+	tests := []struct {
+		prefix string
+		path   string
+		want   string
+	}{
+		{prefix: "/api/v1", path: "/users", want: "/api/v1/users"},
+		{prefix: "/api/v1/", path: "users", want: "/api/v1/users"},
+		{prefix: "api", path: "/users/:id", want: "/api/users/:id"},
+		{prefix: "/api", path: "/", want: "/api"},
+		{prefix: "/", path: "/health", want: "/health"},
+	}
+
+	for _, tt := range tests {
+		router := NewRouter()
+		router.Group(tt.prefix).Register("GET", tt.path, func(req *Request) *Response { return NewResponse() })
+		if !router.Has("GET", tt.want) {
+			t.Fatalf("Group(%q).Register(%q): expected route %q", tt.prefix, tt.path, tt.want)
+		}
+	}
+}
+
+// TestRouteGroup_NestedMiddlewareOrder verifies middleware runs from the router through outer to inner groups.
+func TestRouteGroup_NestedMiddlewareOrder(t *testing.T) {
+	var order []string
+	record := func(name string) Middleware {
+		return func(next HandlerAdapter) HandlerAdapter {
+			return func(req *Request) *Response {
+				order = append(order, name)
+				return next(req)
+			}
+		}
+	}
+
+	router := NewRouter()
+	router.Use(record("router"))
+	api := router.Group("/api", record("api"))
+	v1 := api.Group("/v1", record("v1-a"), record("v1-b"))
+	v1.Register("GET", "/users/:id", func(req *Request) *Response {
+		order = append(order, "handler:"+req.Params["id"])
+		return NewResponse()
+	})
+	api.Register("GET", "/status", func(req *Request) *Response {
+		order = append(order, "status")
+		return NewResponse()
+	})
+
+	router.ServeRequest(&Request{Method: "GET", Path: "/api/v1/users/7"})
+	if want := []string{"router", "api", "v1-a", "v1-b", "handler:7"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("expected order %v, got %v", want, order)
+	}
+
+	order = nil
+	router.ServeRequest(&Request{Method: "GET", Path: "/api/status"})
+	if want := []string{"router", "api", "status"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("expected nested group middleware not to leak into the parent, got %v", order)
+	}
+}
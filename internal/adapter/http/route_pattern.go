@@ -0,0 +1,76 @@
+package http
+
+import "strings"
+
+// routePattern is a registered route whose path has ":name" or "*name" segments.
+type routePattern struct {
+	key      string
+	method   string
+	segments []string
+	handler  HandlerAdapter
+}
+
+// match reports whether path segments satisfy the pattern and returns the
+// captured parameters.
+func (p *routePattern) match(segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, segment := range p.segments {
+		switch {
+		case strings.HasPrefix(segment, "*"):
+			params[segment[1:]] = strings.Join(segments[i:], "/")
+			return params, true
+		case i >= len(segments):
+			return nil, false
+		case strings.HasPrefix(segment, ":"):
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[segment[1:]] = segments[i]
+		case segment != segments[i]:
+			return nil, false
+		}
+	}
+	if len(segments) != len(p.segments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// moreSpecificThan orders overlapping patterns: at the first differing
+// segment a static segment beats a named one, which beats a wildcard.
+func (p *routePattern) moreSpecificThan(other *routePattern) bool {
+	for i := 0; i < len(p.segments) && i < len(other.segments); i++ {
+		mine, theirs := segmentRank(p.segments[i]), segmentRank(other.segments[i])
+		if mine != theirs {
+			return mine > theirs
+		}
+	}
+	return len(p.segments) > len(other.segments)
+}
+
+// segmentRank scores a pattern segment for specificity ordering.
+func segmentRank(segment string) int {
+	switch {
+	case strings.HasPrefix(segment, "*"):
+		return 0
+	case strings.HasPrefix(segment, ":"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// splitPathSegments splits a path into its "/"-separated segments.
+func splitPathSegments(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// hasPatternSegment reports whether any segment is a parameter or wildcard.
+func hasPatternSegment(segments []string) bool {
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			return true
+		}
+	}
+	return false
+}
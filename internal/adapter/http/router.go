@@ -1,9 +1,14 @@
 package http
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // HandlerAdapter adapts a parsed HTTP request into an HTTP response.
@@ -12,17 +17,36 @@ type HandlerAdapter func(*Request) *Response
 // Middleware wraps a handler adapter to provide cross-cutting behavior.
 type Middleware func(HandlerAdapter) HandlerAdapter
 
-// Router maps METHOD:PATH keys to handler adapters.
+// ErrTooManyRoutes is the panic value, wrapped with the cap, raised by
+// Register when a new route would exceed the limit set by SetMaxRoutes.
+var ErrTooManyRoutes = errors.New("router route limit exceeded")
+
+// Router maps METHOD:PATH keys to handler adapters. Paths may contain named
+// segments (/users/:id) and a trailing wildcard (/files/*path).
 type Router struct {
-	mu          sync.RWMutex
-	routes      map[string]HandlerAdapter
-	middlewares []Middleware
+	mu             sync.RWMutex
+	routes         map[string]HandlerAdapter
+	patterns       []routePattern
+	methodHandlers map[string]HandlerAdapter
+	middlewares    []Middleware
+	preRouting     []Middleware
+
+	notFound         HandlerAdapter
+	methodNotAllowed HandlerAdapter
+	bodyTimeouts     map[string]time.Duration
+	cleanPath        CleanPathMode
+	autoOptions      bool
+	autoHead         bool
+	serverOptions    bool
+	maxRoutes        int
+	metrics          *MetricsRegistry
 }
 
 // NewRouter creates an empty router.
 func NewRouter() *Router {
 	return &Router{
-		routes: make(map[string]HandlerAdapter),
+		routes:         make(map[string]HandlerAdapter),
+		methodHandlers: make(map[string]HandlerAdapter),
 	}
 }
 
@@ -33,25 +57,213 @@ func (r *Router) Use(middlewares ...Middleware) {
 	r.middlewares = append(r.middlewares, middlewares...)
 }
 
-// Register maps a method/path pair to a handler adapter.
+// UsePreRouting appends middleware that runs before route resolution, so it
+// may rewrite the request (e.g. its path) that the router then matches.
+func (r *Router) UsePreRouting(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.preRouting = append(r.preRouting, middlewares...)
+}
+
+// Register maps a method/path pair to a handler adapter. A ":name" segment
+// matches any single non-empty segment and a final "*name" segment matches
+// the remainder of the path; matches are exposed through Request.Params.
+// Register panics with ErrTooManyRoutes when a new method/path pair would
+// exceed the SetMaxRoutes cap; replacing an existing route always succeeds.
 func (r *Router) Register(method, path string, handler HandlerAdapter) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.routes[routeKey(method, path)] = handler
+	key := routeKey(method, path)
+	if _, exists := r.routes[key]; !exists && r.maxRoutes > 0 && len(r.routes) >= r.maxRoutes {
+		panic(fmt.Errorf("%w: cap %d reached registering %s", ErrTooManyRoutes, r.maxRoutes, key))
+	}
+	r.routes[key] = handler
+
+	segments := splitPathSegments(path)
+	if !hasPatternSegment(segments) {
+		return
+	}
+	for i := range r.patterns {
+		if r.patterns[i].key == key {
+			r.patterns[i].handler = handler
+			return
+		}
+	}
+	r.patterns = append(r.patterns, routePattern{
+		key:      key,
+		method:   strings.ToUpper(method),
+		segments: segments,
+		handler:  handler,
+	})
+}
+
+// HandleMethodGlobally registers a handler serving every path for method when
+// no exact route matches, e.g. a uniform OPTIONS preflight response.
+func (r *Router) HandleMethodGlobally(method string, handler HandlerAdapter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.methodHandlers[strings.ToUpper(method)] = handler
+}
+
+// SetNotFoundHandler replaces the default 404 response for requests that
+// match no route. The handler runs behind the router middleware. A nil
+// handler restores the default.
+func (r *Router) SetNotFoundHandler(handler HandlerAdapter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notFound = handler
+}
+
+// SetMetricsRegistry records in registry, under the "unmatched" path label,
+// the responses the router answers without running its middleware chain:
+// the default 404 and 405, path-cleaning rejections and redirects, and the
+// 405 for methods outside ServerOptions.AllowedMethods. Pair it with
+// MetricsMiddleware, which records every request that reaches the chain. A
+// nil registry turns this off.
+func (r *Router) SetMetricsRegistry(registry *MetricsRegistry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = registry
+}
+
+// observeUnmatched records resp, answered without the middleware chain since
+// startedAt, in the metrics registry set by SetMetricsRegistry, if any.
+func (r *Router) observeUnmatched(req *Request, resp *Response, startedAt time.Time) *Response {
+	r.mu.RLock()
+	registry := r.metrics
+	r.mu.RUnlock()
+
+	if registry != nil {
+		registry.Observe(requestMethod(req), unmatchedMetricsPath, metricsStatus(resp), SystemClock.Now().Sub(startedAt))
+	}
+	return resp
+}
+
+// SetMethodNotAllowedHandler replaces the default 405 response. The handler
+// runs behind the router middleware and finds the methods registered for the
+// path in Request.AllowedMethods for building its Allow header. A nil
+// handler restores the default.
+func (r *Router) SetMethodNotAllowedHandler(handler HandlerAdapter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.methodNotAllowed = handler
+}
+
+// SetBodyReadTimeout overrides ServerOptions.BodyReadTimeout for one route,
+// using the method and path as registered. Zero disables the body timeout
+// for that route.
+func (r *Router) SetBodyReadTimeout(method, path string, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.bodyTimeouts == nil {
+		r.bodyTimeouts = make(map[string]time.Duration)
+	}
+	r.bodyTimeouts[routeKey(method, path)] = timeout
+}
+
+// SetMaxRoutes caps the number of method/path pairs the router holds, as a
+// guard against unbounded registration from external input. Zero, the
+// default, means no limit. Routes already registered are kept.
+func (r *Router) SetMaxRoutes(limit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxRoutes = limit
+}
+
+// EnableAutoOptions makes the router answer OPTIONS requests for any path
+// with at least one registered method with 204 and an Allow header, unless
+// an OPTIONS handler is registered. It is off by default.
+func (r *Router) EnableAutoOptions(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.autoOptions = enabled
+}
+
+// EnableAutoHead makes the router serve a HEAD request with no HEAD handler
+// through the GET handler for the same path, dropping the body while keeping
+// its Content-Length. It is off by default.
+func (r *Router) EnableAutoHead(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.autoHead = enabled
+}
+
+// EnableServerOptions makes the router answer the asterisk-form request
+// "OPTIONS *" with 204 and an Allow header listing every method registered
+// on any route, plus OPTIONS, and HEAD when EnableAutoHead covers a GET
+// route. Per-path OPTIONS handling is unaffected. It is off by default,
+// leaving "OPTIONS *" to be routed like any other path.
+func (r *Router) EnableServerOptions(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.serverOptions = enabled
+}
+
+// SetCleanPath sets how non-canonical paths such as /users//42 or /a/./b are
+// handled before lookup. Paths whose ".." segments climb above the root are
+// rejected with 400 in any mode other than CleanPathOff.
+func (r *Router) SetCleanPath(mode CleanPathMode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cleanPath = mode
+}
+
+// bodyReadTimeout returns the per-route body read timeout for a request path.
+func (r *Router) bodyReadTimeout(method, path string) (time.Duration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.bodyTimeouts) == 0 {
+		return 0, false
+	}
+	if timeout, ok := r.bodyTimeouts[routeKey(method, path)]; ok {
+		return timeout, true
+	}
+	segments := splitPathSegments(path)
+	method = strings.ToUpper(method)
+	for _, pattern := range r.patterns {
+		if pattern.method != method {
+			continue
+		}
+		if _, ok := pattern.match(segments); !ok {
+			continue
+		}
+		if timeout, ok := r.bodyTimeouts[pattern.key]; ok {
+			return timeout, true
+		}
+	}
+	return 0, false
 }
 
-// Lookup returns the handler adapter for a method/path pair.
+// Lookup returns the handler adapter for a method/path pair, matching
+// parameterized routes the way Resolve does. The handler is returned without
+// middleware and without binding Request.Params.
 func (r *Router) Lookup(method, path string) (HandlerAdapter, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	handler, ok := r.routes[routeKey(method, path)]
+	handler, _, _, ok := r.lookupRoute(method, path)
 	return handler, ok
 }
 
+// Has reports whether a route, static or parameterized, matches a
+// method/path pair without building the middleware chain.
+func (r *Router) Has(method, path string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, _, _, ok := r.lookupRoute(method, path)
+	return ok
+}
+
 // Resolve returns a route handler wrapped with the registered middleware chain.
+// Static routes take priority over parameterized ones, which take priority
+// over handlers registered via HandleMethodGlobally. Parameterized handlers
+// populate Request.Params before the middleware chain runs.
 func (r *Router) Resolve(method, path string) (HandlerAdapter, bool) {
 	r.mu.RLock()
-	handler, ok := r.routes[routeKey(method, path)]
+	handler, route, params, ok := r.lookupRoute(method, path)
+	if !ok {
+		route = ""
+		handler, ok = r.methodHandlers[strings.ToUpper(method)]
+	}
 	if !ok {
 		r.mu.RUnlock()
 		return nil, false
@@ -61,8 +273,282 @@ func (r *Router) Resolve(method, path string) (HandlerAdapter, bool) {
 	copy(middlewares, r.middlewares)
 	r.mu.RUnlock()
 
-	wrapped := applyMiddleware(handler, middlewares)
-	return wrapped, true
+	return withRoute(applyMiddleware(handler, middlewares), route, params), true
+}
+
+// lookupRoute finds the registered route for method and path, static routes
+// first, returning its handler, registered path, and captured params.
+// Callers must hold r.mu.
+func (r *Router) lookupRoute(method, path string) (HandlerAdapter, string, map[string]string, bool) {
+	handler, ok := r.routes[routeKey(method, path)]
+	if ok && strings.ContainsAny(path, ":*") && hasPatternSegment(splitPathSegments(path)) {
+		// A literal request for a pattern path must still bind its params.
+		ok = false
+	}
+	if ok {
+		return handler, path, nil, true
+	}
+	return r.matchPattern(strings.ToUpper(method), path)
+}
+
+// matchPattern finds the most specific parameterized route for method and
+// path, returning its handler, registered pattern, and captured params.
+// Callers must hold r.mu.
+func (r *Router) matchPattern(method, path string) (HandlerAdapter, string, map[string]string, bool) {
+	segments := splitPathSegments(path)
+	var (
+		best       *routePattern
+		bestParams map[string]string
+	)
+	for i := range r.patterns {
+		pattern := &r.patterns[i]
+		if method != "" && pattern.method != method {
+			continue
+		}
+		params, ok := pattern.match(segments)
+		if !ok {
+			continue
+		}
+		if best == nil || pattern.moreSpecificThan(best) {
+			best, bestParams = pattern, params
+		}
+	}
+	if best == nil {
+		return nil, "", nil, false
+	}
+	_, route, _ := strings.Cut(best.key, ":")
+	return best.handler, route, bestParams, true
+}
+
+// ServeRequest runs pre-routing middleware, resolves the request, and returns
+// the handler response, or a 404/405 response when no route matches.
+func (r *Router) ServeRequest(req *Request) *Response {
+	r.mu.RLock()
+	preRouting := make([]Middleware, len(r.preRouting))
+	copy(preRouting, r.preRouting)
+	r.mu.RUnlock()
+
+	return safeInvoke(applyMiddleware(r.route, preRouting), req)
+}
+
+// route resolves a request to its handler and invokes it.
+func (r *Router) route(req *Request) *Response {
+	startedAt := SystemClock.Now()
+	req = withRoutingTarget(req)
+	if requestPath(req) == "*" && requestMethod(req) == "OPTIONS" && r.serverOptionsEnabled() {
+		return r.serveServerOptions(req)
+	}
+	req, resp := r.withCleanPath(req)
+	if resp != nil {
+		return r.observeUnmatched(req, withoutBodyForHead(req, resp), startedAt)
+	}
+	handler, ok := r.Resolve(requestMethod(req), requestPath(req))
+	if (!ok || handler == nil) && strings.EqualFold(requestMethod(req), "HEAD") && r.autoHeadEnabled() {
+		if getHandler, found := r.Resolve("GET", requestPath(req)); found && getHandler != nil {
+			return headResponse(safeInvoke(getHandler, req))
+		}
+	}
+	if !ok || handler == nil {
+		allowed := r.AllowedMethods(requestPath(req))
+		if len(allowed) > 0 {
+			if strings.EqualFold(requestMethod(req), "OPTIONS") && r.autoOptionsEnabled() {
+				return r.serveAutoOptions(req, allowed)
+			}
+			return r.serveMethodNotAllowed(req, allowed, startedAt)
+		}
+		return r.serveNotFound(req, startedAt)
+	}
+	return handler(req)
+}
+
+// serveNotFound answers a request that matches no route, using the custom
+// handler when one is set. The default response is recorded as unmatched
+// since startedAt.
+func (r *Router) serveNotFound(req *Request, startedAt time.Time) *Response {
+	r.mu.RLock()
+	handler := r.notFound
+	r.mu.RUnlock()
+
+	if handler == nil {
+		return r.observeUnmatched(req, withoutBodyForHead(req, notFoundResponse()), startedAt)
+	}
+	return withoutBodyForHead(req, safeInvoke(r.withMiddleware(handler), req))
+}
+
+// serveMethodNotAllowed answers a request whose path exists under other
+// methods, using the custom handler when one is set. The default response is
+// recorded as unmatched since startedAt.
+func (r *Router) serveMethodNotAllowed(req *Request, allowed []string, startedAt time.Time) *Response {
+	r.mu.RLock()
+	handler := r.methodNotAllowed
+	r.mu.RUnlock()
+
+	if handler == nil {
+		return r.observeUnmatched(req, withoutBodyForHead(req, methodNotAllowedResponse(allowed)), startedAt)
+	}
+	withAllowed := withRequestContext(req, requestContext(req))
+	withAllowed.AllowedMethods = allowed
+	return withoutBodyForHead(req, safeInvoke(r.withMiddleware(handler), withAllowed))
+}
+
+// withMiddleware wraps handler in a snapshot of the router middleware chain.
+func (r *Router) withMiddleware(handler HandlerAdapter) HandlerAdapter {
+	r.mu.RLock()
+	middlewares := make([]Middleware, len(r.middlewares))
+	copy(middlewares, r.middlewares)
+	r.mu.RUnlock()
+
+	return applyMiddleware(handler, middlewares)
+}
+
+// autoOptionsEnabled reports whether EnableAutoOptions is on.
+func (r *Router) autoOptionsEnabled() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.autoOptions
+}
+
+// serverOptionsEnabled reports whether EnableServerOptions is on.
+func (r *Router) serverOptionsEnabled() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.serverOptions
+}
+
+// autoHeadEnabled reports whether EnableAutoHead is on.
+func (r *Router) autoHeadEnabled() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.autoHead
+}
+
+// headResponse strips the body from a GET response served for HEAD, pinning
+// Content-Length to the size the GET body would have had.
+func headResponse(resp *Response) *Response {
+	if resp.Raw != nil {
+		if end := bytes.Index(resp.Raw, []byte("\r\n\r\n")); end >= 0 {
+			resp.Raw = resp.Raw[:end+4]
+		}
+		return resp
+	}
+	if !hasHeaderIgnoreCase(resp.Headers, "Content-Length") {
+		resp.SetHeader("Content-Length", strconv.Itoa(len(resp.Body)))
+	}
+	resp.Body = nil
+	return resp
+}
+
+// serveAutoOptions answers an OPTIONS request with 204 and an Allow header
+// listing allowed plus OPTIONS, running the router middleware chain.
+func (r *Router) serveAutoOptions(req *Request, allowed []string) *Response {
+	methods := append([]string{"OPTIONS"}, allowed...)
+	sort.Strings(methods)
+	handler := func(*Request) *Response {
+		return NewResponse().Status(204).Header("Allow", strings.Join(methods, ", "))
+	}
+	return safeInvoke(r.withMiddleware(handler), req)
+}
+
+// serveServerOptions answers "OPTIONS *" with 204 and an Allow header
+// listing the distinct methods of all routes, running the router middleware
+// chain.
+func (r *Router) serveServerOptions(req *Request) *Response {
+	r.mu.RLock()
+	seen := map[string]struct{}{"OPTIONS": {}}
+	for key := range r.routes {
+		if method, _, ok := strings.Cut(key, ":"); ok && method != "" {
+			seen[method] = struct{}{}
+		}
+	}
+	for method := range r.methodHandlers {
+		seen[method] = struct{}{}
+	}
+	if _, ok := seen["GET"]; ok && r.autoHead {
+		seen["HEAD"] = struct{}{}
+	}
+	r.mu.RUnlock()
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	handler := func(*Request) *Response {
+		return NewResponse().Status(204).Header("Allow", strings.Join(methods, ", "))
+	}
+	return safeInvoke(r.withMiddleware(handler), req)
+}
+
+// withCleanPath applies the router's CleanPathMode, returning either the
+// request to route or a response that ends routing (400 or 301).
+func (r *Router) withCleanPath(req *Request) (*Request, *Response) {
+	r.mu.RLock()
+	mode := r.cleanPath
+	r.mu.RUnlock()
+
+	if mode == CleanPathOff || req == nil {
+		return req, nil
+	}
+	cleaned, err := cleanPath(req.Path)
+	if err != nil {
+		return req, statusResponse(400)
+	}
+	if cleaned == req.Path {
+		return req, nil
+	}
+
+	method := requestMethod(req)
+	if mode == CleanPathRedirect && (method == "GET" || method == "HEAD") {
+		return req, redirectResponse(301, cleanedLocation(req, cleaned))
+	}
+	rewritten := *req
+	rewritten.Path = cleaned
+	return &rewritten, nil
+}
+
+// cleanedLocation builds the redirect target for a cleaned path, preferring
+// the cleaned raw path so percent-encoding survives, and keeping the query.
+func cleanedLocation(req *Request, cleaned string) string {
+	location := cleaned
+	if req.RawPath != "" {
+		if cleanedRaw, err := cleanPath(req.RawPath); err == nil {
+			location = cleanedRaw
+		}
+	}
+	if req.RawQuery != "" {
+		location += "?" + req.RawQuery
+	}
+	return location
+}
+
+// redirectResponse builds a redirect with a Location header.
+func redirectResponse(status int, location string) *Response {
+	resp := statusResponse(status)
+	resp.SetHeader("Location", location)
+	return resp
+}
+
+// withRoutingTarget moves a query string left in req.Path into RawQuery and
+// Query, and maps an empty path to "/", so routes match on the path alone.
+func withRoutingTarget(req *Request) *Request {
+	if req == nil {
+		return nil
+	}
+	path, rawQuery, hasQuery := strings.Cut(req.Path, "?")
+	if !hasQuery && path != "" {
+		return req
+	}
+
+	cloned := *req
+	cloned.Path = path
+	if cloned.Path == "" {
+		cloned.Path = "/"
+	}
+	if hasQuery && cloned.RawQuery == "" && rawQuery != "" {
+		cloned.RawQuery = rawQuery
+		cloned.Query, _ = ParseQuery(rawQuery, false)
+	}
+	return &cloned
 }
 
 // AllowedMethods returns sorted HTTP methods registered for a path.
@@ -80,6 +566,12 @@ func (r *Router) AllowedMethods(path string) []string {
 			}
 		}
 	}
+	segments := splitPathSegments(path)
+	for _, pattern := range r.patterns {
+		if _, ok := pattern.match(segments); ok {
+			seen[pattern.method] = struct{}{}
+		}
+	}
 
 	methods := make([]string, 0, len(seen))
 	for method := range seen {
@@ -89,6 +581,19 @@ func (r *Router) AllowedMethods(path string) []string {
 	return methods
 }
 
+// withRoute clones each request with the matched route pattern and params
+// before invoking handler.
+func withRoute(handler HandlerAdapter, route string, params map[string]string) HandlerAdapter {
+	return func(req *Request) *Response {
+		cloned := withRequestContext(req, requestContext(req))
+		cloned.Route = route
+		if params != nil {
+			cloned.Params = params
+		}
+		return handler(cloned)
+	}
+}
+
 // applyMiddleware wraps a handler with middlewares from outermost to innermost.
 func applyMiddleware(handler HandlerAdapter, middlewares []Middleware) HandlerAdapter {
 	wrapped := handler
@@ -1,7 +1,9 @@
 package http
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -122,3 +124,436 @@ func TestRouter_AllowedMethods(t *testing.T) {
 		t.Fatalf("unexpected allowed methods: got %v, want %v", got, want)
 	}
 }
+
+// TestRouter_HandleMethodGlobally verifies a global method handler serves any path.
+func TestRouter_HandleMethodGlobally(t *testing.T) {
+	router := NewRouter()
+	router.HandleMethodGlobally("options", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.StatusCode = 204
+		resp.SetHeader("Access-Control-Allow-Origin", "*")
+		return resp
+	})
+	router.Register("OPTIONS", "/special", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString("special")
+		return resp
+	})
+
+	for _, path := range []string{"/", "/users", "/a/b/c"} {
+		handler, ok := router.Resolve("OPTIONS", path)
+		if !ok || handler == nil {
+			t.Fatalf("expected global OPTIONS handler for %s", path)
+		}
+		resp := handler(&Request{Method: "OPTIONS", Path: path})
+		if resp.StatusCode != 204 {
+			t.Fatalf("expected status 204 for %s, got %d", path, resp.StatusCode)
+		}
+	}
+
+	handler, ok := router.Resolve("OPTIONS", "/special")
+	if !ok {
+		t.Fatalf("expected exact OPTIONS route")
+	}
+	if resp := handler(&Request{Method: "OPTIONS", Path: "/special"}); string(resp.Body) != "special" {
+		t.Fatalf("expected exact route to take priority, got %q", string(resp.Body))
+	}
+
+	if _, ok := router.Resolve("GET", "/users"); ok {
+		t.Fatalf("did not expect GET to resolve via the OPTIONS global handler")
+	}
+}
+
+// TestRouter_Has verifies existence checks for registered and unknown routes.
+func TestRouter_Has(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/users", func(req *Request) *Response { return NewResponse() })
+
+	if !router.Has("get", "/users") {
+		t.Fatalf("expected GET:/users to be registered")
+	}
+	if router.Has("POST", "/users") {
+		t.Fatalf("did not expect POST:/users to be registered")
+	}
+	if router.Has("GET", "/missing") {
+		t.Fatalf("did not expect GET:/missing to be registered")
+	}
+}
+
+// TestRouter_HasAndLookupMatchPatterns verifies parameterized routes are found for concrete paths.
+func TestRouter_HasAndLookupMatchPatterns(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/users/:id", func(req *Request) *Response { return NewResponse().WriteString("user") })
+
+	if !router.Has("GET", "/users/42") {
+		t.Fatalf("expected GET:/users/42 to match /users/:id")
+	}
+	if router.Has("POST", "/users/42") || router.Has("GET", "/users/42/posts") {
+		t.Fatalf("did not expect other methods or deeper paths to match /users/:id")
+	}
+	handler, ok := router.Lookup("GET", "/users/42")
+	if !ok || string(handler(&Request{Method: "GET", Path: "/users/42"}).Body) != "user" {
+		t.Fatalf("expected Lookup to return the /users/:id handler")
+	}
+}
+
+// TestRouter_ServeRequestIgnoresQueryString verifies routes match on the path with the query exposed to handlers.
+func TestRouter_ServeRequestIgnoresQueryString(t *testing.T) {
+	router := NewRouter()
+	var gotQuery string
+	router.Register("GET", "/health", func(req *Request) *Response {
+		gotQuery = req.RawQuery
+		return NewResponse()
+	})
+	router.Register("GET", "/", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString("root")
+		return resp
+	})
+
+	resp := router.ServeRequest(&Request{Method: "GET", Path: "/health?foo=bar"})
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 for /health?foo=bar, got %d", resp.StatusCode)
+	}
+	if gotQuery != "foo=bar" {
+		t.Fatalf("expected raw query foo=bar, got %q", gotQuery)
+	}
+
+	for _, path := range []string{"?", ""} {
+		resp := router.ServeRequest(&Request{Method: "GET", Path: path})
+		if string(resp.Body) != "root" {
+			t.Fatalf("expected path %q to route to /, got %d %q", path, resp.StatusCode, string(resp.Body))
+		}
+	}
+}
+
+// TestRouter_PathParameters verifies named segments and trailing wildcards populate Params.
+func TestRouter_PathParameters(t *testing.T) {
+	router := NewRouter()
+	var got map[string]string
+	capture := func(req *Request) *Response {
+		got = req.Params
+		return NewResponse()
+	}
+	router.Register("GET", "/users/:id", capture)
+	router.Register("GET", "/files/*path", capture)
+
+	tests := []struct {
+		path string
+		want map[string]string
+	}{
+		{path: "/users/42", want: map[string]string{"id": "42"}},
+		{path: "/files/a/b/c", want: map[string]string{"path": "a/b/c"}},
+	}
+	for _, tt := range tests {
+		got = nil
+		resp := router.ServeRequest(&Request{Method: "GET", Path: tt.path})
+		if resp.StatusCode != 200 {
+			t.Fatalf("expected 200 for %s, got %d", tt.path, resp.StatusCode)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("expected params %v for %s, got %v", tt.want, tt.path, got)
+		}
+	}
+
+	for _, path := range []string{"/users", "/users/", "/users/42/extra"} {
+		if resp := router.ServeRequest(&Request{Method: "GET", Path: path}); resp.StatusCode != 404 {
+			t.Fatalf("expected 404 for %s, got %d", path, resp.StatusCode)
+		}
+	}
+	if allowed := router.AllowedMethods("/users/7"); !reflect.DeepEqual(allowed, []string{"GET"}) {
+		t.Fatalf("expected pattern methods in Allow, got %v", allowed)
+	}
+}
+
+// TestRouter_StaticRoutesBeatParameters verifies /users/new wins over /users/:id.
+func TestRouter_StaticRoutesBeatParameters(t *testing.T) {
+	router := NewRouter()
+	named := func(name string) HandlerAdapter {
+		return func(req *Request) *Response {
+			resp := NewResponse()
+			resp.WriteString(name + " " + req.Params["id"] + req.Params["rest"])
+			return resp
+		}
+	}
+	router.Register("GET", "/users/:id", named("param"))
+	router.Register("GET", "/users/new", named("static"))
+	router.Register("GET", "/users/*rest", named("wildcard"))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/users/new", want: "static "},
+		{path: "/users/42", want: "param 42"},
+		{path: "/users/42/posts", want: "wildcard 42/posts"},
+	}
+	for _, tt := range tests {
+		resp := router.ServeRequest(&Request{Method: "GET", Path: tt.path})
+		if string(resp.Body) != tt.want {
+			t.Fatalf("expected %q for %s, got %q", tt.want, tt.path, string(resp.Body))
+		}
+	}
+}
+
+// TestRouter_CustomMethodNotAllowedReceivesAllowedMethods verifies the custom 405 handler sees the allowed methods.
+func TestRouter_CustomMethodNotAllowedReceivesAllowedMethods(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/users", func(req *Request) *Response { return NewResponse() })
+	router.Register("POST", "/users", func(req *Request) *Response { return NewResponse() })
+
+	var got []string
+	router.SetMethodNotAllowedHandler(func(req *Request) *Response {
+		got = req.AllowedMethods
+		resp := JSONError(req, 405, "")
+		resp.SetHeader("Allow", strings.Join(req.AllowedMethods, ", "))
+		return resp
+	})
+
+	resp := router.ServeRequest(&Request{Method: "DELETE", Path: "/users"})
+	if resp.StatusCode != 405 {
+		t.Fatalf("expected 405, got %d", resp.StatusCode)
+	}
+	if !reflect.DeepEqual(got, []string{"GET", "POST"}) {
+		t.Fatalf("expected allowed methods [GET POST], got %v", got)
+	}
+	if resp.Headers["Allow"] != "GET, POST" {
+		t.Fatalf("expected custom Allow header, got %q", resp.Headers["Allow"])
+	}
+	if resp.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("expected custom JSON response, got %#v", resp.Headers)
+	}
+}
+
+// TestRouter_CleanPath verifies duplicate slashes and dot segments are normalized before lookup.
+func TestRouter_CleanPath(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         CleanPathMode
+		method       string
+		path         string
+		rawQuery     string
+		wantStatus   int
+		wantBody     string
+		wantLocation string
+	}{
+		{name: "duplicate slashes rewritten", mode: CleanPathRewrite, method: "GET", path: "/users//42", wantStatus: 200, wantBody: "user 42"},
+		{name: "dot segments rewritten", mode: CleanPathRewrite, method: "GET", path: "/users/./7/../42", wantStatus: 200, wantBody: "user 42"},
+		{name: "duplicate slashes redirected", mode: CleanPathRedirect, method: "GET", path: "/users//42", rawQuery: "v=1", wantStatus: 301, wantLocation: "/users/42?v=1"},
+		{name: "dot segment redirected for HEAD", mode: CleanPathRedirect, method: "HEAD", path: "/users/./42", wantStatus: 301, wantLocation: "/users/42"},
+		{name: "redirect mode rewrites POST", mode: CleanPathRedirect, method: "POST", path: "//users//42", wantStatus: 200, wantBody: "created 42"},
+		{name: "traversal above root rejected", mode: CleanPathRewrite, method: "GET", path: "/users/../../etc", wantStatus: 400},
+		{name: "off keeps exact matching", mode: CleanPathOff, method: "GET", path: "/users//42", wantStatus: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter()
+			router.SetCleanPath(tt.mode)
+			router.Register("GET", "/users/:id", func(req *Request) *Response {
+				resp := NewResponse()
+				resp.WriteString("user " + req.Params["id"])
+				return resp
+			})
+			router.Register("POST", "/users/:id", func(req *Request) *Response {
+				resp := NewResponse()
+				resp.WriteString("created " + req.Params["id"])
+				return resp
+			})
+
+			resp := router.ServeRequest(&Request{Method: tt.method, Path: tt.path, RawPath: tt.path, RawQuery: tt.rawQuery})
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantBody != "" && string(resp.Body) != tt.wantBody {
+				t.Fatalf("expected body %q, got %q", tt.wantBody, string(resp.Body))
+			}
+			if resp.Headers["Location"] != tt.wantLocation {
+				t.Fatalf("expected Location %q, got %q", tt.wantLocation, resp.Headers["Location"])
+			}
+		})
+	}
+}
+
+// TestCleanPath verifies canonicalization and rejection of paths above root.
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "/", want: "/"},
+		{in: "//a///b", want: "/a/b"},
+		{in: "/a/./b/", want: "/a/b/"},
+		{in: "/a/b/..", want: "/a/"},
+		{in: "/a/..", want: "/"},
+		{in: "/..", wantErr: true},
+		{in: "/a/../../b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := cleanPath(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("cleanPath(%q): expected error, got %q", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Fatalf("cleanPath(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// TestRouter_AutoOptions verifies OPTIONS is answered from registered methods only when enabled.
+func TestRouter_AutoOptions(t *testing.T) {
+	handler := func(req *Request) *Response { return NewResponse() }
+	router := NewRouter()
+	router.Register("GET", "/users", handler)
+	router.Register("POST", "/users", handler)
+
+	if resp := router.ServeRequest(&Request{Method: "OPTIONS", Path: "/users"}); resp.StatusCode != 405 {
+		t.Fatalf("expected 405 while auto OPTIONS is disabled, got %d", resp.StatusCode)
+	}
+
+	router.EnableAutoOptions(true)
+	resp := router.ServeRequest(&Request{Method: "OPTIONS", Path: "/users"})
+	if resp.StatusCode != 204 {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	if got := resp.Headers["Allow"]; got != "GET, OPTIONS, POST" {
+		t.Fatalf("expected Allow %q, got %q", "GET, OPTIONS, POST", got)
+	}
+	if len(resp.Body) != 0 {
+		t.Fatalf("expected empty body, got %q", string(resp.Body))
+	}
+
+	if resp := router.ServeRequest(&Request{Method: "OPTIONS", Path: "/missing"}); resp.StatusCode != 404 {
+		t.Fatalf("expected 404 for OPTIONS on an unregistered path, got %d", resp.StatusCode)
+	}
+}
+
+// TestRouter_ServerOptions verifies "OPTIONS *" on the wire aggregates every registered method when enabled.
+func TestRouter_ServerOptions(t *testing.T) {
+	handler := func(req *Request) *Response { return NewResponse() }
+	router := NewRouter()
+	router.Register("GET", "/users", handler)
+	router.Register("POST", "/users", handler)
+	router.Register("DELETE", "/users/:id", handler)
+	router.HandleMethodGlobally("PATCH", handler)
+	router.EnableAutoHead(true)
+	request := "OPTIONS * HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+
+	if resp := serveStreamRequest(t, router, ServerOptions{}, request); !strings.HasPrefix(resp, "HTTP/1.1 404 ") {
+		t.Fatalf("expected 404 while server-wide OPTIONS is disabled, got %q", resp)
+	}
+
+	router.EnableServerOptions(true)
+	resp := serveStreamRequest(t, router, ServerOptions{}, request)
+	if !strings.HasPrefix(resp, "HTTP/1.1 204 No Content\r\n") {
+		t.Fatalf("expected 204, got %q", resp)
+	}
+	if want := "Allow: DELETE, GET, HEAD, OPTIONS, PATCH, POST\r\n"; !strings.Contains(resp, want) {
+		t.Fatalf("expected %q, got %q", want, resp)
+	}
+}
+
+// TestRouter_AutoHead verifies HEAD falls back to the GET handler without a body when enabled.
+func TestRouter_AutoHead(t *testing.T) {
+	var calls []string
+	router := NewRouter()
+	router.Use(func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			calls = append(calls, req.Method)
+			resp := next(req)
+			resp.SetHeader("X-Middleware", "ran")
+			return resp
+		}
+	})
+	router.Register("GET", "/hello", func(req *Request) *Response {
+		return NewResponse().Header("Content-Type", "text/plain").WriteString("hello")
+	})
+
+	if resp := router.ServeRequest(&Request{Method: "HEAD", Path: "/hello"}); resp.StatusCode != 405 {
+		t.Fatalf("expected 405 while auto HEAD is disabled, got %d", resp.StatusCode)
+	}
+
+	router.EnableAutoHead(true)
+	resp := router.ServeRequest(&Request{Method: "HEAD", Path: "/hello"})
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if len(resp.Body) != 0 {
+		t.Fatalf("expected empty body, got %q", string(resp.Body))
+	}
+	wire := string(resp.Bytes())
+	if !strings.Contains(wire, "Content-Length: 5\r\n") || !strings.HasSuffix(wire, "\r\n\r\n") {
+		t.Fatalf("expected Content-Length: 5 and no body on the wire, got %q", wire)
+	}
+	if resp.Headers["X-Middleware"] != "ran" || resp.Headers["Content-Type"] != "text/plain" {
+		t.Fatalf("expected middleware and handler headers, got %#v", resp.Headers)
+	}
+	if !reflect.DeepEqual(calls, []string{"HEAD"}) {
+		t.Fatalf("expected middleware to run once for the HEAD request, got %v", calls)
+	}
+}
+
+// TestRouter_CustomNotFoundAndMethodNotAllowedRunMiddleware verifies both custom handlers run behind middleware.
+func TestRouter_CustomNotFoundAndMethodNotAllowedRunMiddleware(t *testing.T) {
+	router := NewRouter()
+	router.Use(func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			return next(req).Header("X-Brand", "light_serve")
+		}
+	})
+	router.Register("GET", "/users", func(req *Request) *Response { return NewResponse() })
+	router.SetNotFoundHandler(func(req *Request) *Response {
+		return JSONError(req, 404, "no route for "+req.Path)
+	})
+	router.SetMethodNotAllowedHandler(func(req *Request) *Response {
+		return JSONError(req, 405, "").Header("Allow", strings.Join(req.AllowedMethods, ", "))
+	})
+
+	notFound := router.ServeRequest(&Request{Method: "GET", Path: "/missing"})
+	if notFound.StatusCode != 404 || !strings.Contains(string(notFound.Body), "no route for /missing") {
+		t.Fatalf("expected custom 404 body, got %d %q", notFound.StatusCode, string(notFound.Body))
+	}
+	if notFound.Headers["X-Brand"] != "light_serve" {
+		t.Fatalf("expected middleware to wrap the 404 handler, got %#v", notFound.Headers)
+	}
+
+	notAllowed := router.ServeRequest(&Request{Method: "DELETE", Path: "/users"})
+	if notAllowed.StatusCode != 405 || notAllowed.Headers["Allow"] != "GET" {
+		t.Fatalf("expected custom 405 with Allow GET, got %d %#v", notAllowed.StatusCode, notAllowed.Headers)
+	}
+	if notAllowed.Headers["X-Brand"] != "light_serve" {
+		t.Fatalf("expected middleware to wrap the 405 handler, got %#v", notAllowed.Headers)
+	}
+
+	router.SetNotFoundHandler(nil)
+	if resp := router.ServeRequest(&Request{Method: "GET", Path: "/missing"}); string(resp.Body) != "Not Found" {
+		t.Fatalf("expected default 404 after clearing the handler, got %q", string(resp.Body))
+	}
+}
+
+// TestRouter_MaxRoutesGuardsRegistration verifies registering past the cap panics while replacements succeed.
+func TestRouter_MaxRoutesGuardsRegistration(t *testing.T) {
+	router := NewRouter()
+	router.SetMaxRoutes(2)
+	handler := func(req *Request) *Response { return NewResponse() }
+	router.Register("GET", "/a", handler)
+	router.Register("GET", "/users/:id", handler)
+	router.Register("GET", "/a", handler)
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, ErrTooManyRoutes) {
+			t.Fatalf("expected ErrTooManyRoutes panic, got %v", err)
+		}
+		if router.Has("GET", "/b") {
+			t.Fatalf("expected rejected route to stay unregistered")
+		}
+	}()
+	router.Register("GET", "/b", handler)
+}
@@ -0,0 +1,46 @@
+package http
+
+import "testing"
+
+// TestSecurityHeadersMiddleware_HSTSOnlyOverTLS verifies HSTS is added for TLS requests only and handler headers win.
+func TestSecurityHeadersMiddleware_HSTSOnlyOverTLS(t *testing.T) {
+	handler := SecurityHeadersMiddleware(DefaultSecurityHeadersOptions())(func(req *Request) *Response {
+		return NewResponse().Header("X-Frame-Options", "SAMEORIGIN")
+	})
+
+	secure := handler(&Request{Method: "GET", Path: "/", TLS: &TLSInfo{Version: "TLS 1.3"}})
+	if got := secure.Headers["Strict-Transport-Security"]; got != "max-age=31536000; includeSubDomains" {
+		t.Fatalf("expected HSTS over TLS, got %q", got)
+	}
+	if got := secure.Headers["X-Content-Type-Options"]; got != "nosniff" {
+		t.Fatalf("expected nosniff, got %q", got)
+	}
+	if got := secure.Headers["X-Frame-Options"]; got != "SAMEORIGIN" {
+		t.Fatalf("expected the handler's X-Frame-Options to be kept, got %q", got)
+	}
+
+	plain := handler(&Request{Method: "GET", Path: "/"})
+	if got, ok := plain.Headers["Strict-Transport-Security"]; ok {
+		t.Fatalf("expected no HSTS over plain HTTP, got %q", got)
+	}
+	if plain.Headers["Content-Security-Policy"] != "default-src 'self'" {
+		t.Fatalf("expected the other headers over plain HTTP, got %v", plain.Headers)
+	}
+}
+
+// TestSecurityHeadersMiddleware_EmptyValuesOmitted verifies unset options add no headers.
+func TestSecurityHeadersMiddleware_EmptyValuesOmitted(t *testing.T) {
+	handler := SecurityHeadersMiddleware(SecurityHeadersOptions{ContentTypeOptions: "nosniff"})(func(req *Request) *Response {
+		return NewResponse()
+	})
+
+	resp := handler(&Request{Method: "GET", Path: "/", TLS: &TLSInfo{}})
+	for _, name := range []string{"X-Frame-Options", "Referrer-Policy", "Content-Security-Policy", "Strict-Transport-Security"} {
+		if _, ok := resp.Headers[name]; ok {
+			t.Fatalf("expected %s to be omitted, got %v", name, resp.Headers)
+		}
+	}
+	if resp.Headers["X-Content-Type-Options"] != "nosniff" {
+		t.Fatalf("expected the configured header, got %v", resp.Headers)
+	}
+}
@@ -0,0 +1,295 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errInvalidRange reports a Range header that cannot be satisfied.
+var errInvalidRange = errors.New("invalid range")
+
+// byteRange is an inclusive-exclusive span [start, end) of the content.
+type byteRange struct {
+	start, end int
+}
+
+// contentRange formats r as a Content-Range value for content of size bytes.
+func (r byteRange) contentRange(size int) string {
+	return "bytes " + strconv.Itoa(r.start) + "-" + strconv.Itoa(r.end-1) + "/" + strconv.Itoa(size)
+}
+
+// ServeContent answers req with content the way net/http.ServeContent does
+// for a file. It sets Content-Type (application/octet-stream when empty),
+// Accept-Ranges, a strong ETag derived from content, and Last-Modified
+// unless modTime is zero. If-None-Match and If-Modified-Since yield 304 for
+// GET and HEAD, If-None-Match yields 412 for other methods, and a Range
+// header on GET yields 206 with one part or a multipart/byteranges body, or
+// 416 when no range overlaps the content. If-Range falls back to the full
+// content when the validator has changed.
+func ServeContent(req *Request, contentType string, modTime time.Time, content []byte) *Response {
+	return serveContent(req, contentType, modTime, contentETag(content), len(content),
+		func(resp *Response, contentType string, ranges []byteRange, boundary string) *Response {
+			body := content
+			if ranges != nil {
+				var buf bytes.Buffer
+				_ = writeRanges(&buf, bytes.NewReader(content), len(content), contentType, ranges, boundary)
+				body = buf.Bytes()
+			}
+			resp.SetHeader("Content-Length", strconv.Itoa(len(body)))
+			resp.Body = body
+			return withoutBodyForHead(req, resp)
+		})
+}
+
+// contentWriter fills resp, whose status and Content-Type are set, with the
+// selected ranges of the content, or all of it when ranges is nil. Several
+// ranges form a multipart/byteranges body separated by boundary, each part
+// typed contentType.
+type contentWriter func(resp *Response, contentType string, ranges []byteRange, boundary string) *Response
+
+// serveContent implements ServeContent for content of size bytes validated
+// by etag, leaving the body to write.
+func serveContent(req *Request, contentType string, modTime time.Time, etag string, size int, write contentWriter) *Response {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	modTime = modTime.UTC().Truncate(time.Second)
+
+	resp := NewResponse()
+	resp.SetHeader("Accept-Ranges", "bytes")
+	resp.SetHeader("ETag", etag)
+	if !modTime.IsZero() {
+		resp.SetHeader("Last-Modified", modTime.Format(httpDateFormat))
+	}
+
+	method := requestMethod(req)
+	switch checkPreconditions(req, method, etag, modTime) {
+	case 304:
+		// A 304 carries no body but may state the length a 200 would have.
+		resp.StatusCode = 304
+		resp.SetHeader("Content-Length", strconv.Itoa(size))
+		return resp
+	case 412:
+		resp.StatusCode = 412
+		resp.SetHeader("Content-Type", "text/plain")
+		resp.WriteString(statusText(412))
+		return withoutBodyForHead(req, resp)
+	}
+
+	resp.StatusCode = 200
+	resp.SetHeader("Content-Type", contentType)
+	rawRange := requestHeader(req, "range")
+	if rawRange == "" || method != "GET" || !ifRangeMatches(req, etag, modTime) {
+		return write(resp, contentType, nil, "")
+	}
+
+	ranges, err := parseRange(rawRange, size)
+	if err != nil {
+		resp.StatusCode = 416
+		resp.SetHeader("Content-Range", "bytes */"+strconv.Itoa(size))
+		resp.SetHeader("Content-Type", "text/plain")
+		resp.WriteString(statusText(416))
+		return withoutBodyForHead(req, resp)
+	}
+	if rangesLength(ranges) > size {
+		// Overlapping ranges asking for more than the content are served
+		// whole rather than amplified.
+		return write(resp, contentType, nil, "")
+	}
+	resp.StatusCode = 206
+	if len(ranges) == 1 {
+		resp.SetHeader("Content-Range", ranges[0].contentRange(size))
+		return write(resp, contentType, ranges, "")
+	}
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	resp.SetHeader("Content-Type", "multipart/byteranges; boundary="+boundary)
+	return write(resp, contentType, ranges, boundary)
+}
+
+// writeRanges copies ranges of src, which holds size bytes, to w: a single
+// range as is and several as multipart/byteranges parts. Content shorter
+// than size is reported as io.ErrUnexpectedEOF.
+func writeRanges(w io.Writer, src io.ReaderAt, size int, contentType string, ranges []byteRange, boundary string) error {
+	if len(ranges) == 1 {
+		return copyRange(w, src, ranges[0])
+	}
+	parts := multipart.NewWriter(w)
+	if err := parts.SetBoundary(boundary); err != nil {
+		return err
+	}
+	for _, r := range ranges {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {contentType},
+			"Content-Range": {r.contentRange(size)},
+		})
+		if err != nil {
+			return err
+		}
+		if err := copyRange(part, src, r); err != nil {
+			return err
+		}
+	}
+	return parts.Close()
+}
+
+// copyRange copies the bytes of r from src to w.
+func copyRange(w io.Writer, src io.ReaderAt, r byteRange) error {
+	length := int64(r.end - r.start)
+	_, err := io.CopyN(w, io.NewSectionReader(src, int64(r.start), length), length)
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// contentETag returns a strong ETag for content.
+func contentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// checkPreconditions evaluates If-None-Match, falling back to
+// If-Modified-Since when absent, and returns the status to answer with, or
+// zero to serve the content.
+func checkPreconditions(req *Request, method, etag string, modTime time.Time) int {
+	if ifNoneMatch := requestHeader(req, "if-none-match"); ifNoneMatch != "" {
+		if !etagListMatches(ifNoneMatch, etag) {
+			return 0
+		}
+		if method == "GET" || method == "HEAD" {
+			return 304
+		}
+		return 412
+	}
+	if (method != "GET" && method != "HEAD") || modTime.IsZero() {
+		return 0
+	}
+	since, ok := parseHTTPDate(requestHeader(req, "if-modified-since"))
+	if ok && !modTime.After(since) {
+		return 304
+	}
+	return 0
+}
+
+// ifRangeMatches reports whether an If-Range validator, if any, still
+// matches, so the Range header applies. Only strong ETags match.
+func ifRangeMatches(req *Request, etag string, modTime time.Time) bool {
+	ifRange := requestHeader(req, "if-range")
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) {
+		return ifRange == etag
+	}
+	since, ok := parseHTTPDate(ifRange)
+	return ok && !modTime.IsZero() && modTime.Equal(since)
+}
+
+// etagListMatches reports whether a comma-separated If-None-Match list
+// holds "*" or etag, comparing weakly.
+func etagListMatches(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHTTPDate parses an HTTP-date in IMF-fixdate or one of the obsolete
+// RFC 850 and asctime formats.
+func parseHTTPDate(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{httpDateFormat, time.RFC850, time.ANSIC} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseRange parses a "bytes=" Range header against content of size bytes.
+// Ranges starting past the end are dropped; errInvalidRange reports a
+// malformed header or one with no satisfiable range.
+func parseRange(raw string, size int) ([]byteRange, error) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(raw), "bytes=")
+	if !ok {
+		return nil, errInvalidRange
+	}
+	var ranges []byteRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, errInvalidRange
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		var r byteRange
+		if first == "" {
+			// Suffix range: the final n bytes.
+			n, err := strconv.Atoi(last)
+			if err != nil || n < 0 {
+				return nil, errInvalidRange
+			}
+			if n == 0 {
+				continue
+			}
+			r = byteRange{start: max(size-n, 0), end: size}
+		} else {
+			start, err := strconv.Atoi(first)
+			if err != nil || start < 0 {
+				return nil, errInvalidRange
+			}
+			end := size
+			if last != "" {
+				lastByte, err := strconv.Atoi(last)
+				if err != nil || lastByte < start {
+					return nil, errInvalidRange
+				}
+				end = min(lastByte+1, size)
+			}
+			if start >= size {
+				continue
+			}
+			r = byteRange{start: start, end: end}
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return nil, errInvalidRange
+	}
+	return ranges, nil
+}
+
+// rangesLength returns the total number of bytes ranges select.
+func rangesLength(ranges []byteRange) int {
+	total := 0
+	for _, r := range ranges {
+		total += r.end - r.start
+	}
+	return total
+}
+
+// requestHeader returns the named request header, lowercase key, or "".
+func requestHeader(req *Request, key string) string {
+	if req == nil {
+		return ""
+	}
+	return strings.TrimSpace(req.Headers[key])
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServeContent_FullRequest verifies a plain GET gets the whole content with validators.
+func TestServeContent_FullRequest(t *testing.T) {
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	resp := ServeContent(&Request{Method: "GET", Headers: map[string]string{}}, "text/plain", modTime, []byte("0123456789"))
+
+	if resp.StatusCode != 200 || string(resp.Body) != "0123456789" {
+		t.Fatalf("expected full content, got %d %q", resp.StatusCode, string(resp.Body))
+	}
+	want := map[string]string{
+		"Content-Type":   "text/plain",
+		"Content-Length": "10",
+		"Accept-Ranges":  "bytes",
+		"Last-Modified":  "Fri, 01 Mar 2024 12:00:00 GMT",
+	}
+	for key, value := range want {
+		if got := resp.Headers[key]; got != value {
+			t.Fatalf("expected %s %q, got %q", key, value, got)
+		}
+	}
+	if etag := resp.Headers["ETag"]; !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("expected a strong ETag, got %q", etag)
+	}
+}
+
+// TestServeContent_RangeRequests verifies single, multipart, and unsatisfiable ranges.
+func TestServeContent_RangeRequests(t *testing.T) {
+	content := []byte("0123456789")
+	serve := func(headers map[string]string) *Response {
+		return ServeContent(&Request{Method: "GET", Headers: headers}, "text/plain", time.Time{}, content)
+	}
+
+	resp := serve(map[string]string{"range": "bytes=2-4"})
+	if resp.StatusCode != 206 || string(resp.Body) != "234" || resp.Headers["Content-Range"] != "bytes 2-4/10" {
+		t.Fatalf("expected bytes 2-4, got %d %q %q", resp.StatusCode, string(resp.Body), resp.Headers["Content-Range"])
+	}
+
+	resp = serve(map[string]string{"range": "bytes=-3"})
+	if resp.StatusCode != 206 || string(resp.Body) != "789" {
+		t.Fatalf("expected suffix range, got %d %q", resp.StatusCode, string(resp.Body))
+	}
+
+	resp = serve(map[string]string{"range": "bytes=0-1,8-"})
+	body := string(resp.Body)
+	if resp.StatusCode != 206 || !strings.HasPrefix(resp.Headers["Content-Type"], "multipart/byteranges; boundary=") {
+		t.Fatalf("expected multipart ranges, got %d %q", resp.StatusCode, resp.Headers["Content-Type"])
+	}
+	for _, want := range []string{"Content-Range: bytes 0-1/10\r\nContent-Type: text/plain\r\n\r\n01\r\n", "Content-Range: bytes 8-9/10\r\nContent-Type: text/plain\r\n\r\n89\r\n"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in multipart body, got %q", want, body)
+		}
+	}
+
+	resp = serve(map[string]string{"range": "bytes=20-30"})
+	if resp.StatusCode != 416 || resp.Headers["Content-Range"] != "bytes */10" {
+		t.Fatalf("expected 416, got %d %q", resp.StatusCode, resp.Headers["Content-Range"])
+	}
+
+	resp = serve(map[string]string{"range": "bytes=2-4", "if-range": `"stale"`})
+	if resp.StatusCode != 200 || string(resp.Body) != "0123456789" {
+		t.Fatalf("expected full content for a stale If-Range, got %d %q", resp.StatusCode, string(resp.Body))
+	}
+}
+
+// TestServeContent_ConditionalRequests verifies If-None-Match and If-Modified-Since answer 304.
+func TestServeContent_ConditionalRequests(t *testing.T) {
+	content := []byte("0123456789")
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	etag := ServeContent(&Request{Method: "GET", Headers: map[string]string{}}, "", modTime, content).Headers["ETag"]
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    int
+	}{
+		{name: "matching etag", method: "GET", headers: map[string]string{"if-none-match": `"other", W/` + etag}, want: 304},
+		{name: "different etag", method: "GET", headers: map[string]string{"if-none-match": `"other"`}, want: 200},
+		{name: "matching etag on PUT", method: "PUT", headers: map[string]string{"if-none-match": "*"}, want: 412},
+		{name: "not modified since", method: "GET", headers: map[string]string{"if-modified-since": "Fri, 01 Mar 2024 12:00:00 GMT"}, want: 304},
+		{name: "modified since", method: "GET", headers: map[string]string{"if-modified-since": "Thu, 29 Feb 2024 12:00:00 GMT"}, want: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := ServeContent(&Request{Method: tt.method, Headers: tt.headers}, "", modTime, content)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, resp.StatusCode)
+			}
+			if tt.want == 304 && len(resp.Body) != 0 {
+				t.Fatalf("expected no body for 304, got %q", string(resp.Body))
+			}
+		})
+	}
+}
@@ -4,14 +4,175 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"io"
 	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jamalishaq/light_serve/internal/usecase"
 )
 
 const readChunkSize = 4096
-var defaultRouter = NewRouter()
+
+// DefaultMaxConcurrentStreams bounds concurrent pipelined handlers per
+// connection when ServerOptions.MaxConcurrentStreams is unset.
+const DefaultMaxConcurrentStreams = 100
+
+// defaultRouter is the router behind HandleConn, HandleConnWithContext,
+// RegisterRoute, and UseMiddleware; SetDefaultRouter swaps it atomically.
+var defaultRouter atomic.Pointer[Router]
+
+func init() {
+	defaultRouter.Store(NewRouter())
+}
+
+// DefaultRouter returns the current default router.
+func DefaultRouter() *Router {
+	return defaultRouter.Load()
+}
+
+// SetDefaultRouter replaces the default router, e.g. to reset routes between
+// tests. A nil router installs a fresh empty one. Connections already being
+// served keep the router they started with.
+func SetDefaultRouter(router *Router) {
+	if router == nil {
+		router = NewRouter()
+	}
+	defaultRouter.Store(router)
+}
+
+// errRequestRejected marks a parsed request refused by a pre-routing check.
+var errRequestRejected = errors.New("request rejected")
+
+// ServerOptions configures connection-level request handling.
+// The zero value keeps the lenient defaults.
+type ServerOptions struct {
+	// Logger receives connection-level diagnostics; nil disables them.
+	Logger usecase.Logger
+	// RejectBodyOnGetHead answers GET and HEAD requests that carry a body with
+	// 400 before routing, closing a common request-smuggling vector.
+	RejectBodyOnGetHead bool
+	// ShedKeepAlive is probed before each response; when it reports true the
+	// response carries Connection: close to shed keep-alive reuse under load.
+	ShedKeepAlive func() bool
+	// StrictQueryEscapes answers requests whose query string contains a
+	// malformed percent-escape with 400 instead of keeping the raw value.
+	StrictQueryEscapes bool
+	// StrictPathChars answers requests whose raw path contains control
+	// characters, non-ASCII bytes, or other bytes outside RFC 3986 path
+	// characters with 400, defending logs and downstream systems.
+	StrictPathChars bool
+	// MaxReadsPerRequest caps the conn.Read calls allowed while assembling a
+	// single request; exceeding it answers 408. Zero means unlimited.
+	MaxReadsPerRequest int
+	// MaxResponseHeaderBytes caps the serialized size of handler response
+	// headers; oversized responses are logged and replaced by a 500. Zero
+	// means unlimited.
+	MaxResponseHeaderBytes int
+	// LogConnectionReuse emits a debug diagnostic each time a keep-alive
+	// connection serves another request.
+	LogConnectionReuse bool
+	// ConcurrentPipelining runs the handlers of requests pipelined on one
+	// connection concurrently while still writing responses in order.
+	ConcurrentPipelining bool
+	// MaxConcurrentStreams bounds concurrent pipelined handlers per connection
+	// when ConcurrentPipelining is enabled; it is a no-op otherwise. Zero uses
+	// DefaultMaxConcurrentStreams.
+	MaxConcurrentStreams int
+	// Clock measures connection age for diagnostics; nil uses SystemClock.
+	Clock Clock
+	// IdleTimeout bounds how long a keep-alive connection may wait for the
+	// next request after a response. The read deadline is reset to this window
+	// at every request boundary and an idle connection is closed silently when
+	// it expires. Zero waits indefinitely.
+	IdleTimeout time.Duration
+	// BodyReadTimeout bounds how long the server waits for a declared body to
+	// arrive once the request headers are complete; expiry answers 408.
+	// Router.SetBodyReadTimeout overrides it per route. Zero waits
+	// indefinitely.
+	BodyReadTimeout time.Duration
+	// ReadTimeout bounds how long each request may take to read, measured
+	// from when the connection starts being served and then from the first
+	// bytes of every later request. It also bounds the wait for the next
+	// keep-alive request when IdleTimeout is zero, and is restored once a
+	// body read under BodyReadTimeout completes. Zero sets no deadline of its
+	// own.
+	ReadTimeout time.Duration
+	// WriteTimeout sets the connection write deadline afresh before each
+	// response is written, so a keep-alive connection can outlive it. Zero
+	// sets no deadline of its own.
+	WriteTimeout time.Duration
+	// HandlerGoroutine runs each handler on a dedicated goroutine while the
+	// connection goroutine waits for it, so every handler sees the same
+	// execution model whether or not TimeoutMiddleware or concurrent
+	// pipelining is in use. It costs one goroutine spawn per request; a
+	// handler panic is re-raised on the connection goroutine, so the trace
+	// printed for an unrecovered panic no longer shows the handler frames.
+	// The default runs handlers inline on the connection goroutine.
+	HandlerGoroutine bool
+	// AllowedMethods, when non-empty, is the only set of request methods the
+	// server accepts; any other method is answered with 405 and an Allow
+	// header listing this set before route resolution, whatever routes are
+	// registered. Methods are matched case-sensitively.
+	AllowedMethods []string
+	// ParserLimits bounds the request line, headers, and body of each request;
+	// zero fields use the parser defaults.
+	ParserLimits ParserLimits
+	// MaxReadBytesPerSecond paces reads from each connection so request
+	// bodies arrive no faster than this many bytes per second, bounding how
+	// quickly a large upload is buffered. The pacing counts against
+	// BodyReadTimeout. Zero disables it.
+	MaxReadBytesPerSecond int
+	// ConnIdle is called with true when a keep-alive connection starts
+	// waiting for its next request and with false once that wait ends, so a
+	// runtime can track and evict idle connections. Nil disables it.
+	ConnIdle func(conn net.Conn, idle bool)
+	// RequestEvents, when set, receives a RequestEvent after the response to
+	// each routed request has been written, measured on Clock. Sends never
+	// block: events are dropped while the channel is full.
+	RequestEvents chan<- RequestEvent
+}
+
+var (
+	serverOptionsMu      sync.RWMutex
+	defaultServerOptions ServerOptions
+)
+
+// SetServerOptions replaces the options used by HandleConn and its variants.
+func SetServerOptions(opts ServerOptions) {
+	serverOptionsMu.Lock()
+	defer serverOptionsMu.Unlock()
+	defaultServerOptions = opts
+}
+
+// shedKeepAlive reports whether the load-shedding probe asks to close connections.
+func (o ServerOptions) shedKeepAlive() bool {
+	return o.ShedKeepAlive != nil && o.ShedKeepAlive()
+}
+
+// methodAllowed reports whether method passes the AllowedMethods allow-list.
+func (o ServerOptions) methodAllowed(method string) bool {
+	if len(o.AllowedMethods) == 0 {
+		return true
+	}
+	for _, allowed := range o.AllowedMethods {
+		if method == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// currentServerOptions returns the options registered via SetServerOptions.
+func currentServerOptions() ServerOptions {
+	serverOptionsMu.RLock()
+	defer serverOptionsMu.RUnlock()
+	return defaultServerOptions
+}
 
 // HandleConn reads one HTTP request from a connection and writes one response.
 func HandleConn(conn net.Conn) {
@@ -20,7 +181,7 @@ func HandleConn(conn net.Conn) {
 
 // HandleConnWithContext reads one HTTP request with an explicit request context.
 func HandleConnWithContext(conn net.Conn, ctx context.Context) {
-	HandleConnWithRouterAndContext(conn, defaultRouter, ctx)
+	HandleConnWithRouterAndContext(conn, DefaultRouter(), ctx)
 }
 
 // HandleConnWithRouter reads one HTTP request from a connection and routes it.
@@ -30,65 +191,320 @@ func HandleConnWithRouter(conn net.Conn, router *Router) {
 
 // HandleConnWithRouterAndContext reads one HTTP request and routes it with context.
 func HandleConnWithRouterAndContext(conn net.Conn, router *Router, ctx context.Context) {
-	defer conn.Close()
+	HandleConnWithOptions(conn, router, ctx, currentServerOptions())
+}
+
+// HandleConnWithOptions serves requests from a connection using explicit options.
+func HandleConnWithOptions(conn net.Conn, router *Router, ctx context.Context, opts ServerOptions) {
+	h := &connHandler{
+		conn:        conn,
+		router:      router,
+		ctx:         ctx,
+		opts:        opts,
+		connectedAt: clockOrDefault(opts.Clock).Now(),
+		readLimiter: newReadLimiter(opts.MaxReadBytesPerSecond, opts.Clock),
+	}
+	h.resetReadDeadline()
+	h.serve()
+}
+
+// connHandler serves the requests arriving on a single connection.
+type connHandler struct {
+	conn         net.Conn
+	router       *Router
+	ctx          context.Context
+	opts         ServerOptions
+	requestCount int
+	connectedAt  time.Time
+	// readLimiter paces reads when MaxReadBytesPerSecond is set.
+	readLimiter *readLimiter
+	// readDeadline is the ReadTimeout deadline of the current request,
+	// restored after a body read deadline; zero when ReadTimeout is unset.
+	readDeadline time.Time
+	// tlsInfo and clientCertCN cache the TLS session details of a TLS
+	// connection once its handshake is complete.
+	tlsInfo      *TLSInfo
+	clientCertCN string
+}
+
+// serve runs the read/parse/respond loop until the connection should close.
+func (h *connHandler) serve() {
+	defer h.conn.Close()
 
 	buffer := make([]byte, 0, readChunkSize)
 	chunk := make([]byte, readChunkSize)
+	reads := 0
+	idle := false
+	bodyDeadlineSet := false
 
 	for {
 		for len(buffer) > 0 {
-			req, consumed, parseErr := ParseRequest(buffer)
-			if parseErr == nil {
-				if req != nil {
-					req.Ctx = ctx
+			batch, consumed, parseErr := h.parseBatch(buffer)
+			if len(batch) > 0 {
+				if bodyDeadlineSet {
+					_ = h.conn.SetReadDeadline(h.readDeadline)
+					bodyDeadlineSet = false
 				}
-
-				closeConn := writeRoutedResponse(conn, router, req)
+				reads = 0
+				idle = true
+				closeConn := h.writeBatch(batch)
 				if consumed > len(buffer) {
 					return
 				}
 				buffer = buffer[consumed:]
+				if len(buffer) > 0 && !closeConn {
+					// The next pipelined request has already started.
+					h.resetReadDeadline()
+				}
 				if closeConn {
+					if len(buffer) > 0 {
+						last := batch[len(batch)-1]
+						logInfo(h.opts.Logger, "discarded pipelined data after connection close",
+							"method", last.Method,
+							"path", last.Path,
+							"discarded_bytes", len(buffer),
+						)
+					}
 					return
 				}
+			}
+			if parseErr == nil {
 				continue
 			}
 
 			if isIncompleteParseErr(parseErr) {
+				if errors.Is(parseErr, ErrIncompleteBody) && !bodyDeadlineSet {
+					if timeout := h.bodyReadTimeout(buffer); timeout > 0 {
+						_ = h.conn.SetReadDeadline(time.Now().Add(timeout))
+						bodyDeadlineSet = true
+					}
+				}
 				break
 			}
 
-			writeBadRequest(conn)
+			h.writeParseError(parseErr)
 			return
 		}
 
-		n, readErr := conn.Read(chunk)
+		if h.opts.MaxReadsPerRequest > 0 && reads >= h.opts.MaxReadsPerRequest {
+			h.writeRequestTimeout()
+			return
+		}
+		reads++
+
+		waitingIdle := idle && len(buffer) == 0
+		if waitingIdle {
+			h.setIdleDeadline()
+		}
+		idle = false
+
+		h.reportIdle(waitingIdle, true)
+		n, readErr := h.read(chunk)
+		h.reportIdle(waitingIdle, false)
 		if n > 0 {
 			buffer = append(buffer, chunk[:n]...)
+			if waitingIdle {
+				// The idle window is over; the new request gets its own.
+				h.resetReadDeadline()
+			}
 		}
 		if readErr != nil {
+			if waitingIdle && len(buffer) == 0 && isTimeoutErr(readErr) {
+				return
+			}
+			if bodyDeadlineSet && isTimeoutErr(readErr) {
+				h.writeRequestTimeout()
+				return
+			}
 			if errors.Is(readErr, io.EOF) {
 				if len(buffer) == 0 {
 					return
 				}
-				writeBadRequest(conn)
+				h.writeBadRequest()
 				return
 			}
 
-			writeBadRequest(conn)
+			h.writeBadRequest()
 			return
 		}
 	}
 }
 
+// resetReadDeadline starts a fresh ReadTimeout window for the request being
+// read. It does nothing when ReadTimeout is unset.
+func (h *connHandler) resetReadDeadline() {
+	if h.opts.ReadTimeout <= 0 {
+		return
+	}
+	// Socket deadlines are enforced against wall time, not opts.Clock.
+	h.readDeadline = time.Now().Add(h.opts.ReadTimeout)
+	_ = h.conn.SetReadDeadline(h.readDeadline)
+}
+
+// setIdleDeadline bounds the wait for the next keep-alive request by
+// IdleTimeout, falling back to ReadTimeout when it is zero.
+func (h *connHandler) setIdleDeadline() {
+	timeout := h.opts.IdleTimeout
+	if timeout <= 0 {
+		timeout = h.opts.ReadTimeout
+	}
+	if timeout > 0 {
+		_ = h.conn.SetReadDeadline(time.Now().Add(timeout))
+	}
+}
+
+// setWriteDeadline starts a fresh WriteTimeout window for the response about
+// to be written. It does nothing when WriteTimeout is unset.
+func (h *connHandler) setWriteDeadline() {
+	if h.opts.WriteTimeout > 0 {
+		_ = h.conn.SetWriteDeadline(time.Now().Add(h.opts.WriteTimeout))
+	}
+}
+
+// read reads from the connection into chunk, paced by the read limiter.
+func (h *connHandler) read(chunk []byte) (int, error) {
+	if h.readLimiter == nil {
+		return h.conn.Read(chunk)
+	}
+	size, ok := h.readLimiter.wait(h.ctx, len(chunk))
+	if !ok {
+		return 0, io.EOF
+	}
+	n, err := h.conn.Read(chunk[:size])
+	h.readLimiter.record(n)
+	return n, err
+}
+
+// reportIdle forwards an idle transition to opts.ConnIdle while the
+// connection waits for its next keep-alive request.
+func (h *connHandler) reportIdle(waiting, idle bool) {
+	if waiting && h.opts.ConnIdle != nil {
+		h.opts.ConnIdle(h.conn, idle)
+	}
+}
+
+// bodyReadTimeout returns the body read timeout for the request whose
+// headers start buffer, preferring a per-route override.
+func (h *connHandler) bodyReadTimeout(buffer []byte) time.Duration {
+	line, _, _ := nextLine(buffer)
+	method, target, _, err := parseRequestLine(line)
+	if err == nil && h.router != nil {
+		path, _ := splitRequestTarget(target)
+		if timeout, ok := h.router.bodyReadTimeout(method, decodeRequestPath(path)); ok {
+			return timeout
+		}
+	}
+	return h.opts.BodyReadTimeout
+}
+
+// parseBatch parses the next request from buffer and, with concurrent
+// pipelining enabled, every complete request queued behind it. Requests
+// parsed before an error are returned alongside it.
+func (h *connHandler) parseBatch(buffer []byte) ([]*Request, int, error) {
+	var batch []*Request
+	consumed := 0
+	for consumed < len(buffer) {
+		req, n, err := ParseRequestWithLimits(buffer[consumed:], h.opts.ParserLimits)
+		if err != nil {
+			return batch, consumed, err
+		}
+		if err := h.admit(req); err != nil {
+			return batch, consumed, err
+		}
+
+		batch = append(batch, req)
+		consumed += n
+		if !h.opts.ConcurrentPipelining || shouldCloseConnection(req) {
+			break
+		}
+	}
+	return batch, consumed, nil
+}
+
+// admit attaches connection state to a parsed request and applies pre-routing checks.
+func (h *connHandler) admit(req *Request) error {
+	h.requestCount++
+	if h.opts.LogConnectionReuse && h.requestCount > 1 {
+		logDebug(h.opts.Logger, "keep-alive connection reused",
+			"request_count", h.requestCount,
+			"connection_age", clockOrDefault(h.opts.Clock).Now().Sub(h.connectedAt).String(),
+		)
+	}
+	req.Ctx = h.ctx
+	if addr := h.conn.RemoteAddr(); addr != nil {
+		req.RemoteAddr = addr.String()
+	}
+	if addr := h.conn.LocalAddr(); addr != nil {
+		req.LocalAddr = addr.String()
+	}
+	if tlsConn, ok := h.conn.(*tls.Conn); ok {
+		if h.tlsInfo == nil {
+			state := tlsConn.ConnectionState()
+			h.tlsInfo = newTLSInfo(state)
+			h.clientCertCN = verifiedClientCertCN(state)
+		}
+		req.TLS = h.tlsInfo
+		req.ClientCertCN = h.clientCertCN
+	}
+
+	if h.opts.RejectBodyOnGetHead && isBodyOnGetHead(req) {
+		return errRequestRejected
+	}
+	if h.opts.StrictQueryEscapes && hasInvalidQueryEscape(req) {
+		return errRequestRejected
+	}
+	if h.opts.StrictPathChars {
+		if err := ValidatePathChars(req.RawPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBatch routes a batch of pipelined requests and writes responses in
+// request order. It reports whether the connection should close.
+func (h *connHandler) writeBatch(batch []*Request) bool {
+	if len(batch) == 1 {
+		return h.writeRoutedResponse(batch[0])
+	}
+
+	start := clockOrDefault(h.opts.Clock).Now()
+	limit := h.opts.MaxConcurrentStreams
+	if limit <= 0 {
+		limit = DefaultMaxConcurrentStreams
+	}
+	slots := make(chan struct{}, limit)
+	responses := make([]*Response, len(batch))
+	closes := make([]bool, len(batch))
+
+	var wg sync.WaitGroup
+	for i, req := range batch {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, req *Request) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			responses[i], closes[i] = buildRoutedResponse(h.router, req, h.opts)
+		}(i, req)
+	}
+	wg.Wait()
+
+	for i, resp := range responses {
+		if h.writeResponse(batch[i], resp, closes[i], start) {
+			return true
+		}
+	}
+	return false
+}
+
 // RegisterRoute registers a METHOD:PATH handler on the default router.
 func RegisterRoute(method, path string, handler HandlerAdapter) {
-	defaultRouter.Register(method, path, handler)
+	DefaultRouter().Register(method, path, handler)
 }
 
 // UseMiddleware registers middleware on the default router.
 func UseMiddleware(middlewares ...Middleware) {
-	defaultRouter.Use(middlewares...)
+	DefaultRouter().Use(middlewares...)
 }
 
 // isIncompleteParseErr reports whether more bytes may complete the request.
@@ -96,68 +512,238 @@ func isIncompleteParseErr(err error) bool {
 	return errors.Is(err, ErrIncompleteRequest) || errors.Is(err, ErrIncompleteBody)
 }
 
+// isTimeoutErr reports whether err is a network timeout.
+func isTimeoutErr(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isBodyOnGetHead reports whether a GET or HEAD request carries a body.
+func isBodyOnGetHead(req *Request) bool {
+	if req == nil || (req.Method != "GET" && req.Method != "HEAD") {
+		return false
+	}
+	if len(req.Body) > 0 {
+		return true
+	}
+	return req.Headers["transfer-encoding"] != ""
+}
+
+// hasInvalidQueryEscape reports whether the request query fails strict decoding.
+func hasInvalidQueryEscape(req *Request) bool {
+	if req == nil {
+		return false
+	}
+	_, err := ParseQuery(req.RawQuery, true)
+	return err != nil
+}
+
 // writeBadRequest writes a 400 Bad Request response.
-func writeBadRequest(conn net.Conn) {
-	resp := NewResponse()
-	resp.StatusCode = 400
-	resp.SetHeader("Content-Type", "text/plain")
+func (h *connHandler) writeBadRequest() {
+	h.writeStatusAndClose(400)
+}
+
+// writeRequestTimeout writes a 408 Request Timeout response and closes framing.
+func (h *connHandler) writeRequestTimeout() {
+	h.writeStatusAndClose(408)
+}
+
+// writeParseError writes the response for a request that failed to parse:
+// 413 for an oversized body, 501 for an unsupported transfer-coding, and 400
+// otherwise.
+func (h *connHandler) writeParseError(err error) {
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		h.writeStatusAndClose(413)
+	case errors.Is(err, ErrUnsupportedTransferCoding):
+		h.writeStatusAndClose(501)
+	default:
+		h.writeBadRequest()
+	}
+}
+
+// writeStatusAndClose writes a plain-text status response marked Connection: close.
+func (h *connHandler) writeStatusAndClose(status int) {
+	resp := statusResponse(status)
 	resp.SetHeader("Connection", "close")
-	resp.WriteString("Bad Request")
-	_, _ = conn.Write(resp.Bytes())
+	h.setWriteDeadline()
+	h.write(resp.Bytes())
 }
 
 // writeRoutedResponse routes a request and writes the resulting response.
-func writeRoutedResponse(conn net.Conn, router *Router, req *Request) bool {
-	closeConn := shouldCloseConnection(req)
+// It reports whether the connection should close, including after a failed write.
+func (h *connHandler) writeRoutedResponse(req *Request) bool {
+	start := clockOrDefault(h.opts.Clock).Now()
+	var resp *Response
+	var closeConn bool
+	if h.opts.HandlerGoroutine {
+		resp, closeConn = buildRoutedResponseOnGoroutine(h.router, req, h.opts)
+	} else {
+		resp, closeConn = buildRoutedResponse(h.router, req, h.opts)
+	}
+	return h.writeResponse(req, resp, closeConn, start)
+}
 
-	if router == nil {
-		writeNotFound(conn, closeConn)
-		return closeConn
+// writeResponse writes a finalized response, streaming its body when it has
+// a Stream, then publishes its RequestEvent timed from start. It reports
+// whether the connection should close.
+func (h *connHandler) writeResponse(req *Request, resp *Response, closeConn bool, start time.Time) bool {
+	h.setWriteDeadline()
+	var closes bool
+	if resp.Stream != nil && resp.Raw == nil {
+		closes = h.writeStream(req, resp, closeConn)
+	} else {
+		closes = !h.write(resp.Bytes()) || closeConn
 	}
+	publishRequestEvent(h.opts.RequestEvents, req, resp, clockOrDefault(h.opts.Clock).Now().Sub(start))
+	return closes
+}
 
-	handler, ok := router.Resolve(req.Method, req.Path)
-	if !ok || handler == nil {
-		allowed := router.AllowedMethods(req.Path)
-		if len(allowed) > 0 {
-			writeMethodNotAllowed(conn, allowed, closeConn)
-			return closeConn
-		}
-		writeNotFound(conn, closeConn)
-		return closeConn
+// buildRoutedResponseOnGoroutine runs buildRoutedResponse on a fresh
+// goroutine and waits for it, re-raising any panic on the caller.
+func buildRoutedResponseOnGoroutine(router *Router, req *Request, opts ServerOptions) (*Response, bool) {
+	type result struct {
+		resp      *Response
+		closeConn bool
+		panicked  bool
+		recovered any
 	}
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				res.panicked, res.recovered = true, recovered
+			}
+			done <- res
+		}()
+		res.resp, res.closeConn = buildRoutedResponse(router, req, opts)
+	}()
 
-	resp := handler(req)
-	if resp == nil {
-		resp = NewResponse()
-		resp.StatusCode = 500
-		resp.SetHeader("Content-Type", "text/plain")
-		resp.WriteString("Internal Server Error")
+	res := <-done
+	if res.panicked {
+		panic(res.recovered)
+	}
+	return res.resp, res.closeConn
+}
+
+// write sends b on the connection. A failed write leaves the stream in an
+// unknown state, so it is logged once and reported as false; callers stop
+// serving and serve closes the connection.
+func (h *connHandler) write(b []byte) bool {
+	if _, err := h.conn.Write(b); err != nil {
+		logError(h.opts.Logger, "connection write failed",
+			"remote_addr", h.remoteAddr(),
+			"error", err,
+		)
+		return false
+	}
+	return true
+}
+
+// remoteAddr returns the connection's peer address, or "" when unknown.
+func (h *connHandler) remoteAddr() string {
+	if addr := h.conn.RemoteAddr(); addr != nil {
+		return addr.String()
+	}
+	return ""
+}
+
+// buildRoutedResponse routes a request and finalizes the response for writing.
+// It reports whether the connection should close after the response.
+func buildRoutedResponse(router *Router, req *Request, opts ServerOptions) (*Response, bool) {
+	closeConn := shouldCloseConnection(req) || opts.shedKeepAlive()
+
+	var resp *Response
+	switch {
+	case !opts.methodAllowed(requestMethod(req)):
+		resp = withoutBodyForHead(req, methodNotAllowedResponse(opts.AllowedMethods))
+		if router != nil {
+			router.observeUnmatched(req, resp, SystemClock.Now())
+		}
+	case router == nil:
+		resp = withoutBodyForHead(req, notFoundResponse())
+	default:
+		resp = router.ServeRequest(req)
+	}
+	if opts.MaxResponseHeaderBytes > 0 && resp.Raw == nil {
+		if size := resp.headerBytes(); size > opts.MaxResponseHeaderBytes {
+			logError(opts.Logger, "response headers too large",
+				"method", requestMethod(req),
+				"path", requestPath(req),
+				"header_bytes", size,
+				"limit", opts.MaxResponseHeaderBytes,
+			)
+			resp = internalServerErrorResponse()
+		}
+	}
+	if resp.Stream != nil && resp.Raw == nil && requestVersion(req) != "HTTP/1.1" {
+		resp = bufferStream(req, resp, opts)
+	}
+	resp.chunked = len(resp.Trailers) > 0 && resp.Raw == nil && acceptsTrailers(req) && responseHasBody(req, resp)
+	if requestVersion(req) == "HTTP/1.0" {
+		// Answer in the client's version so a 1.0 client honors keep-alive;
+		// streams were buffered above, so the body has a Content-Length.
+		resp.Version = "HTTP/1.0"
 	}
 	setConnectionHeader(resp, closeConn)
+	return resp, closeConn
+}
+
+// acceptsTrailers reports whether an HTTP/1.1 request declared "TE: trailers".
+func acceptsTrailers(req *Request) bool {
+	if requestVersion(req) != "HTTP/1.1" {
+		return false
+	}
+	for _, coding := range strings.Split(req.Headers["te"], ",") {
+		name, _, _ := strings.Cut(coding, ";")
+		if strings.EqualFold(strings.TrimSpace(name), "trailers") {
+			return true
+		}
+	}
+	return false
+}
+
+// requestVersion returns the request's HTTP version, or "" for a nil request.
+func requestVersion(req *Request) string {
+	if req == nil {
+		return ""
+	}
+	return req.Version
+}
 
-	_, _ = conn.Write(resp.Bytes())
-	return closeConn
+// responseHasBody reports whether resp may carry a message body for req.
+func responseHasBody(req *Request, resp *Response) bool {
+	status := resp.StatusCode
+	return requestMethod(req) != "HEAD" && status != 204 && status != 304 && (status < 100 || status >= 200)
 }
 
-// writeNotFound writes a 404 Not Found response.
-func writeNotFound(conn net.Conn, closeConn bool) {
+// notFoundResponse builds a 404 Not Found response.
+func notFoundResponse() *Response {
 	resp := NewResponse()
 	resp.StatusCode = 404
 	resp.SetHeader("Content-Type", "text/plain")
-	setConnectionHeader(resp, closeConn)
 	resp.WriteString("Not Found")
-	_, _ = conn.Write(resp.Bytes())
+	return resp
 }
 
-// writeMethodNotAllowed writes a 405 Method Not Allowed response with Allow header.
-func writeMethodNotAllowed(conn net.Conn, allowed []string, closeConn bool) {
+// methodNotAllowedResponse builds a 405 Method Not Allowed response with Allow header.
+func methodNotAllowedResponse(allowed []string) *Response {
 	resp := NewResponse()
 	resp.StatusCode = 405
 	resp.SetHeader("Content-Type", "text/plain")
 	resp.SetHeader("Allow", strings.Join(allowed, ", "))
-	setConnectionHeader(resp, closeConn)
 	resp.WriteString("Method Not Allowed")
-	_, _ = conn.Write(resp.Bytes())
+	return resp
+}
+
+// withoutBodyForHead drops the body of a HEAD response while keeping its status
+// and headers, so Content-Length is serialized as 0.
+func withoutBodyForHead(req *Request, resp *Response) *Response {
+	if requestMethod(req) == "HEAD" && resp != nil {
+		resp.Body = nil
+	}
+	return resp
 }
 
 // shouldCloseConnection determines whether to close the TCP connection after response.
@@ -5,7 +5,10 @@ import (
 	"errors"
 	"io"
 	"net"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -64,6 +67,27 @@ func TestHandleConn_MalformedRequest(t *testing.T) {
 	}
 }
 
+// TestHandleConn_OversizedContentLength verifies an overflowing Content-Length returns 413.
+func TestHandleConn_OversizedContentLength(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	go HandleConn(serverConn)
+
+	request := "POST /upload HTTP/1.1\r\nContent-Length: 99999999999999999999\r\n\r\n"
+	if _, err := clientConn.Write([]byte(request)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	if resp := string(respBytes); !strings.HasPrefix(resp, "HTTP/1.1 413 Content Too Large\r\n") {
+		t.Fatalf("expected 413 status line, got %q", resp)
+	}
+}
+
 // TestHandleConn_KeepAliveProcessesMultipleRequests verifies basic keep-alive.
 func TestHandleConn_KeepAliveProcessesMultipleRequests(t *testing.T) {
 	router := NewRouter()
@@ -140,6 +164,36 @@ func TestHandleConnWithRouter_RoutedHandler(t *testing.T) {
 	}
 }
 
+// TestHandleConnWithRouter_UnsetStatusWritesOK verifies a zero status is written as 200 OK.
+func TestHandleConnWithRouter_UnsetStatusWritesOK(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/unset", func(req *Request) *Response {
+		return &Response{Body: []byte("implicit")}
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go HandleConnWithRouter(serverConn, router)
+
+	request := "GET /unset HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+	if _, err := clientConn.Write([]byte(request)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	resp := string(respBytes)
+
+	if !strings.HasPrefix(resp, "HTTP/1.1 200 OK\r\n") {
+		t.Fatalf("expected 200 status line, got %q", resp)
+	}
+	if !strings.Contains(resp, "\r\n\r\nimplicit") {
+		t.Fatalf("expected handler body, got %q", resp)
+	}
+}
+
 // TestHandleConnWithRouter_MiddlewareApplied verifies middleware is executed in routed path.
 func TestHandleConnWithRouter_MiddlewareApplied(t *testing.T) {
 	router := NewRouter()
@@ -279,6 +333,710 @@ func TestHandleConnWithRouter_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+// TestHandleConnWithRouter_QueryStringRoutesToPath verifies GET /health?foo=bar reaches the /health route.
+func TestHandleConnWithRouter_QueryStringRoutesToPath(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/health", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString("healthy " + req.RawQuery)
+		return resp
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go HandleConnWithRouter(serverConn, router)
+
+	request := "GET /health?foo=bar HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+	if _, err := clientConn.Write([]byte(request)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	resp := string(respBytes)
+	if !strings.HasPrefix(resp, "HTTP/1.1 200 OK\r\n") {
+		t.Fatalf("expected 200 status line, got %q", resp)
+	}
+	if !strings.HasSuffix(resp, "healthy foo=bar") {
+		t.Fatalf("expected handler to see raw query, got %q", resp)
+	}
+}
+
+// TestHandleConnWithOptions_IdleTimeoutClosesSilentConnection verifies idle keep-alive connections are closed.
+func TestHandleConnWithOptions_IdleTimeoutClosesSilentConnection(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/once", func(req *Request) *Response { return NewResponse() })
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	done := make(chan struct{})
+	go func() {
+		HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{IdleTimeout: 50 * time.Millisecond})
+		close(done)
+	}()
+
+	if _, err := clientConn.Write([]byte("GET /once HTTP/1.1\r\nHost: example.com\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	start := time.Now()
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if !strings.HasPrefix(string(respBytes), "HTTP/1.1 200 OK\r\n") || !strings.Contains(string(respBytes), "Connection: keep-alive\r\n") {
+		t.Fatalf("expected a single keep-alive response, got %q", string(respBytes))
+	}
+	if strings.Count(string(respBytes), "HTTP/1.1 ") != 1 {
+		t.Fatalf("expected idle close without an error response, got %q", string(respBytes))
+	}
+	if elapsed < 40*time.Millisecond || elapsed > 2*time.Second {
+		t.Fatalf("expected connection to close around the idle timeout, took %s", elapsed)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected handler goroutine to exit after idle timeout")
+	}
+}
+
+// failingWriteConn serves scripted request bytes and fails every write.
+type failingWriteConn struct {
+	net.Conn
+	reads  [][]byte
+	writes int
+	closed bool
+}
+
+// Read returns the next scripted chunk, then EOF.
+func (c *failingWriteConn) Read(b []byte) (int, error) {
+	if len(c.reads) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, c.reads[0])
+	c.reads = c.reads[1:]
+	return n, nil
+}
+
+// Write counts the attempt and reports a broken stream.
+func (c *failingWriteConn) Write(b []byte) (int, error) {
+	c.writes++
+	return 0, errors.New("broken pipe")
+}
+
+// Close records that the connection was closed.
+func (c *failingWriteConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// RemoteAddr returns a fixed peer address.
+func (c *failingWriteConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(192, 0, 2, 7), Port: 4000}
+}
+
+// LocalAddr returns a fixed local address.
+func (c *failingWriteConn) LocalAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}
+}
+
+// TestHandleConnWithOptions_WriteFailureStopsLoop verifies a failed write ends the keep-alive loop and is logged once.
+func TestHandleConnWithOptions_WriteFailureStopsLoop(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/", func(req *Request) *Response { return NewResponse() })
+
+	logger := &stubLogger{}
+	pipelined := "GET / HTTP/1.1\r\nHost: example.com\r\n\r\nGET / HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	conn := &failingWriteConn{reads: [][]byte{[]byte(pipelined), []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")}}
+
+	HandleConnWithOptions(conn, router, context.Background(), ServerOptions{Logger: logger})
+
+	if conn.writes != 1 {
+		t.Fatalf("expected serving to stop after the first failed write, got %d writes", conn.writes)
+	}
+	if !conn.closed {
+		t.Fatalf("expected connection to be closed after write failure")
+	}
+	failures := 0
+	for _, entry := range logger.entries {
+		if strings.Contains(entry, "connection write failed") {
+			failures++
+			if !strings.Contains(entry, "192.0.2.7:4000") {
+				t.Fatalf("expected remote address in log entry, got %q", entry)
+			}
+		}
+	}
+	if failures != 1 {
+		t.Fatalf("expected one write failure log entry, got %v", logger.entries)
+	}
+}
+
+// TestHandleConnWithOptions_BodyReadTimeout verifies a trickled body past the body timeout gets 408.
+func TestHandleConnWithOptions_BodyReadTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          ServerOptions
+		routeOverride time.Duration
+	}{
+		{name: "global", opts: ServerOptions{BodyReadTimeout: 50 * time.Millisecond}},
+		{name: "per-route override", opts: ServerOptions{BodyReadTimeout: time.Minute}, routeOverride: 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter()
+			router.Register("POST", "/upload", func(req *Request) *Response { return NewResponse() })
+			if tt.routeOverride > 0 {
+				router.SetBodyReadTimeout("POST", "/upload", tt.routeOverride)
+			}
+
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go HandleConnWithOptions(serverConn, router, context.Background(), tt.opts)
+
+			head := "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 10\r\n\r\n"
+			if _, err := clientConn.Write([]byte(head)); err != nil {
+				t.Fatalf("write request failed: %v", err)
+			}
+			// Each byte arrives well within the timeout, but the whole body
+			// takes longer than it.
+			go func() {
+				for i := 0; i < 10; i++ {
+					time.Sleep(20 * time.Millisecond)
+					if _, err := clientConn.Write([]byte("a")); err != nil {
+						return
+					}
+				}
+			}()
+
+			_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			respBytes, err := io.ReadAll(clientConn)
+			if err != nil {
+				t.Fatalf("read response failed: %v", err)
+			}
+			if resp := string(respBytes); !strings.HasPrefix(resp, "HTTP/1.1 408 Request Timeout\r\n") {
+				t.Fatalf("expected 408 for trickled body, got %q", resp)
+			}
+		})
+	}
+}
+
+// TestHandleConnWithOptions_ReadTimeoutRestoredAfterBody verifies the connection read deadline still applies after a body read deadline.
+func TestHandleConnWithOptions_ReadTimeoutRestoredAfterBody(t *testing.T) {
+	router := NewRouter()
+	router.Register("POST", "/upload", func(req *Request) *Response {
+		return NewResponse().WriteString(string(req.Body))
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	opts := ServerOptions{ReadTimeout: 300 * time.Millisecond, BodyReadTimeout: 200 * time.Millisecond}
+	go HandleConnWithOptions(serverConn, router, context.Background(), opts)
+
+	head := "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 6\r\n\r\n"
+	if _, err := clientConn.Write([]byte(head)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	for _, part := range []string{"ab", "cd", "ef"} {
+		time.Sleep(20 * time.Millisecond)
+		if _, err := clientConn.Write([]byte(part)); err != nil {
+			t.Fatalf("write body failed: %v", err)
+		}
+	}
+
+	_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("expected the server to close the idle connection at the read deadline, got %v", err)
+	}
+	if resp := string(respBytes); !strings.HasPrefix(resp, "HTTP/1.1 200 OK\r\n") || !strings.HasSuffix(resp, "abcdef") {
+		t.Fatalf("expected the trickled body to be served, got %q", resp)
+	}
+}
+
+// TestHandleConn_TransferCodings verifies chunked bodies are served, unsupported codings get 501, and conflicting lengths get 400.
+func TestHandleConn_TransferCodings(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		wantPrefix string
+	}{
+		{
+			name:       "chunked",
+			raw:        "POST /echo HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\nConnection: close\r\n\r\n5\r\nhello\r\n0\r\n\r\n",
+			wantPrefix: "HTTP/1.1 200 OK\r\n",
+		},
+		{
+			name:       "deflate",
+			raw:        "POST /echo HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: deflate\r\n\r\nhello",
+			wantPrefix: "HTTP/1.1 501 Not Implemented\r\n",
+		},
+		{
+			name:       "content-length and transfer-encoding",
+			raw:        "POST /echo HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n",
+			wantPrefix: "HTTP/1.1 400 Bad Request\r\n",
+		},
+		{
+			name:       "differing content-lengths",
+			raw:        "POST /echo HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\nContent-Length: 3\r\n\r\nhello",
+			wantPrefix: "HTTP/1.1 400 Bad Request\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter()
+			router.Register("POST", "/echo", func(req *Request) *Response {
+				resp := NewResponse()
+				resp.WriteBytes(req.Body)
+				return resp
+			})
+
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{})
+
+			if _, err := clientConn.Write([]byte(tt.raw)); err != nil {
+				t.Fatalf("write request failed: %v", err)
+			}
+			_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			respBytes, err := io.ReadAll(clientConn)
+			if err != nil {
+				t.Fatalf("read response failed: %v", err)
+			}
+			if resp := string(respBytes); !strings.HasPrefix(resp, tt.wantPrefix) {
+				t.Fatalf("expected response starting %q, got %q", tt.wantPrefix, resp)
+			}
+		})
+	}
+}
+
+// TestHandleConnWithOptions_HandlerGoroutine verifies where handlers run relative to the connection goroutine.
+func TestHandleConnWithOptions_HandlerGoroutine(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          ServerOptions
+		wantDedicated bool
+	}{
+		{name: "inline by default", opts: ServerOptions{}, wantDedicated: false},
+		{name: "dedicated goroutine", opts: ServerOptions{HandlerGoroutine: true}, wantDedicated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlerID := make(chan uint64, 1)
+			router := NewRouter()
+			router.Register("GET", "/whoami", func(req *Request) *Response {
+				handlerID <- goroutineID()
+				return NewResponse()
+			})
+
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			connID := make(chan uint64, 1)
+			go func() {
+				connID <- goroutineID()
+				HandleConnWithOptions(serverConn, router, context.Background(), tt.opts)
+			}()
+
+			if _, err := clientConn.Write([]byte("GET /whoami HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")); err != nil {
+				t.Fatalf("write request failed: %v", err)
+			}
+			_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if _, err := io.ReadAll(clientConn); err != nil {
+				t.Fatalf("read response failed: %v", err)
+			}
+
+			if dedicated := <-handlerID != <-connID; dedicated != tt.wantDedicated {
+				t.Fatalf("expected handler on dedicated goroutine = %v, got %v", tt.wantDedicated, dedicated)
+			}
+		})
+	}
+}
+
+// goroutineID parses the current goroutine's id from its stack header.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	fields := strings.Fields(strings.TrimPrefix(string(buf), "goroutine "))
+	id, _ := strconv.ParseUint(fields[0], 10, 64)
+	return id
+}
+
+// TestHandleConn_ChunkedResponseTrailers verifies trailers are sent after a chunked body only when TE: trailers is declared.
+func TestHandleConn_ChunkedResponseTrailers(t *testing.T) {
+	tests := []struct {
+		name       string
+		te         string
+		wantHeader []string
+	}{
+		{
+			name:       "te trailers",
+			te:         "TE: trailers\r\n",
+			wantHeader: []string{"Transfer-Encoding: chunked\r\n", "Trailer: X-Checksum, X-Count\r\n"},
+		},
+		{
+			name:       "no te",
+			wantHeader: []string{"Content-Length: 5\r\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter()
+			router.Register("GET", "/stream", func(req *Request) *Response {
+				resp := NewResponse().WriteString("hello")
+				resp.Trailers = map[string]string{"X-Checksum": "5d41402a", "X-Count": "1"}
+				return resp
+			})
+
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{})
+
+			raw := "GET /stream HTTP/1.1\r\nHost: example.com\r\n" + tt.te + "Connection: close\r\n\r\n"
+			if _, err := clientConn.Write([]byte(raw)); err != nil {
+				t.Fatalf("write request failed: %v", err)
+			}
+			_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			respBytes, err := io.ReadAll(clientConn)
+			if err != nil {
+				t.Fatalf("read response failed: %v", err)
+			}
+
+			head, body, ok := strings.Cut(string(respBytes), "\r\n\r\n")
+			if !ok {
+				t.Fatalf("expected a complete response head, got %q", string(respBytes))
+			}
+			for _, want := range tt.wantHeader {
+				if !strings.Contains(head+"\r\n", want) {
+					t.Fatalf("expected header %q, got %q", want, head)
+				}
+			}
+			if tt.te == "" {
+				if body != "hello" || strings.Contains(head, "Trailer") {
+					t.Fatalf("expected plain body without trailers, got head %q body %q", head, body)
+				}
+				return
+			}
+
+			decoded, n, err := decodeChunkedBody([]byte(body), ParserLimits{}.withDefaults())
+			if err != nil || string(decoded) != "hello" || n != len(body) {
+				t.Fatalf("expected chunked body hello, got %q (n=%d, err=%v)", string(decoded), n, err)
+			}
+			trailers := body[strings.Index(body, "0\r\n")+3:]
+			for _, want := range []string{"X-Checksum: 5d41402a\r\n", "X-Count: 1\r\n"} {
+				if !strings.Contains(trailers, want) {
+					t.Fatalf("expected trailer %q, got %q", want, trailers)
+				}
+			}
+		})
+	}
+}
+
+// TestHandleConnWithOptions_AllowedMethods verifies methods outside the server allow-list get 405 even with a route.
+func TestHandleConnWithOptions_AllowedMethods(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/items", func(req *Request) *Response { return NewResponse().WriteString("list") })
+	router.Register("POST", "/items", func(req *Request) *Response { return NewResponse().Status(201) })
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{AllowedMethods: []string{"GET", "HEAD"}})
+
+	request := "POST /items HTTP/1.1\r\nHost: example.com\r\nContent-Length: 2\r\n\r\n{}" +
+		"GET /items HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+	if _, err := clientConn.Write([]byte(request)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+
+	resp := string(respBytes)
+	if !strings.HasPrefix(resp, "HTTP/1.1 405 Method Not Allowed\r\n") || !strings.Contains(resp, "Allow: GET, HEAD\r\n") {
+		t.Fatalf("expected 405 with Allow: GET, HEAD for POST, got %q", resp)
+	}
+	if !strings.Contains(resp, "HTTP/1.1 200 OK\r\n") || !strings.HasSuffix(resp, "list") {
+		t.Fatalf("expected allowed GET to be routed, got %q", resp)
+	}
+}
+
+// TestSetDefaultRouter verifies package-level helpers use the swapped default router.
+func TestSetDefaultRouter(t *testing.T) {
+	previous := DefaultRouter()
+	defer SetDefaultRouter(previous)
+
+	replacement := NewRouter()
+	SetDefaultRouter(replacement)
+	if DefaultRouter() != replacement {
+		t.Fatalf("expected DefaultRouter to return the replacement")
+	}
+
+	RegisterRoute("GET", "/swapped", func(req *Request) *Response { return NewResponse().WriteString("swapped") })
+	if !replacement.Has("GET", "/swapped") {
+		t.Fatalf("expected RegisterRoute to land on the replacement router")
+	}
+	if previous.Has("GET", "/swapped") {
+		t.Fatalf("expected the previous router to be untouched")
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go HandleConnWithContext(serverConn, context.Background())
+
+	if _, err := clientConn.Write([]byte("GET /swapped HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	if resp := string(respBytes); !strings.HasSuffix(resp, "swapped") {
+		t.Fatalf("expected response from the swapped router, got %q", resp)
+	}
+
+	SetDefaultRouter(nil)
+	if DefaultRouter() == nil || DefaultRouter().Has("GET", "/swapped") {
+		t.Fatalf("expected nil to install a fresh empty router")
+	}
+}
+
+// TestHandleConnWithOptions_ParserLimits verifies configured parser limits apply to connections.
+func TestHandleConnWithOptions_ParserLimits(t *testing.T) {
+	router := NewRouter()
+	router.Register("POST", "/upload", func(req *Request) *Response {
+		return NewResponse().WriteString(strconv.Itoa(len(req.Body)))
+	})
+	body := strings.Repeat("a", maxBodyBytes+1)
+	request := "POST /upload HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\nContent-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body
+
+	tests := []struct {
+		name       string
+		opts       ServerOptions
+		wantStatus string
+	}{
+		{name: "default", opts: ServerOptions{}, wantStatus: "HTTP/1.1 413 Content Too Large\r\n"},
+		{name: "raised", opts: ServerOptions{ParserLimits: ParserLimits{MaxBodyBytes: 2 * maxBodyBytes}}, wantStatus: "HTTP/1.1 200 OK\r\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go HandleConnWithOptions(serverConn, router, context.Background(), tc.opts)
+			go func() { _, _ = clientConn.Write([]byte(request)) }()
+
+			respBytes, err := io.ReadAll(clientConn)
+			if err != nil {
+				t.Fatalf("read response failed: %v", err)
+			}
+			if resp := string(respBytes); !strings.HasPrefix(resp, tc.wantStatus) {
+				t.Fatalf("expected %q, got %q", tc.wantStatus, resp)
+			}
+		})
+	}
+}
+
+// TestHandleConnWithOptions_RequestEvents verifies handled requests publish events and a full channel drops them.
+func TestHandleConnWithOptions_RequestEvents(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/ok", func(req *Request) *Response {
+		return NewResponse().WriteString("ok")
+	})
+	request := "GET /ok HTTP/1.1\r\nHost: example.com\r\n\r\n" +
+		"GET /missing HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+
+	events := make(chan RequestEvent, 4)
+	serveStreamRequest(t, router, ServerOptions{RequestEvents: events}, request)
+	close(events)
+	var got []RequestEvent
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %+v", got)
+	}
+	if got[0].Method != "GET" || got[0].Path != "/ok" || got[0].Status != 200 || got[0].RemoteAddr == "" {
+		t.Fatalf("unexpected first event %+v", got[0])
+	}
+	if got[1].Path != "/missing" || got[1].Status != 404 {
+		t.Fatalf("unexpected second event %+v", got[1])
+	}
+
+	full := make(chan RequestEvent, 1)
+	resp := serveStreamRequest(t, router, ServerOptions{RequestEvents: full}, request)
+	if strings.Count(resp, "HTTP/1.1 ") != 2 || len(full) != 1 {
+		t.Fatalf("expected both responses with the overflow event dropped, got %d events and %q", len(full), resp)
+	}
+}
+
+// steppingClock is a fakeClock whose timers fire at once, advancing the
+// clock by the time waited.
+type steppingClock struct {
+	*fakeClock
+}
+
+// After advances the clock by d and returns an already fired channel.
+func (c steppingClock) After(d time.Duration) <-chan time.Time {
+	c.Advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+// TestHandleConnWithOptions_MaxReadBytesPerSecond verifies a large body is read at about the configured rate.
+func TestHandleConnWithOptions_MaxReadBytesPerSecond(t *testing.T) {
+	router := NewRouter()
+	router.Register("POST", "/upload", func(req *Request) *Response {
+		return NewResponse().WriteString(strconv.Itoa(len(req.Body)))
+	})
+	body := strings.Repeat("a", 20000)
+	request := "POST /upload HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\nContent-Length: 20000\r\n\r\n" + body
+	clock := steppingClock{newFakeClock()}
+	startedAt := clock.Now()
+
+	resp := serveStreamRequest(t, router, ServerOptions{MaxReadBytesPerSecond: 40000, Clock: clock}, request)
+	paced := clock.Now().Sub(startedAt)
+	if !strings.HasSuffix(resp, "\r\n\r\n20000") {
+		t.Fatalf("expected the whole body to be read, got %q", resp)
+	}
+	// Every read but the last is paced, and no read exceeds readChunkSize.
+	total := len(request)
+	lowest := time.Duration(total-readChunkSize) * time.Second / 40000
+	highest := time.Duration(total) * time.Second / 40000
+	if paced < lowest || paced > highest {
+		t.Fatalf("expected reads paced for %v to %v, got %v", lowest, highest, paced)
+	}
+}
+
+// TestHandleConnWithOptions_HTTP10KeepAliveResponseLine verifies an HTTP/1.0 keep-alive request gets an HTTP/1.0 response line.
+func TestHandleConnWithOptions_HTTP10KeepAliveResponseLine(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/", func(req *Request) *Response {
+		return NewResponse().WriteString("hello")
+	})
+	request := "GET / HTTP/1.0\r\nConnection: keep-alive\r\n\r\n" +
+		"GET / HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+
+	resp := serveStreamRequest(t, router, ServerOptions{}, request)
+	first, second, ok := strings.Cut(resp, "hello")
+	if !ok {
+		t.Fatalf("expected two responses, got %q", resp)
+	}
+	if !strings.HasPrefix(first, "HTTP/1.0 200 OK\r\n") {
+		t.Fatalf("expected an HTTP/1.0 status line, got %q", first)
+	}
+	if !strings.Contains(first, "Connection: keep-alive\r\n") || !strings.Contains(first, "Content-Length: 5\r\n") {
+		t.Fatalf("expected keep-alive with a Content-Length, got %q", first)
+	}
+	if !strings.HasPrefix(second, "HTTP/1.1 200 OK\r\n") {
+		t.Fatalf("expected the HTTP/1.1 request on the same connection to get a 1.1 line, got %q", second)
+	}
+}
+
+// addrConn overrides the addresses reported by a wrapped connection.
+type addrConn struct {
+	net.Conn
+	remote net.Addr
+	local  net.Addr
+}
+
+// RemoteAddr returns the configured peer address.
+func (c *addrConn) RemoteAddr() net.Addr { return c.remote }
+
+// LocalAddr returns the configured local address.
+func (c *addrConn) LocalAddr() net.Addr { return c.local }
+
+// TestHandleConnWithRouter_ExposesConnectionAddresses verifies RemoteAddr/LocalAddr reach handlers through middleware clones.
+func TestHandleConnWithRouter_ExposesConnectionAddresses(t *testing.T) {
+	router := NewRouter()
+	router.Use(TimeoutMiddleware(time.Second))
+	router.Register("GET", "/whoami", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString(req.RemoteAddr + " -> " + req.LocalAddr)
+		return resp
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	conn := &addrConn{
+		Conn:   serverConn,
+		remote: &net.TCPAddr{IP: net.IPv4(203, 0, 113, 9), Port: 51234},
+		local:  &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 8443},
+	}
+	go HandleConnWithRouter(conn, router)
+
+	request := "GET /whoami HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+	if _, err := clientConn.Write([]byte(request)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	if resp := string(respBytes); !strings.HasSuffix(resp, "203.0.113.9:51234 -> 10.0.0.1:8443") {
+		t.Fatalf("expected connection addresses in body, got %q", resp)
+	}
+}
+
+// TestHandleConnWithRouter_HeadErrorResponsesHaveNoBody verifies HEAD 404/405 keep status and headers without a body.
+func TestHandleConnWithRouter_HeadErrorResponsesHaveNoBody(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/users", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString("users")
+		return resp
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus string
+		wantHeader string
+	}{
+		{name: "not found", path: "/missing", wantStatus: "HTTP/1.1 404 Not Found\r\n"},
+		{name: "method not allowed", path: "/users", wantStatus: "HTTP/1.1 405 Method Not Allowed\r\n", wantHeader: "Allow: GET\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go HandleConnWithRouter(serverConn, router)
+
+			request := "HEAD " + tt.path + " HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+			if _, err := clientConn.Write([]byte(request)); err != nil {
+				t.Fatalf("write request failed: %v", err)
+			}
+
+			respBytes, err := io.ReadAll(clientConn)
+			if err != nil {
+				t.Fatalf("read response failed: %v", err)
+			}
+			resp := string(respBytes)
+
+			if !strings.HasPrefix(resp, tt.wantStatus) {
+				t.Fatalf("expected %q, got %q", tt.wantStatus, resp)
+			}
+			if tt.wantHeader != "" && !strings.Contains(resp, tt.wantHeader) {
+				t.Fatalf("expected header %q, got %q", tt.wantHeader, resp)
+			}
+			if !strings.Contains(resp, "Content-Length: 0\r\n") {
+				t.Fatalf("expected Content-Length: 0, got %q", resp)
+			}
+			if !strings.HasSuffix(resp, "\r\n\r\n") {
+				t.Fatalf("expected no body, got %q", resp)
+			}
+		})
+	}
+}
+
 type cancelAwareUseCase struct {
 	ctxErrCh chan error
 }
@@ -314,8 +1072,8 @@ func TestHandleConnWithRouterAndContext_PropagatesCancel(t *testing.T) {
 	}
 	resp := string(respBytes)
 
-	if !strings.HasPrefix(resp, "HTTP/1.1 500 Internal Server Error\r\n") {
-		t.Fatalf("expected 500 status line, got %q", resp)
+	if !strings.HasPrefix(resp, "HTTP/1.1 499 Client Closed Request\r\n") {
+		t.Fatalf("expected 499 status line, got %q", resp)
 	}
 
 	select {
@@ -327,3 +1085,278 @@ func TestHandleConnWithRouterAndContext_PropagatesCancel(t *testing.T) {
 		t.Fatalf("expected use case to observe cancellation")
 	}
 }
+
+// TestHandleConnWithOptions_RejectBodyOnGetHead verifies GET bodies are rejected only when enabled.
+func TestHandleConnWithOptions_RejectBodyOnGetHead(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/items", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString("items")
+		return resp
+	})
+
+	tests := []struct {
+		name       string
+		opts       ServerOptions
+		wantStatus string
+	}{
+		{name: "enabled", opts: ServerOptions{RejectBodyOnGetHead: true}, wantStatus: "HTTP/1.1 400 Bad Request\r\n"},
+		{name: "disabled", opts: ServerOptions{}, wantStatus: "HTTP/1.1 200 OK\r\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			serverConn, clientConn := net.Pipe()
+			defer clientConn.Close()
+			go HandleConnWithOptions(serverConn, router, context.Background(), tc.opts)
+
+			request := "GET /items HTTP/1.1\r\nHost: example.com\r\nContent-Length: 4\r\nConnection: close\r\n\r\nbody"
+			if _, err := clientConn.Write([]byte(request)); err != nil {
+				t.Fatalf("write request failed: %v", err)
+			}
+
+			respBytes, err := io.ReadAll(clientConn)
+			if err != nil {
+				t.Fatalf("read response failed: %v", err)
+			}
+			if resp := string(respBytes); !strings.HasPrefix(resp, tc.wantStatus) {
+				t.Fatalf("expected %q, got %q", tc.wantStatus, resp)
+			}
+		})
+	}
+}
+
+// TestHandleConnWithOptions_MaxReadsPerRequest verifies many tiny reads trigger a 408.
+func TestHandleConnWithOptions_MaxReadsPerRequest(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/slow-headers", func(req *Request) *Response {
+		return NewResponse()
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{MaxReadsPerRequest: 5})
+
+	request := "GET /slow-headers HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	go func() {
+		for i := 0; i < len(request); i++ {
+			if _, err := clientConn.Write([]byte{request[i]}); err != nil {
+				return
+			}
+		}
+	}()
+
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	if resp := string(respBytes); !strings.HasPrefix(resp, "HTTP/1.1 408 Request Timeout\r\n") {
+		t.Fatalf("expected 408 status line, got %q", resp)
+	}
+}
+
+// TestHandleConnWithRouter_RawResponseWrittenVerbatim verifies raw wire bytes bypass serialization.
+func TestHandleConnWithRouter_RawResponseWrittenVerbatim(t *testing.T) {
+	raw := "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 2\r\n\r\nok"
+	router := NewRouter()
+	router.Register("GET", "/health", RawResponseHandler([]byte(raw)))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go HandleConnWithRouter(serverConn, router)
+
+	request := "GET /health HTTP/1.1\r\nHost: example.com\r\n\r\nGET /health HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+	if _, err := clientConn.Write([]byte(request)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	if resp := string(respBytes); resp != raw+raw {
+		t.Fatalf("expected raw bytes written verbatim for both keep-alive requests, got %q", resp)
+	}
+}
+
+// TestHandleConnWithOptions_LogsDiscardedPipelinedData verifies data after a close request is logged and dropped.
+func TestHandleConnWithOptions_LogsDiscardedPipelinedData(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/first", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString("first")
+		return resp
+	})
+	router.Register("GET", "/second", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.WriteString("second")
+		return resp
+	})
+
+	logger := &stubLogger{}
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	done := make(chan struct{})
+	go func() {
+		HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{Logger: logger})
+		close(done)
+	}()
+
+	second := "GET /second HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	request := "GET /first HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n" + second
+	if _, err := clientConn.Write([]byte(request)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	<-done
+
+	resp := string(respBytes)
+	if strings.Count(resp, "HTTP/1.1 ") != 1 || strings.Contains(resp, "second") {
+		t.Fatalf("expected only the first response, got %q", resp)
+	}
+	if len(logger.entries) != 1 {
+		t.Fatalf("expected one diagnostic log entry, got %v", logger.entries)
+	}
+	if !strings.Contains(logger.entries[0], "discarded pipelined data after connection close") ||
+		!strings.Contains(logger.entries[0], "discarded_bytes "+strconv.Itoa(len(second))) {
+		t.Fatalf("unexpected diagnostic log entry %q", logger.entries[0])
+	}
+}
+
+// TestHandleConnWithOptions_MaxResponseHeaderBytes verifies oversized response headers become a 500.
+func TestHandleConnWithOptions_MaxResponseHeaderBytes(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/huge", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.SetHeader("X-Huge", strings.Repeat("a", 2048))
+		resp.WriteString("never sent")
+		return resp
+	})
+
+	logger := &stubLogger{}
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{Logger: logger, MaxResponseHeaderBytes: 1024})
+
+	request := "GET /huge HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+	if _, err := clientConn.Write([]byte(request)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	resp := string(respBytes)
+	if !strings.HasPrefix(resp, "HTTP/1.1 500 Internal Server Error\r\n") {
+		t.Fatalf("expected guarded 500 status line, got %q", resp)
+	}
+	if strings.Contains(resp, "X-Huge") {
+		t.Fatalf("expected oversized header to be dropped, got %q", resp)
+	}
+	if len(logger.entries) != 1 || !strings.Contains(logger.entries[0], "response headers too large") {
+		t.Fatalf("expected oversized header log entry, got %v", logger.entries)
+	}
+}
+
+// TestHandleConnWithOptions_LogConnectionReuse verifies reuse diagnostics fire for subsequent requests.
+func TestHandleConnWithOptions_LogConnectionReuse(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/reuse", func(req *Request) *Response {
+		return NewResponse()
+	})
+
+	logger := &stubLogger{}
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	done := make(chan struct{})
+	go func() {
+		HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{Logger: logger, LogConnectionReuse: true})
+		close(done)
+	}()
+
+	request := "GET /reuse HTTP/1.1\r\nHost: example.com\r\n\r\nGET /reuse HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n"
+	if _, err := clientConn.Write([]byte(request)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	if _, err := io.ReadAll(clientConn); err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	<-done
+
+	if len(logger.entries) != 1 {
+		t.Fatalf("expected one reuse log entry, got %v", logger.entries)
+	}
+	if !strings.Contains(logger.entries[0], "keep-alive connection reused") || !strings.Contains(logger.entries[0], "request_count 2") {
+		t.Fatalf("unexpected reuse log entry %q", logger.entries[0])
+	}
+	if logger.levels[0] != "DEBUG" || strings.Contains(logger.entries[0], "level") {
+		t.Fatalf("expected a debug entry without a level field, got %s %q", logger.levels[0], logger.entries[0])
+	}
+}
+
+// TestHandleConnWithOptions_MaxConcurrentStreams verifies pipelined handler concurrency is bounded.
+func TestHandleConnWithOptions_MaxConcurrentStreams(t *testing.T) {
+	var mu sync.Mutex
+	active, peak := 0, 0
+	router := NewRouter()
+	router.Register("GET", "/stream", func(req *Request) *Response {
+		mu.Lock()
+		active++
+		if active > peak {
+			peak = active
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+
+		resp := NewResponse()
+		resp.WriteString(req.Headers["x-seq"])
+		return resp
+	})
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go HandleConnWithOptions(serverConn, router, context.Background(), ServerOptions{
+		ConcurrentPipelining: true,
+		MaxConcurrentStreams: 2,
+	})
+
+	var request strings.Builder
+	for i := 0; i < 6; i++ {
+		request.WriteString("GET /stream HTTP/1.1\r\nHost: example.com\r\nX-Seq: " + strconv.Itoa(i) + "\r\n")
+		if i == 5 {
+			request.WriteString("Connection: close\r\n")
+		}
+		request.WriteString("\r\n")
+	}
+	if _, err := clientConn.Write([]byte(request.String())); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	resp := string(respBytes)
+
+	if peak != 2 {
+		t.Fatalf("expected peak concurrency 2, got %d", peak)
+	}
+	last := -1
+	for i := 0; i < 6; i++ {
+		idx := strings.Index(resp, "\r\n\r\n"+strconv.Itoa(i))
+		if idx <= last {
+			t.Fatalf("expected response %d in request order, got %q", i, resp)
+		}
+		last = idx
+	}
+}
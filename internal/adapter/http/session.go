@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Session holds server-side values for one client, identified by the ID
+// carried in the session cookie. It is safe for concurrent use.
+type Session struct {
+	// ID is the random session identifier sent to the client.
+	ID string
+
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+// newSession creates an empty session with a fresh random ID.
+func newSession() *Session {
+	return &Session{ID: randomHex(32), values: make(map[string]any)}
+}
+
+// Get returns the value stored under key.
+func (s *Session) Get(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set stores value under key.
+func (s *Session) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[string]any)
+	}
+	s.values[key] = value
+}
+
+// Delete removes the value stored under key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// SessionStore loads and saves sessions for SessionMiddleware. Implement it
+// to keep sessions in an external store shared between server instances.
+type SessionStore interface {
+	// Load returns the session for id; found is false when id is unknown or
+	// its session has expired.
+	Load(id string) (session *Session, found bool, err error)
+	// Save stores session after a request, refreshing its expiry.
+	Save(session *Session) error
+}
+
+// MemorySessionStore is an in-process SessionStore that expires sessions
+// not saved within its TTL. Sessions are lost on restart and are not shared
+// between server instances.
+type MemorySessionStore struct {
+	ttl   time.Duration
+	clock Clock
+
+	mu        sync.Mutex
+	sessions  map[string]memorySessionEntry
+	lastSweep time.Time
+}
+
+type memorySessionEntry struct {
+	session   *Session
+	expiresAt time.Time
+}
+
+// NewMemorySessionStore creates an in-memory store expiring sessions ttl
+// after they were last saved.
+func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
+	return NewMemorySessionStoreWithClock(ttl, SystemClock)
+}
+
+// NewMemorySessionStoreWithClock is NewMemorySessionStore measuring expiry on clock.
+func NewMemorySessionStoreWithClock(ttl time.Duration, clock Clock) *MemorySessionStore {
+	clock = clockOrDefault(clock)
+	return &MemorySessionStore{
+		ttl:       ttl,
+		clock:     clock,
+		sessions:  make(map[string]memorySessionEntry),
+		lastSweep: clock.Now(),
+	}
+}
+
+// Load returns the unexpired session for id, dropping it once expired.
+func (s *MemorySessionStore) Load(id string) (*Session, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.sessions[id]
+	if !ok {
+		return nil, false, nil
+	}
+	if !s.clock.Now().Before(entry.expiresAt) {
+		delete(s.sessions, id)
+		return nil, false, nil
+	}
+	return entry.session, true, nil
+}
+
+// Save stores session until the TTL elapses. Expired sessions that were
+// never loaded again are swept at most once per TTL.
+func (s *MemorySessionStore) Save(session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	if now.Sub(s.lastSweep) >= s.ttl {
+		for id, entry := range s.sessions {
+			if !now.Before(entry.expiresAt) {
+				delete(s.sessions, id)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.sessions[session.ID] = memorySessionEntry{session: session, expiresAt: now.Add(s.ttl)}
+	return nil
+}
+
+type sessionKey struct{}
+
+// SessionMiddleware loads the session named by the cookieName cookie from
+// store, or starts a new one when the cookie is missing or its session has
+// expired, and exposes it to handlers through SessionFromRequest. The
+// session is saved after the handler runs. A new session is sent to the
+// client in a Secure, HttpOnly, SameSite=Lax cookie. Store failures answer
+// 500.
+func SessionMiddleware(store SessionStore, cookieName string) Middleware {
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			var session *Session
+			if id, ok := req.Cookie(cookieName); ok && id != "" {
+				loaded, found, err := store.Load(id)
+				if err != nil {
+					return withoutBodyForHead(req, internalServerErrorResponse())
+				}
+				if found {
+					session = loaded
+				}
+			}
+			created := session == nil
+			if created {
+				session = newSession()
+			}
+
+			ctx := context.WithValue(requestContext(req), sessionKey{}, session)
+			resp := safeInvoke(next, withRequestContext(req, ctx))
+			if err := store.Save(session); err != nil {
+				return withoutBodyForHead(req, internalServerErrorResponse())
+			}
+			if created && resp.Raw == nil {
+				resp.SetCookie(Cookie{
+					Name:     cookieName,
+					Value:    session.ID,
+					Path:     "/",
+					Secure:   true,
+					HttpOnly: true,
+					SameSite: SameSiteLax,
+				})
+			}
+			return resp
+		}
+	}
+}
+
+// SessionFromRequest returns the session attached by SessionMiddleware, or
+// nil when the middleware did not run.
+func SessionFromRequest(req *Request) *Session {
+	session, _ := requestContext(req).Value(sessionKey{}).(*Session)
+	return session
+}
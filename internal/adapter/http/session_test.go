@@ -0,0 +1,58 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSessionMiddleware_NewAndReturningSession verifies a new session sets a cookie and a returning one is loaded.
+func TestSessionMiddleware_NewAndReturningSession(t *testing.T) {
+	store := NewMemorySessionStore(time.Hour)
+	handler := SessionMiddleware(store, "sid")(func(req *Request) *Response {
+		session := SessionFromRequest(req)
+		visits, _ := session.Get("visits")
+		count, _ := visits.(int)
+		session.Set("visits", count+1)
+		return NewResponse().WriteString(strings.Repeat("v", count+1))
+	})
+
+	first := handler(&Request{Method: "GET", Path: "/", Headers: map[string]string{}})
+	setCookie := first.Headers["Set-Cookie"]
+	for _, want := range []string{"sid=", "Path=/", "HttpOnly", "Secure", "SameSite=Lax"} {
+		if !strings.Contains(setCookie, want) {
+			t.Fatalf("expected %q in Set-Cookie, got %q", want, setCookie)
+		}
+	}
+	id := strings.TrimPrefix(strings.SplitN(setCookie, ";", 2)[0], "sid=")
+	if len(id) != 64 {
+		t.Fatalf("expected a 64-character session id, got %q", id)
+	}
+
+	second := handler(&Request{Method: "GET", Path: "/", Headers: map[string]string{"cookie": "theme=dark; sid=" + id}})
+	if string(second.Body) != "vv" {
+		t.Fatalf("expected returning session to be loaded, got body %q", string(second.Body))
+	}
+	if _, ok := second.Headers["Set-Cookie"]; ok {
+		t.Fatalf("expected no Set-Cookie for a returning session, got %q", second.Headers["Set-Cookie"])
+	}
+}
+
+// TestMemorySessionStore_ExpiresAfterTTL verifies sessions expire once not saved within the TTL.
+func TestMemorySessionStore_ExpiresAfterTTL(t *testing.T) {
+	clock := newFakeClock()
+	store := NewMemorySessionStoreWithClock(time.Minute, clock)
+	session := newSession()
+	if err := store.Save(session); err != nil {
+		t.Fatalf("unexpected save error: %v", err)
+	}
+
+	clock.Advance(59 * time.Second)
+	if loaded, found, _ := store.Load(session.ID); !found || loaded != session {
+		t.Fatalf("expected session before TTL, got %v %v", loaded, found)
+	}
+	clock.Advance(time.Second)
+	if _, found, _ := store.Load(session.ID); found {
+		t.Fatalf("expected session to expire at TTL")
+	}
+}
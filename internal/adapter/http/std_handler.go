@@ -0,0 +1,189 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+)
+
+// AsHTTPHandler exposes the router as a net/http Handler, so it can be
+// mounted inside an existing net/http server. Each request is translated
+// into a Request carrying the incoming context, run through ServeRequest,
+// and the Response is written to the ResponseWriter. Repeated request
+// headers are joined with ", " under their lowercase name, as the parser
+// would see them on one line.
+func (r *Router) AsHTTPHandler() nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, stdReq *nethttp.Request) {
+		req, status := fromHTTPRequest(stdReq)
+		if status != 0 {
+			writeHTTPResponse(w, stdReq, withoutBodyForHead(req, statusResponse(status)))
+			return
+		}
+		writeHTTPResponse(w, stdReq, r.ServeRequest(req))
+	})
+}
+
+// fromHTTPRequest translates a net/http request into a Request. A non-zero
+// status reports a body that could not be read.
+func fromHTTPRequest(stdReq *nethttp.Request) (*Request, int) {
+	headers := make(map[string]string, len(stdReq.Header)+1)
+	for key, values := range stdReq.Header {
+		headers[strings.ToLower(key)] = strings.Join(values, ", ")
+	}
+	if stdReq.Host != "" {
+		headers["host"] = stdReq.Host
+	}
+
+	rawQuery := stdReq.URL.RawQuery
+	var query map[string][]string
+	if rawQuery != "" {
+		query, _ = ParseQuery(rawQuery, false)
+	}
+
+	req := &Request{
+		Ctx:        stdReq.Context(),
+		Method:     stdReq.Method,
+		Path:       stdReq.URL.Path,
+		RawPath:    stdReq.URL.EscapedPath(),
+		RawQuery:   rawQuery,
+		Query:      query,
+		RemoteAddr: stdReq.RemoteAddr,
+		Version:    stdReq.Proto,
+		Headers:    headers,
+	}
+	if addr, ok := stdReq.Context().Value(nethttp.LocalAddrContextKey).(net.Addr); ok {
+		req.LocalAddr = addr.String()
+	}
+	if stdReq.TLS != nil {
+		req.TLS = newTLSInfo(*stdReq.TLS)
+		req.ClientCertCN = verifiedClientCertCN(*stdReq.TLS)
+	}
+
+	if stdReq.Body != nil {
+		maxBody := currentServerOptions().ParserLimits.withDefaults().MaxBodyBytes
+		body, err := io.ReadAll(io.LimitReader(stdReq.Body, int64(maxBody)+1))
+		switch {
+		case err != nil:
+			return req, 400
+		case len(body) > maxBody:
+			return req, 413
+		}
+		req.Body = body
+	}
+	return req, 0
+}
+
+// writeHTTPResponse writes resp to w. Raw responses are parsed back into
+// status, headers, and body. A Stream that panics or fails aborts the
+// connection through net/http, since the status has already been sent.
+func writeHTTPResponse(w nethttp.ResponseWriter, stdReq *nethttp.Request, resp *Response) {
+	if resp.Raw != nil {
+		writeRawHTTPResponse(w, stdReq, resp.Raw)
+		return
+	}
+
+	if resp.Stream != nil && resp.deferHead {
+		sw := &stdStreamWriter{w: w, resp: resp}
+		if _, panicked, err := callStream(resp.Stream, sw); panicked || err != nil {
+			if !sw.headSent {
+				nethttp.Error(w, statusText(500), 500)
+				return
+			}
+			panic(nethttp.ErrAbortHandler)
+		}
+		sw.writeHead()
+		return
+	}
+
+	writeHTTPHead(w, resp)
+	if resp.Stream != nil {
+		if _, panicked, err := callStream(resp.Stream, w); panicked || err != nil {
+			panic(nethttp.ErrAbortHandler)
+		}
+	} else {
+		_, _ = w.Write(resp.Body)
+	}
+	header := w.Header()
+	for key, value := range resp.Trailers {
+		header.Set(key, value)
+	}
+}
+
+// writeHTTPHead copies the status and headers of resp to w.
+func writeHTTPHead(w nethttp.ResponseWriter, resp *Response) {
+	statusCode := resp.StatusCode
+	if statusCode == 0 {
+		statusCode = 200
+	}
+	header := w.Header()
+	for key := range resp.Headers {
+		header[key] = resp.HeaderValues(key)
+	}
+	if resp.Stream != nil {
+		// net/http frames the body itself.
+		for key := range header {
+			if strings.EqualFold(key, "Content-Length") {
+				delete(header, key)
+			}
+		}
+	}
+	for key := range resp.Trailers {
+		header.Add("Trailer", key)
+	}
+	w.WriteHeader(statusCode)
+}
+
+// stdStreamWriter writes the head of a deferred-head stream to a
+// net/http ResponseWriter on the first Write or Flush.
+type stdStreamWriter struct {
+	w        nethttp.ResponseWriter
+	resp     *Response
+	headSent bool
+}
+
+func (s *stdStreamWriter) writeHead() {
+	if !s.headSent {
+		s.headSent = true
+		writeHTTPHead(s.w, s.resp)
+	}
+}
+
+func (s *stdStreamWriter) Write(p []byte) (int, error) {
+	s.writeHead()
+	return s.w.Write(p)
+}
+
+// Flush sends the head and any buffered body to the client.
+func (s *stdStreamWriter) Flush() error {
+	s.writeHead()
+	if flusher, ok := s.w.(nethttp.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// writeRawHTTPResponse writes pre-serialized wire bytes through w.
+func writeRawHTTPResponse(w nethttp.ResponseWriter, stdReq *nethttp.Request, raw []byte) {
+	parsed, err := nethttp.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), stdReq)
+	if err != nil {
+		writeHTTPResponse(w, stdReq, internalServerErrorResponse())
+		return
+	}
+	defer parsed.Body.Close()
+
+	header := w.Header()
+	for key, values := range parsed.Header {
+		header[key] = values
+	}
+	if parsed.ContentLength >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(parsed.ContentLength, 10))
+	}
+	w.WriteHeader(parsed.StatusCode)
+	if _, err := io.Copy(w, parsed.Body); err != nil {
+		panic(nethttp.ErrAbortHandler)
+	}
+}
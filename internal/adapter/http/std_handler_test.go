@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type stdHandlerCtxKey struct{}
+
+// TestRouter_AsHTTPHandler verifies requests and responses translate through net/http.
+func TestRouter_AsHTTPHandler(t *testing.T) {
+	router := NewRouter()
+	router.Register("POST", "/users/:id", func(req *Request) *Response {
+		ctxValue, _ := req.Ctx.Value(stdHandlerCtxKey{}).(string)
+		return NewResponse().
+			Status(201).
+			Header("X-User", req.Params["id"]).
+			Header("X-Token", req.Headers["x-token"]).
+			Header("X-Ctx", ctxValue).
+			Header("X-Query", req.Query["q"][0]).
+			AddHeader("Set-Cookie", "a=1").
+			AddHeader("Set-Cookie", "b=2").
+			WriteBytes(append([]byte("echo:"), req.Body...))
+	})
+
+	stdReq := httptest.NewRequest("POST", "/users/42?q=go", strings.NewReader("payload"))
+	stdReq.Header.Set("X-Token", "secret")
+	stdReq = stdReq.WithContext(context.WithValue(stdReq.Context(), stdHandlerCtxKey{}, "carried"))
+	rec := httptest.NewRecorder()
+	router.AsHTTPHandler().ServeHTTP(rec, stdReq)
+
+	if rec.Code != 201 {
+		t.Fatalf("expected status 201, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != "echo:payload" {
+		t.Fatalf("expected echoed body, got %q", got)
+	}
+	for key, want := range map[string]string{"X-User": "42", "X-Token": "secret", "X-Ctx": "carried", "X-Query": "go"} {
+		if got := rec.Header().Get(key); got != want {
+			t.Fatalf("expected %s %q, got %q", key, want, got)
+		}
+	}
+	if got := rec.Header().Values("Set-Cookie"); !reflect.DeepEqual(got, []string{"a=1", "b=2"}) {
+		t.Fatalf("expected both Set-Cookie values, got %v", got)
+	}
+}
+
+// TestRouter_AsHTTPHandlerWritesRoutingErrorsAndRaw verifies 404s and raw responses reach the ResponseWriter.
+func TestRouter_AsHTTPHandlerWritesRoutingErrorsAndRaw(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/raw", RawResponseHandler([]byte("HTTP/1.1 202 Accepted\r\nX-Raw: yes\r\nContent-Length: 3\r\n\r\nraw")))
+	handler := router.AsHTTPHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	if rec.Code != nethttp.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/raw", nil))
+	if rec.Code != 202 || rec.Header().Get("X-Raw") != "yes" || rec.Body.String() != "raw" {
+		t.Fatalf("expected raw response to translate, got %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+}
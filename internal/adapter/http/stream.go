@@ -0,0 +1,166 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// errStreamWriteFailed is returned to a Response.Stream writer once the
+// connection has failed a write.
+var errStreamWriteFailed = errors.New("stream write failed")
+
+// writeStream writes a streamed response: the head, one chunk per Stream
+// write, and the last chunk. It reports whether the connection must close,
+// which is always the case once the body could not be completed.
+func (h *connHandler) writeStream(req *Request, resp *Response, closeConn bool) bool {
+	w := &chunkWriter{h: h, req: req, resp: resp}
+	if !resp.deferHead {
+		if !w.writeHead() {
+			return true
+		}
+		if w.discard {
+			return closeConn
+		}
+	}
+
+	recovered, panicked, err := callStream(resp.Stream, w)
+	switch {
+	case (panicked || err != nil) && !w.headSent:
+		// Nothing has reached the client, so it can still get a 500.
+		logError(h.opts.Logger, "stream failed before response was written",
+			"method", requestMethod(req),
+			"path", requestPath(req),
+			"remote_addr", h.remoteAddr(),
+			"panic", recovered,
+			"error", err,
+		)
+		failure := withoutBodyForHead(req, internalServerErrorResponse())
+		setConnectionHeader(failure, closeConn)
+		return !h.write(failure.Bytes()) || closeConn
+	case panicked:
+		logError(h.opts.Logger, "handler panicked after response was partially written",
+			"method", requestMethod(req),
+			"path", requestPath(req),
+			"remote_addr", h.remoteAddr(),
+			"panic", recovered,
+			"action", "close_connection",
+		)
+		return true
+	case w.failed:
+		return true
+	case err != nil:
+		logError(h.opts.Logger, "stream aborted",
+			"method", requestMethod(req),
+			"path", requestPath(req),
+			"remote_addr", h.remoteAddr(),
+			"error", err,
+		)
+		return true
+	}
+
+	if !w.headSent && !w.writeHead() {
+		return true
+	}
+	if w.discard {
+		return closeConn
+	}
+	var tail bytes.Buffer
+	resp.writeLastChunk(&tail)
+	return !h.write(tail.Bytes()) || closeConn
+}
+
+// callStream calls stream with w, converting a panic into its recovered value.
+func callStream(stream func(io.Writer) error, w io.Writer) (recovered any, panicked bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			recovered, panicked = r, true
+		}
+	}()
+	return nil, false, stream(w)
+}
+
+// bufferStream runs resp.Stream into Body for clients that cannot receive
+// chunked framing. Nothing has been written yet, so a panic or error still
+// yields a 500.
+func bufferStream(req *Request, resp *Response, opts ServerOptions) *Response {
+	var buf bytes.Buffer
+	recovered, panicked, err := callStream(resp.Stream, &buf)
+	if panicked || err != nil {
+		logError(opts.Logger, "stream failed before response was written",
+			"method", requestMethod(req),
+			"path", requestPath(req),
+			"panic", recovered,
+			"error", err,
+		)
+		return internalServerErrorResponse()
+	}
+	resp.Stream = nil
+	resp.deferHead = false
+	resp.Body = buf.Bytes()
+	return resp
+}
+
+// chunkWriter sends each Write as one chunk on the connection, writing the
+// head first when it was deferred.
+type chunkWriter struct {
+	h    *connHandler
+	req  *Request
+	resp *Response
+
+	headSent bool
+	// discard drops writes for responses that carry no body, such as HEAD.
+	discard bool
+	failed  bool
+}
+
+// writeHead sends the status line and headers once.
+func (w *chunkWriter) writeHead() bool {
+	w.headSent = true
+	w.discard = !responseHasBody(w.req, w.resp)
+	if !w.h.write(w.resp.Bytes()) {
+		w.failed = true
+		return false
+	}
+	return true
+}
+
+// Write frames p as a chunk and writes it; empty writes are skipped since a
+// zero-size chunk would end the body.
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	if w.failed {
+		return 0, errStreamWriteFailed
+	}
+	if !w.headSent && !w.writeHead() {
+		return 0, errStreamWriteFailed
+	}
+	if w.discard {
+		return len(p), nil
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	chunk := make([]byte, 0, len(p)+20)
+	chunk = strconv.AppendInt(chunk, int64(len(p)), 16)
+	chunk = append(chunk, "\r\n"...)
+	chunk = append(chunk, p...)
+	chunk = append(chunk, "\r\n"...)
+	if !w.h.write(chunk) {
+		w.failed = true
+		return 0, errStreamWriteFailed
+	}
+	return len(p), nil
+}
+
+// Flush sends the head if it is still pending. Chunks are written as they
+// are produced, so there is nothing else to flush.
+func (w *chunkWriter) Flush() error {
+	if w.failed {
+		return errStreamWriteFailed
+	}
+	if !w.headSent && !w.writeHead() {
+		return errStreamWriteFailed
+	}
+	return nil
+}
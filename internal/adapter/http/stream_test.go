@@ -0,0 +1,163 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	nethttp "net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveStreamRequest sends raw to a connection served by router and returns everything read until close.
+func serveStreamRequest(t *testing.T, router *Router, opts ServerOptions, raw string) string {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		HandleConnWithOptions(serverConn, router, context.Background(), opts)
+	}()
+
+	if _, err := clientConn.Write([]byte(raw)); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+	_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	respBytes, err := io.ReadAll(clientConn)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	<-done
+	return string(respBytes)
+}
+
+// TestResponseStream_WritesChunks verifies a streamed body is sent as chunks and ends with the last chunk.
+func TestResponseStream_WritesChunks(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/events", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.Stream = func(w io.Writer) error {
+			for _, part := range []string{"one", "", "three"} {
+				if _, err := io.WriteString(w, part); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return resp
+	})
+
+	resp := serveStreamRequest(t, router, ServerOptions{}, "GET /events HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+	head, body, _ := strings.Cut(resp, "\r\n\r\n")
+	if !strings.Contains(head, "Transfer-Encoding: chunked") || strings.Contains(head, "Content-Length") {
+		t.Fatalf("expected chunked framing without Content-Length, got %q", head)
+	}
+	if body != "3\r\none\r\n5\r\nthree\r\n0\r\n\r\n" {
+		t.Fatalf("unexpected chunked body %q", body)
+	}
+}
+
+// TestResponseStream_PanicAfterPartialWriteClosesConnection verifies a mid-stream panic aborts the connection and is logged.
+func TestResponseStream_PanicAfterPartialWriteClosesConnection(t *testing.T) {
+	logger := &stubLogger{}
+	router := NewRouter()
+	router.Register("GET", "/events", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.Stream = func(w io.Writer) error {
+			_, _ = io.WriteString(w, "hello")
+			panic("stream exploded")
+		}
+		return resp
+	})
+
+	// Keep-alive is requested, so only the abort can end the read.
+	resp := serveStreamRequest(t, router, ServerOptions{Logger: logger}, "GET /events HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	if strings.Count(resp, "HTTP/1.1 ") != 1 || !strings.HasPrefix(resp, "HTTP/1.1 200 OK\r\n") {
+		t.Fatalf("expected a single 200 status line, got %q", resp)
+	}
+	if !strings.HasSuffix(resp, "5\r\nhello\r\n") {
+		t.Fatalf("expected the stream to stop after the written chunk without a last chunk, got %q", resp)
+	}
+	if len(logger.entries) != 1 || !strings.Contains(logger.entries[0], "partially written") || !strings.Contains(logger.entries[0], "stream exploded") {
+		t.Fatalf("expected one partial-write panic log, got %v", logger.entries)
+	}
+}
+
+// TestResponseStream_BufferedForHTTP10 verifies HTTP/1.0 clients receive the stream with Content-Length.
+func TestResponseStream_BufferedForHTTP10(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/events", func(req *Request) *Response {
+		resp := NewResponse()
+		resp.Stream = func(w io.Writer) error {
+			_, err := io.WriteString(w, "buffered")
+			return err
+		}
+		return resp
+	})
+
+	resp := serveStreamRequest(t, router, ServerOptions{}, "GET /events HTTP/1.0\r\nHost: example.com\r\n\r\n")
+	if !strings.Contains(resp, "Content-Length: 8\r\n") || !strings.HasSuffix(resp, "\r\n\r\nbuffered") {
+		t.Fatalf("expected buffered body with Content-Length, got %q", resp)
+	}
+}
+
+// TestStreamHandler_ClientReassemblesChunks verifies a StreamHandler's writes arrive as chunks a client reassembles.
+func TestStreamHandler_ClientReassemblesChunks(t *testing.T) {
+	router := NewRouter()
+	router.Register("GET", "/export", StreamHandlerAdapter(func(w *ResponseWriter, req *Request) error {
+		w.WriteHeader(202)
+		w.SetHeader("Content-Type", "text/csv")
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		w.SetHeader("X-Late", "ignored")
+		for _, row := range []string{"id,name\n", "1,ada\n", "2,grace\n"} {
+			if _, err := io.WriteString(w, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	raw := serveStreamRequest(t, router, ServerOptions{}, "GET /export HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+	if !strings.Contains(raw, "\r\n\r\n8\r\nid,name\n\r\n6\r\n1,ada\n\r\n8\r\n2,grace\n\r\n0\r\n\r\n") {
+		t.Fatalf("expected one chunk per write, got %q", raw)
+	}
+	resp, err := nethttp.ReadResponse(bufio.NewReader(strings.NewReader(raw)), nil)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body failed: %v", err)
+	}
+	if resp.StatusCode != 202 || resp.Header.Get("Content-Type") != "text/csv" || resp.Header.Get("X-Late") != "" {
+		t.Fatalf("expected head set before the flush only, got %d %v", resp.StatusCode, resp.Header)
+	}
+	if string(body) != "id,name\n1,ada\n2,grace\n" {
+		t.Fatalf("expected reassembled body, got %q", string(body))
+	}
+}
+
+// TestStreamHandler_ErrorBeforeWriteAnswers500 verifies a failure before anything is sent still yields a 500.
+func TestStreamHandler_ErrorBeforeWriteAnswers500(t *testing.T) {
+	logger := &stubLogger{}
+	router := NewRouter()
+	router.Register("GET", "/export", StreamHandlerAdapter(func(w *ResponseWriter, req *Request) error {
+		w.SetHeader("Content-Type", "text/csv")
+		return errors.New("query failed")
+	}))
+
+	resp := serveStreamRequest(t, router, ServerOptions{Logger: logger}, "GET /export HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(resp, "HTTP/1.1 500 Internal Server Error\r\n") || strings.Contains(resp, "text/csv") {
+		t.Fatalf("expected a plain 500, got %q", resp)
+	}
+	if len(logger.entries) != 1 || !strings.Contains(logger.entries[0], "query failed") {
+		t.Fatalf("expected the failure to be logged, got %v", logger.entries)
+	}
+}
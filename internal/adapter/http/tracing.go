@@ -0,0 +1,139 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// TraceContext carries W3C trace-context values for a single request.
+type TraceContext struct {
+	TraceID    string
+	ParentID   string
+	Flags      string
+	TraceState string
+}
+
+// TraceParent renders the context as a W3C traceparent header value.
+func (tc TraceContext) TraceParent() string {
+	return "00-" + tc.TraceID + "-" + tc.ParentID + "-" + tc.Flags
+}
+
+// TraceExtractor reads an inbound trace context from a request.
+type TraceExtractor func(req *Request) (TraceContext, bool)
+
+// TraceInjector writes trace context details onto an outgoing response.
+type TraceInjector func(resp *Response, req *Request, tc TraceContext)
+
+type traceContextKey struct{}
+
+// TracingMiddleware extracts or starts a trace context, stores it on the request
+// context, and injects it into the response. Nil hooks fall back to W3C headers.
+func TracingMiddleware(extract TraceExtractor, inject TraceInjector) Middleware {
+	if extract == nil {
+		extract = ExtractW3CTraceContext
+	}
+	if inject == nil {
+		inject = InjectW3CTraceContext
+	}
+
+	return func(next HandlerAdapter) HandlerAdapter {
+		return func(req *Request) *Response {
+			tc, ok := extract(req)
+			if !ok {
+				tc = NewTraceContext()
+			}
+
+			ctx := context.WithValue(requestContext(req), traceContextKey{}, tc)
+			resp := safeInvoke(next, withRequestContext(req, ctx))
+			inject(resp, req, tc)
+			return resp
+		}
+	}
+}
+
+// TraceContextFromContext returns the trace context stored by TracingMiddleware.
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// NewTraceContext starts a new sampled trace with random trace and parent IDs.
+func NewTraceContext() TraceContext {
+	return TraceContext{
+		TraceID:  randomHex(16),
+		ParentID: randomHex(8),
+		Flags:    "01",
+	}
+}
+
+// ExtractW3CTraceContext parses the traceparent and tracestate request headers.
+func ExtractW3CTraceContext(req *Request) (TraceContext, bool) {
+	if req == nil || req.Headers == nil {
+		return TraceContext{}, false
+	}
+	tc, ok := parseTraceParent(req.Headers["traceparent"])
+	if !ok {
+		return TraceContext{}, false
+	}
+	tc.TraceState = strings.TrimSpace(req.Headers["tracestate"])
+	return tc, true
+}
+
+// InjectW3CTraceContext echoes traceparent/tracestate and a correlation id on the response.
+func InjectW3CTraceContext(resp *Response, req *Request, tc TraceContext) {
+	if resp == nil {
+		return
+	}
+	resp.SetHeader("traceparent", tc.TraceParent())
+	if tc.TraceState != "" {
+		resp.SetHeader("tracestate", tc.TraceState)
+	}
+
+	_, correlationID := requestIdentifiers(req)
+	if correlationID == "" {
+		correlationID = tc.TraceID
+	}
+	resp.SetHeader("X-Correlation-Id", correlationID)
+}
+
+// parseTraceParent validates a version-00 traceparent value.
+func parseTraceParent(value string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return TraceContext{}, false
+	}
+	if !isHexID(parts[1], 32) || !isHexID(parts[2], 16) || !isHexID(parts[3], 2) {
+		return TraceContext{}, false
+	}
+	return TraceContext{TraceID: parts[1], ParentID: parts[2], Flags: parts[3]}, true
+}
+
+// isHexID reports whether value is lowercase hex of the given length and not all zeros.
+func isHexID(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	allZero := true
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
+			return false
+		}
+		if c != '0' {
+			allZero = false
+		}
+	}
+	return length == 2 || !allZero
+}
+
+// randomHex returns n random bytes hex-encoded.
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
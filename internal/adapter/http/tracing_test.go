@@ -0,0 +1,68 @@
+package http
+
+import "testing"
+
+// TestTracingMiddleware_ParsesAndEchoesTraceParent verifies inbound trace context reaches handlers.
+func TestTracingMiddleware_ParsesAndEchoesTraceParent(t *testing.T) {
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var observed TraceContext
+
+	handler := TracingMiddleware(nil, nil)(func(req *Request) *Response {
+		tc, ok := TraceContextFromContext(req.Context())
+		if !ok {
+			t.Fatalf("expected trace context on request context")
+		}
+		observed = tc
+		return NewResponse()
+	})
+
+	resp := handler(&Request{
+		Method: "GET",
+		Path:   "/traced",
+		Headers: map[string]string{
+			"traceparent": traceParent,
+			"tracestate":  "vendor=value",
+		},
+	})
+
+	if observed.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected parsed trace id, got %q", observed.TraceID)
+	}
+	if observed.ParentID != "00f067aa0ba902b7" {
+		t.Fatalf("expected parsed parent id, got %q", observed.ParentID)
+	}
+	if observed.TraceState != "vendor=value" {
+		t.Fatalf("expected tracestate, got %q", observed.TraceState)
+	}
+	if got := resp.Headers["traceparent"]; got != traceParent {
+		t.Fatalf("expected echoed traceparent %q, got %q", traceParent, got)
+	}
+	if got := resp.Headers["X-Correlation-Id"]; got != observed.TraceID {
+		t.Fatalf("expected correlation id to fall back to trace id, got %q", got)
+	}
+}
+
+// TestTracingMiddleware_StartsNewTrace verifies a trace is started without inbound headers.
+func TestTracingMiddleware_StartsNewTrace(t *testing.T) {
+	var observed TraceContext
+	handler := TracingMiddleware(nil, nil)(func(req *Request) *Response {
+		observed, _ = TraceContextFromContext(req.Context())
+		return NewResponse()
+	})
+
+	resp := handler(&Request{
+		Method:  "GET",
+		Path:    "/new-trace",
+		Headers: map[string]string{"traceparent": "garbage", "x-correlation-id": "corr-1"},
+	})
+
+	if _, ok := parseTraceParent(observed.TraceParent()); !ok {
+		t.Fatalf("expected a valid generated traceparent, got %q", observed.TraceParent())
+	}
+	if got := resp.Headers["traceparent"]; got != observed.TraceParent() {
+		t.Fatalf("expected generated traceparent to be echoed, got %q", got)
+	}
+	if got := resp.Headers["X-Correlation-Id"]; got != "corr-1" {
+		t.Fatalf("expected inbound correlation id to be echoed, got %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jamalishaq/light_serve/internal/usecase"
+)
+
+// jsonLogger writes one JSON object per line to an io.Writer.
+type jsonLogger struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// NewJSONLogger creates a logger that writes each event as a JSON line with
+// level, msg, time, and the flattened key/value pairs.
+func NewJSONLogger(w io.Writer) usecase.Logger {
+	return &jsonLogger{w: w, now: time.Now}
+}
+
+// Debug logs diagnostic events.
+func (l *jsonLogger) Debug(msg string, keysAndValues ...any) {
+	l.write("DEBUG", msg, keysAndValues)
+}
+
+// Info logs informational events.
+func (l *jsonLogger) Info(msg string, keysAndValues ...any) {
+	l.write("INFO", msg, keysAndValues)
+}
+
+// Warn logs events that need attention but are not failures.
+func (l *jsonLogger) Warn(msg string, keysAndValues ...any) {
+	l.write("WARN", msg, keysAndValues)
+}
+
+// Error logs error events.
+func (l *jsonLogger) Error(msg string, keysAndValues ...any) {
+	l.write("ERROR", msg, keysAndValues)
+}
+
+// write encodes one event and writes it as a single line.
+func (l *jsonLogger) write(level, msg string, keysAndValues []any) {
+	if l == nil || l.w == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"level":`)
+	writeJSONValue(&buf, level)
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(&buf, msg)
+	buf.WriteString(`,"time":`)
+	writeJSONValue(&buf, l.now().UTC().Format(time.RFC3339Nano))
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := sanitizeKey(fmt.Sprint(keysAndValues[i]), i/2)
+		if key == "level" || key == "msg" || key == "time" {
+			key = "field_" + key
+		}
+		value := any("<missing>")
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		buf.WriteByte(',')
+		writeJSONValue(&buf, key)
+		buf.WriteByte(':')
+		writeJSONValue(&buf, value)
+	}
+	buf.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(buf.Bytes())
+}
+
+// writeJSONValue encodes value, rendering errors by message and falling back
+// to its fmt representation when it cannot be marshaled.
+func writeJSONValue(buf *bytes.Buffer, value any) {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case fmt.Stringer:
+		value = v.String()
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(encoded)
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestJSONLogger_EmitsParseableLine verifies level, msg, time, and fields are encoded.
+func TestJSONLogger_EmitsParseableLine(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := NewJSONLogger(&buffer).(*jsonLogger)
+	logger.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	logger.Error("request failed", "Status Code", 500, "error", errors.New("boom"), "msg", "shadowed")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &entry); err != nil {
+		t.Fatalf("expected valid JSON line, got %q: %v", buffer.String(), err)
+	}
+	want := map[string]any{
+		"level":       "ERROR",
+		"msg":         "request failed",
+		"time":        "2024-05-01T12:00:00Z",
+		"status_code": float64(500),
+		"error":       "boom",
+		"field_msg":   "shadowed",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Fatalf("expected %s=%v, got %v (entry %v)", key, value, entry[key], entry)
+		}
+	}
+	if !bytes.HasSuffix(buffer.Bytes(), []byte("}\n")) {
+		t.Fatalf("expected one newline-terminated object, got %q", buffer.String())
+	}
+}
+
+// TestJSONLogger_OddPairCountUsesMissingValue verifies missing values and fallback keys.
+func TestJSONLogger_OddPairCountUsesMissingValue(t *testing.T) {
+	var buffer bytes.Buffer
+	NewJSONLogger(&buffer).Info("startup", "", "first", "status")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &entry); err != nil {
+		t.Fatalf("expected valid JSON line, got %q: %v", buffer.String(), err)
+	}
+	if entry["level"] != "INFO" {
+		t.Fatalf("expected INFO level, got %v", entry["level"])
+	}
+	if entry["field_0"] != "first" {
+		t.Fatalf("expected field_0 fallback key, got %v", entry)
+	}
+	if entry["status"] != "<missing>" {
+		t.Fatalf("expected <missing> placeholder, got %v", entry["status"])
+	}
+}
@@ -19,30 +19,37 @@ func NewStdLogger(base *log.Logger) usecase.Logger {
 	return &stdLogger{base: base}
 }
 
+// Debug logs diagnostic events.
+func (l *stdLogger) Debug(msg string, keysAndValues ...any) {
+	l.write("DEBUG", msg, keysAndValues)
+}
+
 // Info logs informational events.
 func (l *stdLogger) Info(msg string, keysAndValues ...any) {
-	if l == nil || l.base == nil {
-		return
-	}
-	fields := formatKeyValues(keysAndValues...)
-	if fields == "" {
-		l.base.Printf("level=INFO msg=%q", msg)
-		return
-	}
-	l.base.Printf("level=INFO msg=%q %s", msg, fields)
+	l.write("INFO", msg, keysAndValues)
+}
+
+// Warn logs events that need attention but are not failures.
+func (l *stdLogger) Warn(msg string, keysAndValues ...any) {
+	l.write("WARN", msg, keysAndValues)
 }
 
 // Error logs error events.
 func (l *stdLogger) Error(msg string, keysAndValues ...any) {
+	l.write("ERROR", msg, keysAndValues)
+}
+
+// write prints one event at level.
+func (l *stdLogger) write(level, msg string, keysAndValues []any) {
 	if l == nil || l.base == nil {
 		return
 	}
 	fields := formatKeyValues(keysAndValues...)
 	if fields == "" {
-		l.base.Printf("level=ERROR msg=%q", msg)
+		l.base.Printf("level=%s msg=%q", level, msg)
 		return
 	}
-	l.base.Printf("level=ERROR msg=%q %s", msg, fields)
+	l.base.Printf("level=%s msg=%q %s", level, msg, fields)
 }
 
 // formatKeyValues renders key/value pairs into a log-friendly string.
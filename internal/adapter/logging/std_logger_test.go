@@ -21,6 +21,23 @@ func TestStdLogger_InfoWithoutFields(t *testing.T) {
 	}
 }
 
+// TestStdLogger_LevelPrefixes verifies each method logs at its own level.
+func TestStdLogger_LevelPrefixes(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := NewStdLogger(log.New(&buffer, "", 0))
+
+	logger.Debug("probe", "count", 2)
+	logger.Info("probe")
+	logger.Warn("probe")
+	logger.Error("probe")
+
+	want := []string{`level=DEBUG msg="probe" count=2`, `level=INFO msg="probe"`, `level=WARN msg="probe"`, `level=ERROR msg="probe"`}
+	got := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
 // TestFormatKeyValues_OddPairCountUsesMissingValue verifies missing values are explicit.
 func TestFormatKeyValues_OddPairCountUsesMissingValue(t *testing.T) {
 	fields := formatKeyValues("method", "GET", "status")
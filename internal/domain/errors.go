@@ -12,4 +12,12 @@ var (
 	ErrUnauthorized  = errors.New("unauthorized")
 	// ErrBadRequest indicates invalid domain input.
 	ErrBadRequest    = errors.New("bad request")
+	// ErrForbidden indicates the caller is known but may not access the resource.
+	ErrForbidden     = errors.New("forbidden")
+	// ErrConflict indicates the action conflicts with the current resource state.
+	ErrConflict      = errors.New("conflict")
+	// ErrValidation indicates well-formed input that violates domain rules.
+	ErrValidation    = errors.New("validation failed")
+	// ErrRateLimited indicates the caller has exceeded its allowed request rate.
+	ErrRateLimited   = errors.New("rate limited")
 )
@@ -0,0 +1,35 @@
+package usecase
+
+import "context"
+
+// ContextKey is the type of context keys set by adapters for use cases.
+type ContextKey string
+
+const (
+	// RequestIDKey carries the identifier of the current request.
+	RequestIDKey ContextKey = "request_id"
+	// CorrelationIDKey carries the identifier shared by related requests.
+	CorrelationIDKey ContextKey = "correlation_id"
+)
+
+// WithRequestID returns a child context carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, id)
+}
+
+// RequestIDFromContext returns the request ID, or "" when none is set.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// WithCorrelationID returns a child context carrying the correlation ID.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, CorrelationIDKey, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID, or "" when none is set.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(CorrelationIDKey).(string)
+	return id
+}
@@ -16,6 +16,9 @@ type RequestInput struct {
 }
 
 // ResponseOutput is the output from a use case. Transport-agnostic.
+// A zero StatusCode means 200; Headers without a Content-Type get text/plain.
 type ResponseOutput struct {
-	Body []byte
+	StatusCode int
+	Headers    map[string]string
+	Body       []byte
 }
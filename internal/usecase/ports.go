@@ -6,7 +6,9 @@ import "context"
 
 // Logger is a port for logging. Adapters implement this interface.
 type Logger interface {
+	Debug(msg string, keysAndValues ...any)
 	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
 	Error(msg string, keysAndValues ...any)
 }
 